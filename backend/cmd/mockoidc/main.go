@@ -0,0 +1,33 @@
+// cmd/mockoidc = OIDC issuer จำลองสำหรับทดสอบล็อกอินผ่านผู้ให้บริการภายนอกในเครื่อง
+//
+//	go run ./cmd/mockoidc -addr :9998
+//
+// ตัว issuer อยู่ที่ package oauth/mockoidc (เทสต์ใช้ตัวเดียวกันผ่าน httptest)
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"example.com/GROUB/oauth/mockoidc"
+)
+
+var (
+	addr         = flag.String("addr", ":9998", "listen address")
+	issuer       = flag.String("issuer", "http://localhost:9998", "issuer URL (ต้องตรงกับ OAUTH_<NAME>_ISSUER)")
+	clientID     = flag.String("client-id", "groub", "client id ที่ยอมรับ")
+	clientSecret = flag.String("client-secret", "groub-secret", "client secret (ว่าง = ไม่ตรวจ)")
+)
+
+func main() {
+	flag.Parse()
+
+	s, err := mockoidc.New(*issuer, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("mock OIDC issuer %s listening on %s", *issuer, *addr)
+	log.Fatal(http.ListenAndServe(*addr, s))
+}
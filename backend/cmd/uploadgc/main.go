@@ -0,0 +1,59 @@
+// cmd/uploadgc = เก็บกวาดไฟล์อัปโหลดที่ไม่มีใครอ้าง 1 รอบจาก command line (ใช้ DB/.env เดียวกับ server)
+//
+//	go run ./cmd/uploadgc -dry-run
+//	go run ./cmd/uploadgc -grace 48h -purge-after 336h
+//
+// รันในโฟลเดอร์ backend (groub.db, .env และ uploads อ้างแบบ relative) พิมพ์รายงานเป็น JSON
+// รันพร้อม server ได้: ใช้ lease เดียวกันใน DB ถ้า server (หรือ CLI อีกตัว) กำลังเก็บกวาดอยู่จะไม่ทำแล้วออกด้วย exit 2
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"os"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/storage"
+	"example.com/GROUB/uploadgc"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	def := uploadgc.DefaultOptions()
+	dryRun := flag.Bool("dry-run", false, "แค่รายงาน ไม่ย้าย/ลบไฟล์")
+	grace := flag.Duration("grace", def.Grace, "ไฟล์ต้องเก่ากว่านี้ถึงจะย้ายเข้า quarantine")
+	purgeAfter := flag.Duration("purge-after", def.PurgeAfter, "อยู่ใน quarantine นานเท่านี้แล้วลบจริง")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system env")
+	}
+	if err := storage.Setup(); err != nil {
+		log.Fatal(err)
+	}
+	config.ConnectionDB()
+	// ตาราง lease อาจยังไม่มีถ้ายังไม่เคยเปิด server รุ่นนี้
+	if err := config.DB().AutoMigrate(&entity.JobLock{}); err != nil {
+		log.Fatal(err)
+	}
+
+	rep, err := uploadgc.Sweep(context.Background(), config.DB(), storage.Default(), uploadgc.Options{
+		Grace:      *grace,
+		PurgeAfter: *purgeAfter,
+		DryRun:     *dryRun,
+	})
+	if errors.Is(err, uploadgc.ErrRunning) {
+		log.Println(err)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(rep)
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// DevAuthEnabled เปิดทางลัด auth สำหรับ dev (X-User-Id / Bearer uid:<id>)
+// ต้องตั้ง DEV_AUTH=true เองเท่านั้น ค่าเริ่มต้นคือปิด
+func DevAuthEnabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("DEV_AUTH"))) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// TrustedProxies = proxy ที่เชื่อ X-Forwarded-For / X-Real-IP ได้ (TRUSTED_PROXIES คั่นด้วย , รับ IP หรือ CIDR)
+// ไม่ตั้ง = ไม่เชื่อ header พวกนี้เลย ใช้ IP ที่ต่อเข้ามาตรง ๆ (กันปลอม IP หลบ rate limit / login lockout)
+func TrustedProxies() []string {
+	var out []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"example.com/GROUB/entity"
+	"example.com/GROUB/pricing"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -36,6 +41,15 @@ func SetupDatabase() {
 		&entity.Post_a_New_Product{},
 		&entity.Product{},
 		&entity.ProductImage{},
+		&entity.ProductOption{},
+		&entity.ProductOptionValue{},
+		&entity.ProductVariant{},
+		&entity.CategoryAttribute{},
+		&entity.CategoryAttributeOption{},
+		&entity.ProductAttributeValue{},
+		&entity.StockMovement{},
+		&entity.StockReservation{},
+		&entity.PriceChange{},
 		&entity.ShopAddress{},
 		&entity.ShopCategory{},
 		&entity.ShopProfile{},
@@ -45,6 +59,18 @@ func SetupDatabase() {
 		&entity.Discountcode{},
 		&entity.DiscountUsage{},
 		&entity.Order{},
+		&entity.OrderItem{},
+		&entity.Review{},
+		&entity.ReviewPhoto{},
+		&entity.Session{},
+		&entity.AuthToken{},
+		&entity.RecoveryCode{},
+		&entity.DataExport{},
+		&entity.ExternalIdentity{},
+		&entity.OAuthState{},
+		&entity.SellerAPIKey{},
+		&entity.AuditEvent{},
+		&entity.JobLock{},
 	); err != nil {
 		log.Fatal("AutoMigrate (base) failed:", err)
 	}
@@ -65,6 +91,54 @@ func SetupDatabase() {
 		_ = m.AddColumn(&entity.DMThread{}, "LastMessageAt")
 	}
 
+	// สมาชิกเก่าที่เป็นผู้ขายอยู่แล้ว -> role seller
+	if err := db.Model(&entity.Member{}).
+		Where("role = ? AND id IN (?)", entity.RoleBuyer,
+			db.Model(&entity.Seller{}).Select("member_id")).
+		Update("role", entity.RoleSeller).Error; err != nil {
+		log.Println("backfill seller role ล้มเหลว:", err)
+	}
+
+	// สินค้าเก่าก่อนมี variant: ช่วงราคา = ราคาเดียว
+	if err := db.Model(&entity.Product{}).
+		Where("price_max < price").
+		Update("price_max", gorm.Expr("price")).Error; err != nil {
+		log.Println("backfill price_max ล้มเหลว:", err)
+	}
+
+	// โพสต์เก่าก่อนมีสถานะ: เปิดขายตั้งแต่ตอนสร้าง
+	if err := db.Unscoped().Model(&entity.Post_a_New_Product{}).
+		Where("status = ? AND published_at IS NULL", entity.PostPublished).
+		UpdateColumn("published_at", gorm.Expr("created_at")).Error; err != nil {
+		log.Println("backfill published_at ล้มเหลว:", err)
+	}
+
+	// หมวดหมู่เก่าก่อนมีต้นไม้: เป็นหมวดบนสุดทั้งหมด
+	if err := db.Unscoped().Model(&entity.Category{}).
+		Where("path IS NULL OR path = ''").
+		UpdateColumns(map[string]interface{}{"path": gorm.Expr("'/' || id || '/'"), "depth": 0}).Error; err != nil {
+		log.Println("backfill category path ล้มเหลว:", err)
+	}
+
+	// สินค้าที่มีอยู่ก่อนเก็บประวัติราคา: ราคาตอนนี้เป็นแถวแรก
+	if err := pricing.Backfill(db, time.Now()); err != nil {
+		log.Println("backfill price history ล้มเหลว:", err)
+	}
+
+	// audit log: กันแก้/ลบที่ระดับ DB ด้วย (hook ของ gorm กันได้แค่ผ่าน ORM)
+	for _, stmt := range []string{
+		`CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+		 BEGIN SELECT RAISE(ABORT, 'audit_events is append-only'); END`,
+		`CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+		 BEGIN SELECT RAISE(ABORT, 'audit_events is append-only'); END`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			log.Println("สร้าง trigger audit ล้มเหลว:", err)
+		}
+	}
+
+	bootstrapAdmin()
+
 	// ====== Seed เดิมของคุณ ======
 	categories := []entity.ShopCategory{
 		{CategoryName: "เสื้อผ้าแฟชั่น"},
@@ -90,7 +164,7 @@ func SetupDatabase() {
 		{Name: "เกมมิ่งเกียร์"},
 	}
 	for _, cat := range categoriesProduct {
-		result := db.FirstOrCreate(&cat, entity.Category{Name: cat.Name})
+		result := db.Where("parent_id IS NULL").FirstOrCreate(&cat, entity.Category{Name: cat.Name})
 		if result.Error != nil {
 			log.Println("เพิ่ม Category สินค้าล้มเหลว:", result.Error)
 		} else {
@@ -111,3 +185,59 @@ func SetupDatabase() {
 		}
 	}
 }
+
+// bootstrapAdmin สร้าง/เลื่อนขั้นบัญชี admin จาก ADMIN_USERNAME + ADMIN_PASSWORD
+// - ถ้ามี username นี้อยู่แล้ว -> เปลี่ยน role เป็น admin (ไม่แตะรหัสผ่าน)
+// - ถ้ายังไม่มี -> สร้างใหม่ด้วย ADMIN_PASSWORD
+func bootstrapAdmin() {
+	username := os.Getenv("ADMIN_USERNAME")
+	if username == "" {
+		return
+	}
+
+	var m entity.Member
+	err := db.Where("LOWER(user_name) = LOWER(?)", username).First(&m).Error
+	if err == nil {
+		if m.Role != entity.RoleAdmin {
+			if err := db.Model(&m).Update("role", entity.RoleAdmin).Error; err != nil {
+				log.Println("เลื่อนขั้น admin ล้มเหลว:", err)
+				return
+			}
+			fmt.Println("เลื่อนขั้น admin:", m.UserName)
+		}
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Println("ค้นหา admin ล้มเหลว:", err)
+		return
+	}
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		log.Println("ไม่ได้สร้าง admin: ADMIN_PASSWORD ว่าง")
+		return
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Println("hash รหัส admin ล้มเหลว:", err)
+		return
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		p := entity.People{FirstName: "Admin", LastName: username}
+		if err := tx.Create(&p).Error; err != nil {
+			return err
+		}
+		m = entity.Member{
+			UserName: username,
+			Password: string(hashed),
+			Role:     entity.RoleAdmin,
+			PeopleID: p.ID,
+		}
+		return tx.Create(&m).Error
+	}); err != nil {
+		log.Println("สร้าง admin ล้มเหลว:", err)
+		return
+	}
+	fmt.Println("สร้าง admin:", username)
+}
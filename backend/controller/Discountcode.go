@@ -3,13 +3,11 @@ package controller
 import (
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"example.com/GROUB/config"
 	"example.com/GROUB/entity"
+	"example.com/GROUB/imaging"
 	"github.com/gin-gonic/gin"
 )
 
@@ -35,10 +33,6 @@ func parseTimePtr(iso string) (*time.Time, error) {
 	return nil, fmt.Errorf("invalid time format: %s", iso)
 }
 
-func ensureDir(p string) error {
-	return os.MkdirAll(p, 0755)
-}
-
 // baseURL เช่น http://localhost:8080
 func baseURL(c *gin.Context) string {
 	scheme := "http"
@@ -55,26 +49,21 @@ func saveImageAndReturnURL(c *gin.Context) (string, error) {
 		return "", nil // ไม่อัปก็ไม่เป็นไร
 	}
 
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	switch ext {
-	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
-	default:
-		// ปล่อยผ่านได้ แต่แนะนำ validate ฝั่งหน้าบ้านแล้ว
-	}
-
-	uploadDir := filepath.Join("uploads", "Discountcode")
-	if err := ensureDir(uploadDir); err != nil {
+	// ตรวจชนิดไฟล์จริง + ล้าง EXIF + ทำรูปย่อ (ไฟล์ไม่ใช่รูป = imaging.IsInvalid)
+	img, err := saveImage(c.Request.Context(), file, "Discountcode", "dc_")
+	if err != nil {
 		return "", err
 	}
 
-	filename := fmt.Sprintf("dc_%d%s", time.Now().UnixNano(), ext)
-	dst := filepath.Join(uploadDir, filename)
+	return baseURL(c) + img.URL, nil
+}
 
-	if err := c.SaveUploadedFile(file, dst); err != nil {
-		return "", err
+// สถานะตอบกลับเมื่อบันทึกรูปไม่สำเร็จ (ไฟล์ไม่ใช่รูป/ใหญ่เกิน = 400)
+func imageErrorStatus(err error) int {
+	if imaging.IsInvalid(err) {
+		return http.StatusBadRequest
 	}
-
-	return baseURL(c) + "/uploads/Discountcode/" + filename, nil
+	return http.StatusInternalServerError
 }
 
 func CreateDiscountCode(c *gin.Context) {
@@ -97,7 +86,7 @@ func CreateDiscountCode(c *gin.Context) {
 
 	imageURL, err := saveImageAndReturnURL(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "upload save failed", "error": err.Error()})
+		c.JSON(imageErrorStatus(err), gin.H{"message": "upload save failed", "error": err.Error()})
 		return
 	}
 
@@ -117,6 +106,7 @@ func CreateDiscountCode(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, auditEvent{Action: "discountcode.create", TargetType: "discountcode", TargetID: item.ID, After: item})
 	c.JSON(http.StatusCreated, gin.H{"data": item})
 }
 
@@ -137,6 +127,7 @@ func UpdateDiscountCode(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"message": "not found"})
 		return
 	}
+	before := code
 
 	var dto createDiscountDTO
 	if err := c.ShouldBind(&dto); err != nil {
@@ -158,7 +149,7 @@ func UpdateDiscountCode(c *gin.Context) {
 	// อัปเดตรูปใหม่ถ้ามีส่งมา
 	newURL, err := saveImageAndReturnURL(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "upload save failed", "error": err.Error()})
+		c.JSON(imageErrorStatus(err), gin.H{"message": "upload save failed", "error": err.Error()})
 		return
 	}
 
@@ -179,14 +170,19 @@ func UpdateDiscountCode(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "update failed", "error": err.Error()})
 		return
 	}
+	recordAudit(c, auditEvent{Action: "discountcode.update", TargetType: "discountcode", TargetID: code.ID,
+		Before: before, After: code})
 	c.JSON(http.StatusOK, gin.H{"data": code})
 }
 
 func DeleteDiscountCode(c *gin.Context) {
 	id := c.Param("id")
+	var before entity.Discountcode
+	_ = config.DB().First(&before, id).Error
 	if err := config.DB().Delete(&entity.Discountcode{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "delete failed", "error": err.Error()})
 		return
 	}
+	recordAudit(c, auditEvent{Action: "discountcode.delete", TargetType: "discountcode", TargetID: id, Before: before})
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
@@ -4,16 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
-	"encoding/base64"
-    "encoding/json"
 
 	"example.com/GROUB/config"
 	"example.com/GROUB/entity"
+	"example.com/GROUB/storage"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -34,82 +32,16 @@ func parseUintQuery(c *gin.Context, name string) (uint, error) {
 	return uint(id64), err
 }
 
-// ดึง userID จาก middleware (c.Set("userID", ...)) หรือโหมด dev ผ่าน Authorization: Bearer uid:<id>
-// เพิ่ม import:
-// "encoding/base64"
-// "encoding/json"
-
+// ดึง member_id ที่ middleware ตรวจแล้ว (Authz หรือ dev auth เมื่อเปิด DEV_AUTH)
 func authedUserID(c *gin.Context) (uint, bool) {
-	// 1) middleware ตั้งไว้
-	if v, ok := c.Get("userID"); ok {
-		switch t := v.(type) {
-		case uint:
-			if t > 0 { return t, true }
-		case int64:
-			if t > 0 { return uint(t), true }
-		case float64:
-			if t > 0 { return uint(t), true }
-		}
-	}
-
-	// 2) dev header ตรง ๆ
-	if x := strings.TrimSpace(c.GetHeader("X-User-Id")); x != "" {
-		if n, err := strconv.ParseUint(x, 10, 64); err == nil && n > 0 {
-			return uint(n), true
-		}
-	}
-
-	// 3) Authorization
-	auth := strings.TrimSpace(c.GetHeader("Authorization"))
-	if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
-		token := strings.TrimSpace(auth[7:])
-
-		// 3.1) รูปแบบ dev: Bearer uid:<id>
-		if strings.HasPrefix(token, "uid:") {
-			if n, err := strconv.ParseUint(strings.TrimPrefix(token, "uid:"), 10, 64); err == nil && n > 0 {
-				return uint(n), true
-			}
-		}
-
-		// 3.2) (ออปชัน) ถ้าเป็น JWT ลองอ่าน payload (dev only, no verify)
-		if strings.Count(token, ".") == 2 {
-			parts := strings.Split(token, ".") // header.payload.signature
-			if len(parts) == 3 {
-				// base64url decode
-				payload := parts[1]
-				if pad := len(payload) % 4; pad != 0 {
-					payload += strings.Repeat("=", 4-pad)
-				}
-				if b, err := base64.URLEncoding.DecodeString(payload); err == nil {
-					var claims map[string]any
-					if err := json.Unmarshal(b, &claims); err == nil {
-						// รองรับหลายคีย์: sub / id / user_id
-						if v, ok := claims["sub"]; ok {
-							if s := fmt.Sprint(v); s != "" {
-								if n, err := strconv.ParseUint(s, 10, 64); err == nil && n > 0 {
-									return uint(n), true
-								}
-							}
-						}
-						for _, k := range []string{"id", "user_id", "uid"} {
-							if v, ok := claims[k]; ok {
-								if s := fmt.Sprint(v); s != "" {
-									if n, err := strconv.ParseUint(s, 10, 64); err == nil && n > 0 {
-										return uint(n), true
-									}
-								}
-							}
-						}
-					}
-				}
-			}
-		}
+	v, ok := c.Get("member_id")
+	if !ok {
+		return 0, false
 	}
-
-	return 0, false
+	uid, ok := v.(uint)
+	return uid, ok && uid > 0
 }
 
-
 // ช่วยเช็กว่า user เป็นสมาชิกของห้องไหม
 func isThreadMember(db *gorm.DB, threadID, userID uint) (bool, *entity.DMThread) {
 	var th entity.DMThread
@@ -135,18 +67,17 @@ func OpenThread(c *gin.Context) {
 		return
 	}
 
-	// ใช้ user จาก auth เป็นหลัก (ถ้ามี), ถ้าไม่มีค่อย fallback ไป req.CurrentUserID
-	if uid, ok := authedUserID(c); ok {
-		if req.CurrentUserID != 0 && req.CurrentUserID != uid {
-			c.JSON(http.StatusForbidden, gin.H{"error": "actor mismatch"})
-			return
-		}
-		req.CurrentUserID = uid
+	// ใช้ user จาก auth เท่านั้น (currentUserId ถ้าส่งมาต้องตรงกัน)
+	uid, ok := authedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
 	}
-	if req.CurrentUserID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing current user"})
+	if req.CurrentUserID != 0 && req.CurrentUserID != uid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "actor mismatch"})
 		return
 	}
+	req.CurrentUserID = uid
 
 	db := config.DB()
 
@@ -216,23 +147,19 @@ func OpenThread(c *gin.Context) {
 
 // GET /api/dm/threads?memberId=1
 func ListThreads(c *gin.Context) {
-	// ถ้ามี user จาก auth ให้ใช้ค่านั้นเป็นหลัก (จะไม่ให้ดึงห้องของคนอื่น)
-	if uid, ok := authedUserID(c); ok && uid > 0 {
-		// memberID จาก query (ถ้าส่งมา) ต้องตรงกับ uid
-		if qidStr := c.Query("memberId"); qidStr != "" {
-			if qid, err := strconv.ParseUint(qidStr, 10, 64); err == nil && uint(qid) != uid {
-				c.JSON(http.StatusForbidden, gin.H{"error": "memberId mismatch"})
-				return
-			}
-		}
-		c.Request.URL.Query().Set("memberId", strconv.FormatUint(uint64(uid), 10))
-	}
-
-	memberID, err := parseUintQuery(c, "memberId")
-	if err != nil || memberID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "memberId required"})
+	// ใช้ user จาก auth เท่านั้น (จะไม่ให้ดึงห้องของคนอื่น)
+	memberID, ok := authedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
+	// memberId จาก query (ถ้าส่งมา) ต้องตรงกับ user ที่ล็อกอิน
+	if c.Query("memberId") != "" {
+		if qid, err := parseUintQuery(c, "memberId"); err != nil || qid != memberID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "memberId mismatch"})
+			return
+		}
+	}
 
 	db := config.DB()
 	var threads []entity.DMThread
@@ -293,13 +220,14 @@ func ListPosts(c *gin.Context) {
 		return
 	}
 	uid, ok := authedUserID(c)
-	if ok {
-		// ถ้ามี auth ต้องเป็นสมาชิกห้องเท่านั้น
-		db := config.DB()
-		if isMem, _ := isThreadMember(db, threadID, uid); !isMem {
-			c.JSON(http.StatusForbidden, gin.H{"error": "not a member of this thread"})
-			return
-		}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	// ต้องเป็นสมาชิกห้องเท่านั้น
+	if isMem, _ := isThreadMember(config.DB(), threadID, uid); !isMem {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a member of this thread"})
+		return
 	}
 
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
@@ -345,18 +273,17 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
-	// บังคับ sender ให้ตรงกับ auth ถ้ามี
-	if uid, ok := authedUserID(c); ok {
-		if req.SenderID != 0 && req.SenderID != uid {
-			c.JSON(http.StatusForbidden, gin.H{"error": "sender mismatch"})
-			return
-		}
-		req.SenderID = uid
+	// บังคับ sender ให้ตรงกับ auth
+	uid, ok := authedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
 	}
-	if req.SenderID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing sender"})
+	if req.SenderID != 0 && req.SenderID != uid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "sender mismatch"})
 		return
 	}
+	req.SenderID = uid
 
 	db := config.DB()
 
@@ -371,6 +298,16 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
+	// ไฟล์แนบต้องเป็นไฟล์ที่อัปโหลดผ่าน /api/dm/upload เท่านั้น (กันอ้าง key อื่นใน storage)
+	for i, a := range req.Attachments {
+		key, ok := storage.KeyFromURL(a.FileURL)
+		if !ok || !strings.HasPrefix(key, dmUploadDir+"/") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment"})
+			return
+		}
+		req.Attachments[i].FileURL = storage.URL(key)
+	}
+
 	post := entity.DMPost{
 		ThreadID: threadID,
 		SenderID: req.SenderID,
@@ -498,18 +435,17 @@ func MarkRead(c *gin.Context) {
 		return
 	}
 
-	// ยึด auth เป็นหลัก
-	if uid, ok := authedUserID(c); ok {
-		if req.MemberID != 0 && req.MemberID != uid {
-			c.JSON(http.StatusForbidden, gin.H{"error": "member mismatch"})
-			return
-		}
-		req.MemberID = uid
+	// ยึด auth เท่านั้น
+	uid, ok := authedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
 	}
-	if req.MemberID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing memberId"})
+	if req.MemberID != 0 && req.MemberID != uid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "member mismatch"})
 		return
 	}
+	req.MemberID = uid
 
 	db := config.DB()
 	if isMem, _ := isThreadMember(db, threadID, req.MemberID); !isMem {
@@ -526,7 +462,9 @@ func MarkRead(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "ok"})
 }
 
-/* ===================== Upload (save to ./uploads) ===================== */
+/* ===================== Upload (save to storage) ===================== */
+
+const dmUploadDir = "dm" // ใต้ /uploads
 
 // POST /api/dm/upload (multipart/form-data, field: file)
 func UploadFile(c *gin.Context) {
@@ -535,18 +473,21 @@ func UploadFile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "no file"})
 		return
 	}
-	_ = os.MkdirAll("./uploads", 0755)
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no file"})
+		return
+	}
+	defer src.Close()
 
-	filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(file.Filename))
-	dst := filepath.Join("./uploads", filename)
-	if err := c.SaveUploadedFile(file, dst); err != nil {
+	key := fmt.Sprintf("%s/%d_%s", dmUploadDir, time.Now().UnixNano(), filepath.Base(file.Filename))
+	if err := storage.Default().Put(c.Request.Context(), key, src, file.Size, file.Header.Get("Content-Type")); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "save failed"})
 		return
 	}
 
-	// เสิร์ฟผ่าน Static: /uploads/<filename>
-	url := "/uploads/" + filename
-	c.JSON(http.StatusOK, gin.H{"url": url})
+	// เสิร์ฟผ่าน /uploads/<key> (ดู ServeUpload)
+	c.JSON(http.StatusOK, gin.H{"url": storage.URL(key)})
 }
 
 /* ===================== Search users (optional) ===================== */
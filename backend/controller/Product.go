@@ -4,13 +4,16 @@ package controller
 import (
 	"errors"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"example.com/GROUB/config"
 	"example.com/GROUB/entity"
+	"example.com/GROUB/inventory"
+	"example.com/GROUB/pricing"
+	"example.com/GROUB/publishing"
+	"example.com/GROUB/trash"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -19,11 +22,21 @@ import (
 type CreateProductRequest struct {
 	Name        string   `json:"name" binding:"required"` // เดิม product_name -> name
 	Description string   `json:"description" binding:"required"`
-	Price       int      `json:"price" binding:"required,min=0"` // เดิม float64 -> int ให้ตรงกับ entity.Product
-	Quantity    int      `json:"quantity" binding:"required,min=0"`
+	Price       int      `json:"price" binding:"min=0"` // เดิม float64 -> int ให้ตรงกับ entity.Product (มี variant ไม่ต้องส่ง)
+	Quantity    int      `json:"quantity" binding:"min=0"`
 	CategoryID  uint     `json:"category_id" binding:"required"`
-	SellerID    uint     `json:"seller_id" binding:"required"`
 	Images      []string `json:"images" binding:"required,min=1"`
+
+	Options  []optionInput  `json:"options"`  // เช่น ไซซ์/สี
+	Variants []variantInput `json:"variants"` // ราคา/สต็อกแยกตาม SKU
+
+	// ข้อมูลจำเพาะตาม schema ของหมวด เช่น {"brand":"Apple","storage_gb":128}
+	Attributes map[string]interface{} `json:"attributes"`
+
+	// ว่าง = published (เปิดขายทันทีเหมือนเดิม) หรือ scheduled ถ้าส่ง publish_at มา
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
 }
 
 func CreateProduct(c *gin.Context) {
@@ -32,25 +45,119 @@ func CreateProduct(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ครบหรือรูปแบบไม่ถูกต้อง"})
 		return
 	}
+	// ผู้ขาย = เจ้าของ token / API key เสมอ (seller_id ใน body ไม่ใช้แล้ว กันโพสต์ในนามร้านอื่น)
+	seller, ok := currentSeller(c)
+	if !ok {
+		return
+	}
+	sellerID := seller.ID
+	// ไม่มี variant: ต้องส่งราคา/จำนวนเหมือนเดิม
+	if len(req.Variants) == 0 && (req.Price == 0 || req.Quantity == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ครบหรือรูปแบบไม่ถูกต้อง"})
+		return
+	}
+	if err := validateVariants(req.Options, req.Variants); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sched := publishing.Schedule{Status: req.Status, PublishAt: req.PublishAt, UnpublishAt: req.UnpublishAt}
+	if sched.Status == "" {
+		sched.Status = entity.PostPublished
+		if req.PublishAt != nil {
+			sched.Status = entity.PostScheduled
+		}
+	}
+	now := time.Now()
+	sched, err := sched.Normalize(now)
+	if err == nil && sched.Status != entity.PostDraft && sched.Status != entity.PostScheduled && sched.Status != entity.PostPublished {
+		err = errors.New("โพสต์ใหม่ต้องเป็น draft, scheduled หรือ published")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, v := range req.Variants {
+		if v.ID != 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "สินค้าใหม่ไม่ต้องส่ง variant id"})
+			return
+		}
+	}
+	schema, err := categoryAttributes(config.DB(), req.CategoryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่พบหมวดหมู่"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "สร้างสินค้าไม่สำเร็จ"})
+		return
+	}
+	attrVals, err := attributeValues(schema, req.Attributes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actor := contextMemberID(c)
+	if err := checkVariantRefs(config.DB(), 0, sellerID, req.Variants); err != nil {
+		if errors.Is(err, errSKUTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "สร้างสินค้าไม่สำเร็จ"})
+		return
+	}
 
-	// 1) สร้าง Product (ตาม entity.Product)
+	// 1) สร้าง Product (ตาม entity.Product) + ตัวเลือก/variant
 	product := entity.Product{
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
-		Quantity:    req.Quantity,
-		SellerID:    req.SellerID,
+		PriceMax:    req.Price,
+		SellerID:    sellerID,
 	}
-	if err := config.DB().Create(&product).Error; err != nil {
+	if err := config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		if err := saveAttributeValues(tx, product.ID, attrVals); err != nil {
+			return err
+		}
+		if err := tx.Preload("Attribute").Where("product_id = ?", product.ID).Order("attribute_id").Find(&product.Attributes).Error; err != nil {
+			return err
+		}
+		if len(req.Variants) == 0 {
+			// สต็อกตั้งต้นลงสมุดบัญชีเป็น restock
+			if _, err := inventory.Restock(tx, inventory.Line{ProductID: product.ID, Quantity: req.Quantity}, actor, "initial stock"); err != nil {
+				return err
+			}
+			product.Quantity = req.Quantity
+		} else {
+			if err := saveVariants(tx, product.ID, req.Options, req.Variants, actor); err != nil {
+				return err
+			}
+			if err := tx.Preload("Options.Values").Preload("Variants.OptionValues").Preload("Attributes.Attribute").First(&product, product.ID).Error; err != nil {
+				return err
+			}
+		}
+		// ราคาแรกของสินค้า (และทุก variant) ลงประวัติราคา
+		return pricing.Record(tx, product.ID, actor, entity.PriceSourceCreate)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "สร้างสินค้าไม่สำเร็จ"})
 		return
 	}
+	lowest := product.Price
+	product.LowestPrice30d = &lowest
 
 	// 2) สร้าง Post_a_New_Product (มีแต่ FK/ความสัมพันธ์เท่านั้น)
 	post := entity.Post_a_New_Product{
 		Product_ID:  &product.ID,
 		Category_ID: &req.CategoryID,
-		SellerID:    &req.SellerID,
+		SellerID:    &sellerID,
+		Status:      sched.Status,
+		PublishAt:   sched.PublishAt,
+		UnpublishAt: sched.UnpublishAt,
+	}
+	if post.Status == entity.PostPublished {
+		post.PublishedAt = &now
 	}
 	if err := config.DB().Create(&post).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "โพสต์สินค้าไม่สำเร็จ"})
@@ -72,6 +179,8 @@ func CreateProduct(c *gin.Context) {
 		}
 	}
 
+	reindexProducts(config.DB(), product.ID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "สร้างสินค้าสำเร็จ",
 		"product": product,
@@ -79,16 +188,9 @@ func CreateProduct(c *gin.Context) {
 	})
 }
 
+// GET /api/listAllProducts?category_id=&seller_id=&shop_category_id=&min_price=&max_price=&in_stock=&province=&attr[key]=&sort=&limit=&cursor=
 func ListAllProducts(c *gin.Context) {
-	var posts []entity.Post_a_New_Product
-
-	if err := config.DB().
-		Preload("Product.ProductImage").Preload("Category").Preload("Seller").Preload("Seller.ShopProfile").
-		Find(&posts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลสินค้าได้"})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"data": posts})
+	listProducts(c, nil)
 }
 
 // controller/post.go
@@ -117,10 +219,13 @@ func ListMyPostProducts(c *gin.Context) {
 	}
 	sellerID := m.Seller.ID
 
-	// 3) ดึงโพสต์สินค้าของ seller คนนี้
+	// 3) ดึงโพสต์สินค้าของ seller คนนี้ (ทุกสถานะ, ?status= กรองได้)
+	q := config.DB().Where("seller_id = ?", sellerID)
+	if st := c.Query("status"); st != "" {
+		q = q.Where("status = ?", st)
+	}
 	var posts []entity.Post_a_New_Product
-	if err := config.DB().
-		Where("seller_id = ?", sellerID).
+	if err := q.
 		Preload("Product.ProductImage").
 		Preload("Category").
 		Preload("Seller").
@@ -129,6 +234,8 @@ func ListMyPostProducts(c *gin.Context) {
 		return
 	}
 
+	fillLowestPrices(config.DB(), postProducts(posts)...)
+
 	c.JSON(http.StatusOK, gin.H{"data": posts})
 }
 
@@ -140,6 +247,12 @@ type UpdateProductReq struct {
 	Quantity    *int      `json:"quantity"`    // ตรงกับ entity.Product.Quantity (int)
 	CategoryID  *uint     `json:"category_id"` // อยู่ที่ post_a_new_products
 	Images      *[]string `json:"images"`      // ส่งมาถือว่า replace ทั้งชุด
+
+	Options  *[]optionInput  `json:"options"`  // ส่งมาต้องส่ง variants ด้วย
+	Variants *[]variantInput `json:"variants"` // ส่งมาถือว่า replace ทั้งชุด (variant เดิมอ้างด้วย id)
+
+	// ส่งมาถือว่า replace ทั้งชุด; ย้ายหมวดโดยไม่ส่ง = เก็บค่าที่หมวดใหม่ยังมี
+	Attributes *map[string]interface{} `json:"attributes"`
 }
 
 func UpdateProduct(c *gin.Context) {
@@ -184,6 +297,73 @@ func UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	// 3.1) ตัวเลือก/variant
+	var opts []optionInput
+	if in.Options != nil && in.Variants == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "แก้ตัวเลือกต้องส่ง variants มาด้วย"})
+		return
+	}
+	if in.Variants != nil {
+		if in.Options != nil {
+			opts = *in.Options
+		} else if o, err := loadOptionInputs(db, *post.Product_ID); err == nil {
+			opts = o
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validateVariants(opts, *in.Variants); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := checkVariantRefs(db, *post.Product_ID, sellerID, *in.Variants); err != nil {
+			if errors.Is(err, errSKUTaken) || errors.Is(err, inventory.ErrBelowReserved) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else if in.Price != nil || in.Quantity != nil {
+		// ราคา/สต็อกของสินค้าที่มี variant คำนวณจาก variant
+		var n int64
+		db.Model(&entity.ProductVariant{}).Where("product_id = ?", *post.Product_ID).Count(&n)
+		if n > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "สินค้านี้มีตัวเลือก ให้แก้ราคา/จำนวนที่ variants"})
+			return
+		}
+	}
+
+	// 3.2) ข้อมูลจำเพาะ: ตรวจกับ schema ของหมวด (หมวดใหม่ถ้าย้ายหมวด)
+	var attrVals []entity.ProductAttributeValue
+	saveAttrs := in.Attributes != nil || (in.CategoryID != nil && (post.Category_ID == nil || *in.CategoryID != *post.Category_ID))
+	if saveAttrs {
+		catID := derefUint(post.Category_ID)
+		if in.CategoryID != nil {
+			catID = *in.CategoryID
+		}
+		schema, err := categoryAttributes(db, catID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่พบหมวดหมู่"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var attrIn map[string]interface{}
+		if in.Attributes != nil {
+			attrIn = *in.Attributes
+		} else if attrIn, err = currentAttributeInput(db, *post.Product_ID, schema); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if attrVals, err = attributeValues(schema, attrIn); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// 4) เก็บรูปเก่า (ถ้าจะ replace)
 	var oldImgs []entity.ProductImage
 	if in.Images != nil {
@@ -203,9 +383,6 @@ func UpdateProduct(c *gin.Context) {
 		if in.Price != nil {
 			prodUpd["price"] = *in.Price
 		}
-		if in.Quantity != nil {
-			prodUpd["quantity"] = *in.Quantity
-		}
 
 		if len(prodUpd) > 0 {
 			if err := tx.Model(&entity.Product{}).
@@ -214,6 +391,21 @@ func UpdateProduct(c *gin.Context) {
 				return err
 			}
 		}
+		if in.Variants != nil {
+			if err := saveVariants(tx, *post.Product_ID, opts, *in.Variants, &memberID); err != nil {
+				return err
+			}
+		} else if in.Quantity != nil {
+			// จำนวนในคลังแก้ผ่าน inventory เพื่อให้มีประวัติและไม่ต่ำกว่าที่ถูกจอง
+			if _, err := inventory.SetQuantity(tx, inventory.Line{ProductID: *post.Product_ID, Quantity: *in.Quantity}, &memberID, ""); err != nil {
+				return err
+			}
+		}
+		if in.Variants == nil && in.Price != nil {
+			if err := syncVariantTotals(tx, *post.Product_ID); err != nil {
+				return err
+			}
+		}
 
 		// 5.2 อัปเดตตาราง post_a_new_products (เฉพาะ category_id)
 		postUpd := map[string]interface{}{}
@@ -228,6 +420,11 @@ func UpdateProduct(c *gin.Context) {
 				return err
 			}
 		}
+		if saveAttrs {
+			if err := saveAttributeValues(tx, *post.Product_ID, attrVals); err != nil {
+				return err
+			}
+		}
 
 		// 5.3 รูปภาพ: replace ทั้งชุดถ้าส่ง images มา
 		if in.Images != nil {
@@ -248,39 +445,30 @@ func UpdateProduct(c *gin.Context) {
 				}
 			}
 		}
-		return nil
+		// 5.4 ราคาที่เปลี่ยน (ของสินค้าหรือ variant) ลงประวัติราคา
+		return pricing.Record(tx, *post.Product_ID, &memberID, entity.PriceSourceUpdate)
 	}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(stockErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	reindexProducts(db, *post.Product_ID)
+
 	// 6) ลบไฟล์รูปเก่าหลัง commit (เฉพาะกรณี replace)
 	for _, im := range oldImgs {
-		safeRemoveUnder("uploads/products", im.ImagePath)
+		removeUnusedUnder(db, "products", im.ImagePath)
 	}
 
 	// 7) โหลดข้อมูลล่าสุดก่อนส่งกลับ
-	_ = db.Preload("Product.ProductImage").
+	_ = preloadAttributes(preloadVariants(db)).Preload("Product.ProductImage").
 		Preload("Category").
 		Preload("Seller").
 		First(&post, post.ID).Error
+	fillLowestPrices(db, &post.Product)
 
 	c.JSON(http.StatusOK, gin.H{"data": post})
 }
 
-// ลบไฟล์อย่างปลอดภัยเฉพาะใต้ baseDir
-func safeRemoveUnder(baseDir, p string) {
-	if p == "" {
-		return
-	}
-	p = strings.TrimPrefix(p, "/")
-	clean := filepath.Clean(p)
-	if !strings.HasPrefix(clean, baseDir+"/") && clean != baseDir {
-		return
-	}
-	_ = os.Remove(clean)
-}
-
 func GetPostProductByID(c *gin.Context) {
 	// 1) ตรวจ id ที่มากับ URL
 	idStr := c.Param("id")
@@ -311,7 +499,7 @@ func GetPostProductByID(c *gin.Context) {
 
 	// 3) ดึงโพสต์นี้ ที่ต้องเป็นของ seller คนนี้เท่านั้น (own-only)
 	var post entity.Post_a_New_Product
-	if err := config.DB().
+	if err := preloadAttributes(preloadVariants(config.DB())).
 		Where("id = ? AND seller_id = ?", uint(postID), sellerID).
 		Preload("Product.ProductImage").
 		Preload("Category").
@@ -321,10 +509,20 @@ func GetPostProductByID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": post})
+	// 4) สรุปคะแนนรีวิว (แยกตามจำนวนดาว)
+	rating, err := loadRatingSummary(config.DB(), "product_id", *post.Product_ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงคะแนนรีวิวได้"})
+		return
+	}
+
+	fillLowestPrices(config.DB(), &post.Product)
+
+	c.JSON(http.StatusOK, gin.H{"data": post, "rating": rating})
 }
 
 // GET /api/public/posts/:id
+// GET /api/shops/:sellerId/posts (รับ filter / sort / cursor เหมือน ListAllProducts)
 func ListPostsBySeller(c *gin.Context) {
 	sid := c.Param("sellerId")
 	sellerID, err := strconv.ParseUint(sid, 10, 64)
@@ -332,52 +530,39 @@ func ListPostsBySeller(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "seller id ไม่ถูกต้อง"})
 		return
 	}
-
-	var posts []entity.Post_a_New_Product
-	if err := config.DB().
-		Where("seller_id = ?", uint(sellerID)).
-		Preload("Product").
-		Preload("Product.ProductImage").
-		Preload("Category").
-		Find(&posts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "โหลดโพสต์ล้มเหลว"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"data": posts})
+	id := uint(sellerID)
+	listProducts(c, &id)
 }
 
 func SoftDeletePostWithProductAndImages(c *gin.Context) {
-	id := c.Param("id")
+	s, ok := currentSeller(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "post id ไม่ถูกต้อง"})
+		return
+	}
 	db := config.DB()
 
+	// ลบได้เฉพาะโพสต์ของร้านตัวเอง (ของร้านอื่นตอบเหมือนไม่มีโพสต์นี้)
+	var before entity.Post_a_New_Product // เก็บไว้ลง audit log
+	if err := db.Preload("Product").Where("id = ? AND seller_id = ?", id, s.ID).First(&before).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบโพสต์"})
+		return
+	}
+
+	now := time.Now()
 	if err := db.Transaction(func(tx *gorm.DB) error {
 		// 1) หาโพสต์ก่อน
 		var post entity.Post_a_New_Product
-		if err := tx.Select("id, product_id").First(&post, id).Error; err != nil {
+		if err := tx.Select("id, product_id").Where("seller_id = ?", s.ID).First(&post, id).Error; err != nil {
 			return err
 		}
 
-		// 2) ลบโพสต์ (soft delete)
-		if err := tx.Delete(&post).Error; err != nil {
-			return err
-		}
-
-		// 3) ถ้าโพสต์มี Product_ID
-		if post.Product_ID != nil {
-			// 3.1 ลบรูปทั้งหมดของ product (soft delete)
-			if err := tx.Where("product_id = ?", *post.Product_ID).
-				Delete(&entity.ProductImage{}).Error; err != nil {
-				return err
-			}
-
-			// 3.2 ลบตัว product (soft delete)
-			if err := tx.Delete(&entity.Product{}, *post.Product_ID).Error; err != nil {
-				return err
-			}
-		}
-
-		return nil
+		// 2) ย้ายโพสต์ + product + รูปทั้งหมดลงถังขยะ (soft delete เวลาเดียวกัน กู้คืนพร้อมกันได้)
+		return trash.Delete(tx, &post, now)
 	}); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบโพสต์"})
@@ -387,5 +572,9 @@ func SoftDeletePostWithProductAndImages(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "ลบโพสต์ สินค้า และรูปภาพเรียบร้อย (soft)"})
+	if before.Product_ID != nil {
+		reindexProducts(db, *before.Product_ID)
+	}
+	recordAudit(c, auditEvent{Action: "post.delete", TargetType: "post", TargetID: before.ID, Before: before})
+	c.JSON(http.StatusOK, gin.H{"message": "ลบโพสต์ สินค้า และรูปภาพเรียบร้อย (soft)", "purge_at": trash.PurgeAt(now)})
 }
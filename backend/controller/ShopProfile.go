@@ -5,9 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"example.com/GROUB/config"
@@ -101,6 +98,13 @@ func CreateSellerAndShop(c *gin.Context) {
 			return err
 		}
 
+		// 5) buyer -> seller (admin คงเดิม)
+		if err := tx.Model(&entity.Member{}).
+			Where("id = ? AND role = ?", memberID, entity.RoleBuyer).
+			Update("role", entity.RoleSeller).Error; err != nil {
+			return err
+		}
+
 		return nil
 	}); err != nil {
 		if err.Error() == "already a seller" {
@@ -200,7 +204,7 @@ func UpdateShopProfile(c *gin.Context) {
 
 	// หาโปรไฟล์จาก seller_id
 	var p entity.ShopProfile
-	if err := db.Preload("ShopAddress").
+	if err := db.Preload("ShopAddress").Preload("Category").
 		Where("seller_id = ?", in.SellerID).
 		First(&p).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
@@ -208,6 +212,7 @@ func UpdateShopProfile(c *gin.Context) {
 	}
 
 	oldLogo := p.LogoPath // เก็บ path เดิมไว้เทียบ
+	before := p
 
 	// ทำให้เป็น all-or-nothing
 	if err := db.Transaction(func(tx *gorm.DB) error {
@@ -269,14 +274,13 @@ func UpdateShopProfile(c *gin.Context) {
 
 	// ลบไฟล์โลโก้เก่า (ทำหลัง commit เท่านั้น)
 	if in.LogoPath != nil && oldLogo != *in.LogoPath {
-		clean := strings.TrimPrefix(filepath.Clean(oldLogo), "/") // ✅ S1017
-		if strings.HasPrefix(clean, "uploads/logo/") {            // safety guard
-			_ = os.Remove(clean)
-		}
+		safeRemoveUnder("logo", oldLogo) // รวมรูปย่อทุกขนาด เฉพาะใต้ logo/ เท่านั้น
 	}
 
 	// โหลดข้อมูลล่าสุดส่งกลับ
 	_ = db.Preload("ShopAddress").Preload("Category").First(&p, p.ID).Error
+	recordAudit(c, auditEvent{Action: "shop_profile.update", TargetType: "shop_profile", TargetID: p.ID,
+		Before: before, After: p})
 	c.JSON(http.StatusOK, gin.H{"data": p})
 }
 
@@ -297,5 +301,12 @@ func GetShopProfileBySellerID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": prof})
+	// คะแนนรวมของร้าน = รีวิวทุกสินค้าของร้าน (ไม่นับที่ถูกซ่อน)
+	rating, err := loadRatingSummary(db, "seller_id", *prof.SellerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ดึงข้อมูลร้านล้มเหลว"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": prof, "rating": rating})
 }
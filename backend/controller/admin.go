@@ -1,11 +1,15 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"example.com/GROUB/config"
 	"example.com/GROUB/entity"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func ListCategoies(c *gin.Context) {
@@ -20,25 +24,44 @@ func ListCategoies(c *gin.Context) {
 }
 
 type Category struct {
-	Name string `json:"name" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	ParentID *uint  `json:"parent_id"` // ว่าง = หมวดบนสุด
 }
 
 func CreateCategory(c *gin.Context) {
 	var req Category
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลสินค้าไม่ครบ"})
 		return
 	}
+	req.Name = strings.TrimSpace(req.Name)
+
+	db := config.DB()
+	if req.ParentID != nil {
+		var n int64
+		db.Model(&entity.Category{}).Where("id = ?", *req.ParentID).Count(&n)
+		if n == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบหมวดหมู่แม่"})
+			return
+		}
+	}
+	if categoryNameTaken(db, req.ParentID, req.Name, 0) {
+		c.JSON(http.StatusConflict, gin.H{"error": errCategoryNameTaken.Error()})
+		return
+	}
 
-	// 1. สร้าง Product
+	// 1. สร้าง Category (path/depth เติมใน AfterCreate)
 	category := entity.Category{
-		Name: req.Name,
+		Name:     req.Name,
+		ParentID: req.ParentID,
 	}
-	if err := config.DB().Create(&category).Error; err != nil {
+	if err := db.Create(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "เพิ่มหมวดหมู่สินค้าไม่สำเร็จ"})
 		return
 	}
 
+	recordAudit(c, auditEvent{Action: "category.create", TargetType: "category", TargetID: category.ID, After: category})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "สร้างหมวดหมู่สินค้าสำเร็จ",
 		"Category": category,
@@ -79,6 +102,8 @@ func CreateCShopCategory(c *gin.Context) {
         return
     }
 
+    recordAudit(c, auditEvent{Action: "shop_category.create", TargetType: "shop_category", TargetID: category.ID, After: category})
+
     c.JSON(http.StatusOK, gin.H{
         "message":  "สร้างหมวดหมู่สินค้าสำเร็จ",
         "category": category,
@@ -97,27 +122,104 @@ func UpdateCategory(c *gin.Context) {
 	}
 
 	db := config.DB()
+	var before entity.Category
+	if err := db.First(&before, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบหมวดหมู่"})
+		return
+	}
+	if categoryNameTaken(db, before.ParentID, req.Name, before.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": errCategoryNameTaken.Error()})
+		return
+	}
 	if err := db.Model(&entity.Category{}).
 		Where("id = ?", id).
 		Update("name", req.Name).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "อัปเดต category ไม่สำเร็จ"})
 		return
 	}
+	after := before
+	after.Name = req.Name
+	recordAudit(c, auditEvent{Action: "category.update", TargetType: "category", TargetID: id, Before: before, After: after})
 
 	c.JSON(http.StatusOK, gin.H{"message": "อัปเดต category สำเร็จ"})
 }
 
 // ----------- DELETE Category -----------
+// ?reassign_to=<id> ย้ายสินค้าในหมวดนี้ไปหมวดอื่นก่อนลบ; ไม่ระบุแล้วยังมีสินค้า/หมวดย่อย = 409
 func DeleteCategory(c *gin.Context) {
 	id := c.Param("id")
 
 	db := config.DB()
-	if err := db.Delete(&entity.Category{}, id).Error; err != nil {
+	var before entity.Category
+	if err := db.First(&before, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบหมวดหมู่"})
+		return
+	}
+
+	var target *entity.Category
+	if v := c.Query("reassign_to"); v != "" {
+		var t entity.Category
+		if err := db.First(&t, v).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบหมวดหมู่ปลายทาง"})
+			return
+		}
+		if t.ID == before.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "หมวดปลายทางต้องไม่ใช่หมวดที่จะลบ"})
+			return
+		}
+		target = &t
+	}
+
+	var moved int64
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		var children, posts int64
+		if err := tx.Model(&entity.Category{}).Where("parent_id = ?", before.ID).Count(&children).Error; err != nil {
+			return err
+		}
+		// นับโพสต์ที่อยู่ในถังขยะด้วย กันกู้คืนแล้วไม่มีหมวด
+		if err := tx.Unscoped().Model(&entity.Post_a_New_Product{}).Where("category_id = ?", before.ID).Count(&posts).Error; err != nil {
+			return err
+		}
+		if children > 0 || (posts > 0 && target == nil) {
+			return &categoryInUseError{children: children, posts: posts}
+		}
+		if target != nil && posts > 0 {
+			var err error
+			if moved, err = reassignCategoryPosts(tx, before.ID, target.ID); err != nil {
+				return err
+			}
+		}
+		// ข้อมูลจำเพาะของหมวดนี้ลบตามไปด้วย (ค่าที่สินค้าที่ย้ายออกไปกรอกไว้ถูกล้างใน prune)
+		attrs := tx.Model(&entity.CategoryAttribute{}).Select("id").Where("category_id = ?", before.ID)
+		if err := tx.Unscoped().Where("attribute_id IN (?)", attrs).Delete(&entity.CategoryAttributeOption{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("category_id = ?", before.ID).Delete(&entity.CategoryAttribute{}).Error; err != nil {
+			return err
+		}
+		if target != nil && posts > 0 {
+			if err := pruneAttributeValues(tx, target.Path); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&entity.Category{}, before.ID).Error
+	}); err != nil {
+		var inUse *categoryInUseError
+		if errors.As(err, &inUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": inUse.Error(), "children": inUse.children, "post_count": inUse.posts})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ลบ category ไม่สำเร็จ"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "ลบ category สำเร็จ"})
+	detail := ""
+	if target != nil && moved > 0 {
+		detail = fmt.Sprintf("ย้ายสินค้า %d รายการไปหมวด %d", moved, target.ID)
+	}
+	recordAudit(c, auditEvent{Action: "category.delete", Detail: detail, TargetType: "category", TargetID: id, Before: before})
+
+	c.JSON(http.StatusOK, gin.H{"message": "ลบ category สำเร็จ", "reassigned": moved})
 }
 
 // ----------- UPDATE ShopCategory -----------
@@ -133,12 +235,17 @@ func UpdateShopCategory(c *gin.Context) {
 	}
 
 	db := config.DB()
+	var before entity.ShopCategory
+	_ = db.First(&before, id).Error
 	if err := db.Model(&entity.ShopCategory{}).
 		Where("id = ?", id).
 		Update("category_name", req.CategoryName).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "อัปเดต shop category ไม่สำเร็จ"})
 		return
 	}
+	after := before
+	after.CategoryName = req.CategoryName
+	recordAudit(c, auditEvent{Action: "shop_category.update", TargetType: "shop_category", TargetID: id, Before: before, After: after})
 
 	c.JSON(http.StatusOK, gin.H{"message": "อัปเดต shop category สำเร็จ"})
 }
@@ -148,10 +255,50 @@ func DeleteShopCategory(c *gin.Context) {
 	id := c.Param("id")
 
 	db := config.DB()
+	var before entity.ShopCategory
+	_ = db.First(&before, id).Error
 	if err := db.Delete(&entity.ShopCategory{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ลบ shop category ไม่สำเร็จ"})
 		return
 	}
+	recordAudit(c, auditEvent{Action: "shop_category.delete", TargetType: "shop_category", TargetID: id, Before: before})
 
 	c.JSON(http.StatusOK, gin.H{"message": "ลบ shop category สำเร็จ"})
-}
\ No newline at end of file
+}
+// ----------- PUT /api/admin/members/:id/role -----------
+func SetMemberRole(c *gin.Context) {
+	id := c.Param("id")
+
+	var req struct {
+		Role string `json:"role" binding:"required,oneof=buyer seller admin"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role ต้องเป็น buyer, seller หรือ admin"})
+		return
+	}
+
+	db := config.DB()
+	var m entity.Member
+	if err := db.First(&m, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบสมาชิก"})
+		return
+	}
+
+	oldRole := m.Role
+
+	// role อยู่ใน JWT -> เปลี่ยนแล้วต้องเพิกถอน session เดิมให้ล็อกอินใหม่
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&m).Update("role", req.Role).Error; err != nil {
+			return err
+		}
+		return revokeAllSessions(tx, m.ID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "เปลี่ยน role ไม่สำเร็จ"})
+		return
+	}
+
+	recordAudit(c, auditEvent{Action: "member.role_change", TargetType: "member", TargetID: m.ID,
+		Before: gin.H{"role": oldRole}, After: gin.H{"role": req.Role}})
+
+	c.JSON(http.StatusOK, gin.H{"message": "เปลี่ยน role สำเร็จ", "id": m.ID, "role": req.Role})
+}
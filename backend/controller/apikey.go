@@ -0,0 +1,179 @@
+// controller/apikey.go
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiKeyPrefix     = "gsk_" // ให้สแกน secret ในโค้ดเจอง่าย
+	maxActiveAPIKeys = 20
+)
+
+// หา seller ของ member ที่ล็อกอินอยู่ (ยังไม่เป็นผู้ขาย -> 403)
+func currentSeller(c *gin.Context) (*entity.Seller, bool) {
+	mid, ok := c.Get("member_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return nil, false
+	}
+	var s entity.Seller
+	if err := config.DB().Where("member_id = ?", mid.(uint)).First(&s).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"message": "บัญชีนี้ยังไม่ได้เป็นผู้ขาย"})
+		return nil, false
+	}
+	return &s, true
+}
+
+// ---------- GET /api/seller/api-keys ----------
+
+func ListAPIKeys(c *gin.Context) {
+	s, ok := currentSeller(c)
+	if !ok {
+		return
+	}
+	var keys []entity.SellerAPIKey
+	if err := config.DB().Where("seller_id = ?", s.ID).Order("id DESC").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// ---------- POST /api/seller/api-keys ----------
+
+type CreateAPIKeyReq struct {
+	Name  string `json:"name"  binding:"required,min=1,max=100"`
+	Scope string `json:"scope" binding:"omitempty,oneof=read write"`
+}
+
+func CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+	s, ok := currentSeller(c)
+	if !ok {
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = entity.APIScopeRead
+	}
+
+	db := config.DB()
+	var n int64
+	if err := db.Model(&entity.SellerAPIKey{}).Where("seller_id = ? AND revoked_at IS NULL", s.ID).Count(&n).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+		return
+	}
+	if n >= maxActiveAPIKeys {
+		c.JSON(http.StatusConflict, gin.H{"message": "too many active api keys, revoke one first"})
+		return
+	}
+
+	raw, _, err := newOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "cannot create key"})
+		return
+	}
+	raw = apiKeyPrefix + raw
+
+	key := entity.SellerAPIKey{
+		SellerID: s.ID,
+		Name:     strings.TrimSpace(req.Name),
+		Scope:    req.Scope,
+		Prefix:   raw[:len(apiKeyPrefix)+8],
+		KeyHash:  hashToken(raw),
+	}
+	if err := db.Create(&key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "create key failed"})
+		return
+	}
+
+	// key ตัวจริงแสดงครั้งเดียวตอนสร้าง (DB เก็บแค่ hash)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "api key created, copy it now - it will not be shown again",
+		"key":     raw,
+		"data":    key,
+	})
+}
+
+// ---------- PATCH /api/seller/api-keys/:id ----------
+
+type UpdateAPIKeyReq struct {
+	Name  *string `json:"name"  binding:"omitempty,min=1,max=100"`
+	Scope *string `json:"scope" binding:"omitempty,oneof=read write"`
+}
+
+func UpdateAPIKey(c *gin.Context) {
+	var req UpdateAPIKeyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+	key, ok := findMyAPIKey(c)
+	if !ok {
+		return
+	}
+	if key.RevokedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"message": "api key already revoked"})
+		return
+	}
+
+	upd := map[string]interface{}{}
+	if req.Name != nil {
+		upd["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Scope != nil {
+		upd["scope"] = *req.Scope
+	}
+	if len(upd) > 0 {
+		if err := config.DB().Model(key).Updates(upd).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "update key failed"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": key})
+}
+
+// ---------- DELETE /api/seller/api-keys/:id ----------
+
+// เพิกถอนแต่เก็บแถวไว้ (ยังเห็นประวัติการใช้งาน)
+func RevokeAPIKey(c *gin.Context) {
+	key, ok := findMyAPIKey(c)
+	if !ok {
+		return
+	}
+	if key.RevokedAt == nil {
+		if err := config.DB().Model(key).Update("revoked_at", time.Now()).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "revoke key failed"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "api key revoked"})
+}
+
+func findMyAPIKey(c *gin.Context) (*entity.SellerAPIKey, bool) {
+	s, ok := currentSeller(c)
+	if !ok {
+		return nil, false
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid id"})
+		return nil, false
+	}
+	var key entity.SellerAPIKey
+	if err := config.DB().Where("id = ? AND seller_id = ?", id, s.ID).First(&key).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "api key not found"})
+		return nil, false
+	}
+	return &key, true
+}
@@ -0,0 +1,806 @@
+// controller/attribute.go
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxCategoryAttributes = 50
+	maxAttributeOptions   = 200
+	maxAttributeText      = 255
+)
+
+var attrKeyPattern = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+
+var (
+	errAttributeKeyTaken = errors.New("key นี้ถูกใช้กับหมวดแม่หรือหมวดลูกแล้ว")
+	errTooManyAttributes = fmt.Errorf("หมวดหนึ่งมีข้อมูลจำเพาะได้ไม่เกิน %d ช่อง", maxCategoryAttributes)
+)
+
+// ---------- schema ของหมวด ----------
+
+// categoryAttributes = attribute ที่ใช้กับหมวดนี้ รวมที่สืบทอดจากหมวดแม่ (หมวดบนก่อน แล้วตาม position)
+func categoryAttributes(db *gorm.DB, categoryID uint) ([]entity.CategoryAttribute, error) {
+	var cat entity.Category
+	if err := db.Select("id", "path").First(&cat, categoryID).Error; err != nil {
+		return nil, err
+	}
+	return chainAttributes(db, pathIDs(cat.Path))
+}
+
+// chainAttributes = attribute ของหมวดใน chain (เรียงจากบนลงล่างตามลำดับใน chain)
+func chainAttributes(db *gorm.DB, chain []uint) ([]entity.CategoryAttribute, error) {
+	attrs := []entity.CategoryAttribute{}
+	if len(chain) == 0 {
+		return attrs, nil
+	}
+	if err := db.Where("category_id IN ?", chain).
+		Preload("Options", func(tx *gorm.DB) *gorm.DB { return tx.Order("position, id") }).
+		Order("position, id").
+		Find(&attrs).Error; err != nil {
+		return nil, err
+	}
+	level := make(map[uint]int, len(chain))
+	for i, id := range chain {
+		level[id] = i
+	}
+	sort.SliceStable(attrs, func(i, j int) bool { return level[attrs[i].CategoryID] < level[attrs[j].CategoryID] })
+	return attrs, nil
+}
+
+// key ที่ชนกับ attribute ของหมวดใน chain หรือหมวดลูกหลานของ subtreePath (ไม่นับ exceptID)
+func attributeKeyTaken(db *gorm.DB, key string, chain []uint, subtreePath string, exceptID uint) (bool, error) {
+	var n int64
+	err := db.Model(&entity.CategoryAttribute{}).
+		Where("key = ? AND id <> ?", key, exceptID).
+		Where("(category_id IN ? OR category_id IN (?))", chain,
+			db.Model(&entity.Category{}).Select("id").Where("path LIKE ?", subtreePath+"%")).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// pruneAttributeValues ลบค่าของ attribute ที่ไม่ได้ใช้กับหมวดของสินค้าแล้ว (หลังย้ายหมวด/ย้ายสินค้า)
+// pathPrefix = path ของหมวดที่สินค้าถูกย้ายเข้าไป (ตรวจทั้ง subtree)
+func pruneAttributeValues(tx *gorm.DB, pathPrefix string) error {
+	return tx.Exec(`DELETE FROM product_attribute_values WHERE id IN (
+		SELECT v.id FROM product_attribute_values v
+		JOIN post_a_new_products p ON p.product_id = v.product_id
+		JOIN categories pc ON pc.id = p.category_id
+		LEFT JOIN category_attributes a ON a.id = v.attribute_id AND a.deleted_at IS NULL
+		LEFT JOIN categories ac ON ac.id = a.category_id
+		WHERE pc.path LIKE ? AND (ac.id IS NULL OR pc.path NOT LIKE ac.path || '%'))`, pathPrefix+"%").Error
+}
+
+// ---------- ค่าของสินค้า ----------
+
+// attributeValues ตรวจค่าที่ผู้ขายส่งมา ({"brand":"Apple","storage":128,"5g":true}) กับ schema ของหมวด
+// แล้วแปลงเป็นแถวที่จะบันทึก; null / "" = ไม่กรอก, key ที่หมวดนี้ไม่มี = error
+func attributeValues(schema []entity.CategoryAttribute, in map[string]interface{}) ([]entity.ProductAttributeValue, error) {
+	known := make(map[string]bool, len(schema))
+	for _, a := range schema {
+		known[a.Key] = true
+	}
+	seen := make(map[string]bool, len(in))
+	for k := range in {
+		nk := normKey(k)
+		if !known[nk] {
+			return nil, fmt.Errorf("หมวดนี้ไม่มีข้อมูลจำเพาะ %q", k)
+		}
+		if seen[nk] {
+			return nil, fmt.Errorf("ข้อมูลจำเพาะ %q ซ้ำ", k)
+		}
+		seen[nk] = true
+	}
+
+	out := make([]entity.ProductAttributeValue, 0, len(in))
+	for _, a := range schema {
+		raw := lookupAttribute(in, a.Key)
+		if s, ok := raw.(string); ok {
+			raw = strings.TrimSpace(s)
+		}
+		if raw == nil || raw == "" {
+			if a.Required {
+				return nil, fmt.Errorf("ต้องระบุ%s (%s)", a.Name, a.Key)
+			}
+			continue
+		}
+
+		v := entity.ProductAttributeValue{AttributeID: a.ID}
+		switch a.Type {
+		case entity.AttrEnum:
+			s, _ := raw.(string)
+			opt, ok := matchAttributeOption(a.Options, s)
+			if !ok {
+				return nil, fmt.Errorf("%s ต้องเป็นหนึ่งใน %v", a.Name, attributeOptionValues(a.Options))
+			}
+			v.Value = opt
+		case entity.AttrText:
+			s, ok := raw.(string)
+			if !ok || len([]rune(s)) > maxAttributeText {
+				return nil, fmt.Errorf("%s ต้องเป็นข้อความยาวไม่เกิน %d ตัวอักษร", a.Name, maxAttributeText)
+			}
+			v.Value = s
+		case entity.AttrNumber:
+			n, ok := attributeNumber(raw)
+			if !ok {
+				return nil, fmt.Errorf("%s ต้องเป็นตัวเลข", a.Name)
+			}
+			if (a.Min != nil && n < *a.Min) || (a.Max != nil && n > *a.Max) {
+				return nil, fmt.Errorf("%s ต้องอยู่ในช่วง %s", a.Name, numberRange(a.Min, a.Max, a.Unit))
+			}
+			v.Number = &n
+		case entity.AttrBool:
+			b, ok := raw.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%s ต้องเป็น true หรือ false", a.Name)
+			}
+			v.Bool = &b
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func lookupAttribute(m map[string]interface{}, key string) interface{} {
+	for k, v := range m {
+		if normKey(k) == key {
+			return v
+		}
+	}
+	return nil
+}
+
+// ตัวเลขจาก JSON (float64) หรือข้อความตัวเลข (ฟอร์มบางตัวส่งเป็น string)
+func attributeNumber(raw interface{}) (float64, bool) {
+	switch x := raw.(type) {
+	case float64:
+		return x, true
+	case string:
+		n, err := strconv.ParseFloat(x, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// คืนค่าตามตัวสะกดของ option (ไม่สนตัวพิมพ์ตอนเทียบ)
+func matchAttributeOption(opts []entity.CategoryAttributeOption, s string) (string, bool) {
+	for _, o := range opts {
+		if normKey(o.Value) == normKey(s) {
+			return o.Value, true
+		}
+	}
+	return "", false
+}
+
+func attributeOptionValues(opts []entity.CategoryAttributeOption) []string {
+	out := make([]string, len(opts))
+	for i, o := range opts {
+		out[i] = o.Value
+	}
+	return out
+}
+
+func numberRange(min, max *float64, unit string) string {
+	f := func(p *float64) string {
+		if p == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*p, 'f', -1, 64)
+	}
+	s := f(min) + ".." + f(max)
+	if unit != "" {
+		s += " " + unit
+	}
+	return s
+}
+
+// ค่าที่สินค้ามีอยู่ในรูปเดียวกับที่ผู้ขายส่งมา (เฉพาะ attribute ที่อยู่ใน schema) ใช้ตอนย้ายหมวดโดยไม่ส่ง attributes
+func currentAttributeInput(db *gorm.DB, productID uint, schema []entity.CategoryAttribute) (map[string]interface{}, error) {
+	keys := make(map[uint]string, len(schema))
+	for _, a := range schema {
+		keys[a.ID] = a.Key
+	}
+	var vals []entity.ProductAttributeValue
+	if err := db.Where("product_id = ?", productID).Find(&vals).Error; err != nil {
+		return nil, err
+	}
+	in := make(map[string]interface{}, len(vals))
+	for _, v := range vals {
+		key, ok := keys[v.AttributeID]
+		if !ok {
+			continue
+		}
+		switch {
+		case v.Number != nil:
+			in[key] = *v.Number
+		case v.Bool != nil:
+			in[key] = *v.Bool
+		default:
+			in[key] = v.Value
+		}
+	}
+	return in, nil
+}
+
+// saveAttributeValues แทนที่ค่าข้อมูลจำเพาะทั้งชุดของสินค้า
+func saveAttributeValues(tx *gorm.DB, productID uint, vals []entity.ProductAttributeValue) error {
+	if err := tx.Where("product_id = ?", productID).Delete(&entity.ProductAttributeValue{}).Error; err != nil {
+		return err
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+	for i := range vals {
+		vals[i].ID = 0
+		vals[i].ProductID = productID
+	}
+	return tx.Create(&vals).Error
+}
+
+func preloadAttributes(db *gorm.DB) *gorm.DB {
+	return db.Preload("Product.Attributes", func(tx *gorm.DB) *gorm.DB { return tx.Order("attribute_id") }).
+		Preload("Product.Attributes.Attribute")
+}
+
+// ---------- GET /api/categories/:id/attributes ----------
+// schema ที่ผู้ขายต้องกรอกเมื่อลงสินค้าในหมวดนี้ (inherited = มาจากหมวดแม่)
+
+type categoryAttributeView struct {
+	entity.CategoryAttribute
+	Inherited bool `json:"inherited"`
+}
+
+func ListCategoryAttributes(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category id ไม่ถูกต้อง"})
+		return
+	}
+	attrs, err := categoryAttributes(config.DB(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบหมวดหมู่"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ดึงข้อมูลจำเพาะของหมวดไม่สำเร็จ"})
+		return
+	}
+	out := make([]categoryAttributeView, len(attrs))
+	for i, a := range attrs {
+		out[i] = categoryAttributeView{CategoryAttribute: a, Inherited: a.CategoryID != id}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": out})
+}
+
+// ---------- admin: POST/PUT/DELETE /api/categories/:id/attributes[/:attrId] ----------
+
+type CategoryAttributeReq struct {
+	Key        string   `json:"key"` // แก้ไม่ได้หลังสร้าง
+	Name       string   `json:"name"`
+	Type       string   `json:"type"` // enum | number | text | boolean แก้ไม่ได้หลังสร้าง
+	Unit       string   `json:"unit"`
+	Min        *float64 `json:"min"`
+	Max        *float64 `json:"max"`
+	Required   bool     `json:"required"`
+	Filterable *bool    `json:"filterable"` // ไม่ส่ง = true (text เป็น false เสมอ)
+	Position   int      `json:"position"`
+	Options    []string `json:"options"` // เฉพาะ enum
+}
+
+// normalize trim + ตรวจรูปแบบ (ไม่แตะ DB)
+func (r *CategoryAttributeReq) normalize() error {
+	r.Key = normKey(r.Key)
+	r.Name = strings.TrimSpace(r.Name)
+	r.Unit = strings.TrimSpace(r.Unit)
+	if !attrKeyPattern.MatchString(r.Key) {
+		return errors.New("key ต้องเป็น a-z, 0-9 หรือ _ ยาวไม่เกิน 50 ตัวอักษร")
+	}
+	if r.Name == "" || len([]rune(r.Name)) > 100 {
+		return errors.New("ชื่อต้องไม่ว่างและยาวไม่เกิน 100 ตัวอักษร")
+	}
+	switch r.Type {
+	case entity.AttrEnum, entity.AttrNumber, entity.AttrText, entity.AttrBool:
+	default:
+		return fmt.Errorf("type ต้องเป็น %s, %s, %s หรือ %s", entity.AttrEnum, entity.AttrNumber, entity.AttrText, entity.AttrBool)
+	}
+	if r.Type != entity.AttrNumber && (r.Unit != "" || r.Min != nil || r.Max != nil) {
+		return errors.New("unit / min / max ใช้ได้กับ type number เท่านั้น")
+	}
+	if len([]rune(r.Unit)) > 20 {
+		return errors.New("unit ยาวไม่เกิน 20 ตัวอักษร")
+	}
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return errors.New("min ต้องไม่มากกว่า max")
+	}
+	if r.Type == entity.AttrText {
+		if r.Filterable != nil && *r.Filterable {
+			return errors.New("attribute แบบ text ใช้เป็น filter ไม่ได้")
+		}
+		f := false
+		r.Filterable = &f
+	}
+	if r.Type != entity.AttrEnum {
+		if len(r.Options) > 0 {
+			return errors.New("options ใช้ได้กับ type enum เท่านั้น")
+		}
+		return nil
+	}
+	if len(r.Options) == 0 || len(r.Options) > maxAttributeOptions {
+		return fmt.Errorf("enum ต้องมี options 1-%d ค่า", maxAttributeOptions)
+	}
+	seen := make(map[string]bool, len(r.Options))
+	for i, o := range r.Options {
+		o = strings.TrimSpace(o)
+		if o == "" || len([]rune(o)) > 50 {
+			return errors.New("ค่าใน options ต้องไม่ว่างและยาวไม่เกิน 50 ตัวอักษร")
+		}
+		if seen[normKey(o)] {
+			return fmt.Errorf("ค่า %q ใน options ซ้ำ", o)
+		}
+		seen[normKey(o)] = true
+		r.Options[i] = o
+	}
+	return nil
+}
+
+func (r CategoryAttributeReq) options() []entity.CategoryAttributeOption {
+	out := make([]entity.CategoryAttributeOption, len(r.Options))
+	for i, o := range r.Options {
+		out[i] = entity.CategoryAttributeOption{Value: o, Position: i}
+	}
+	return out
+}
+
+func CreateCategoryAttribute(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category id ไม่ถูกต้อง"})
+		return
+	}
+	var req CategoryAttributeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	if err := req.normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filterable := req.Filterable == nil || *req.Filterable
+
+	db := config.DB()
+	var cat entity.Category
+	if err := db.First(&cat, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบหมวดหมู่"})
+		return
+	}
+
+	attr := entity.CategoryAttribute{
+		CategoryID: cat.ID,
+		Key:        req.Key,
+		Name:       req.Name,
+		Type:       req.Type,
+		Unit:       req.Unit,
+		Min:        req.Min,
+		Max:        req.Max,
+		Required:   req.Required,
+		Filterable: filterable,
+		Position:   req.Position,
+		Options:    req.options(),
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&entity.CategoryAttribute{}).Where("category_id = ?", cat.ID).Count(&n).Error; err != nil {
+			return err
+		}
+		if n >= maxCategoryAttributes {
+			return errTooManyAttributes
+		}
+		taken, err := attributeKeyTaken(tx, req.Key, pathIDs(cat.Path), cat.Path, 0)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return errAttributeKeyTaken
+		}
+		return tx.Create(&attr).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errAttributeKeyTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, errTooManyAttributes):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "เพิ่มข้อมูลจำเพาะไม่สำเร็จ"})
+		}
+		return
+	}
+
+	recordAudit(c, auditEvent{Action: "category.attribute.create", TargetType: "category_attribute", TargetID: attr.ID, After: attr})
+	c.JSON(http.StatusOK, gin.H{"message": "เพิ่มข้อมูลจำเพาะสำเร็จ", "data": attr})
+}
+
+// ค่า/ช่วงที่ยังมีสินค้าใช้อยู่ แก้ schema ทับไม่ได้
+type attributeInUseError struct{ msg string }
+
+func (e *attributeInUseError) Error() string { return e.msg }
+
+// เปลี่ยน required เป็น true ได้ สินค้าเก่าที่ยังไม่กรอกต้องกรอกตอนแก้ไขครั้งถัดไป
+func UpdateCategoryAttribute(c *gin.Context) {
+	catID, err1 := parseUintParam(c, "id")
+	attrID, err2 := parseUintParam(c, "attrId")
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id ไม่ถูกต้อง"})
+		return
+	}
+	var req CategoryAttributeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+
+	db := config.DB()
+	var before entity.CategoryAttribute
+	if err := db.Preload("Options", func(tx *gorm.DB) *gorm.DB { return tx.Order("position, id") }).
+		Where("id = ? AND category_id = ?", attrID, catID).
+		First(&before).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบข้อมูลจำเพาะ"})
+		return
+	}
+	if (req.Key != "" && normKey(req.Key) != before.Key) || (req.Type != "" && req.Type != before.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "แก้ key หรือ type ไม่ได้ ให้ลบแล้วสร้างใหม่"})
+		return
+	}
+	req.Key, req.Type = before.Key, before.Type
+	if req.Filterable == nil {
+		req.Filterable = &before.Filterable
+	}
+	if err := req.normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	after := before
+	after.Name, after.Unit, after.Min, after.Max = req.Name, req.Unit, req.Min, req.Max
+	after.Required, after.Filterable, after.Position = req.Required, *req.Filterable, req.Position
+	after.Options = req.options()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		values := tx.Model(&entity.ProductAttributeValue{}).Where("attribute_id = ?", before.ID).Session(&gorm.Session{})
+		switch before.Type {
+		case entity.AttrEnum:
+			keep := make(map[string]string, len(req.Options))
+			for _, o := range req.Options {
+				keep[normKey(o)] = o
+			}
+			for _, o := range before.Options {
+				nk := normKey(o.Value)
+				if _, ok := keep[nk]; ok {
+					continue
+				}
+				var n int64
+				if err := values.Where("LOWER(value) = ?", nk).Count(&n).Error; err != nil {
+					return err
+				}
+				if n > 0 {
+					return &attributeInUseError{fmt.Sprintf("ค่า %q มีสินค้าใช้อยู่ %d รายการ ลบออกจาก options ไม่ได้", o.Value, n)}
+				}
+			}
+			// เปลี่ยนแค่ตัวพิมพ์เล็ก/ใหญ่: ตามไปแก้ค่าที่สินค้าเก็บไว้
+			for nk, o := range keep {
+				if err := tx.Model(&entity.ProductAttributeValue{}).
+					Where("attribute_id = ? AND LOWER(value) = ? AND value <> ?", before.ID, nk, o).
+					Update("value", o).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Unscoped().Where("attribute_id = ?", before.ID).Delete(&entity.CategoryAttributeOption{}).Error; err != nil {
+				return err
+			}
+			for i := range after.Options {
+				after.Options[i].AttributeID = before.ID
+			}
+			if err := tx.Create(&after.Options).Error; err != nil {
+				return err
+			}
+		case entity.AttrNumber:
+			if req.Min != nil || req.Max != nil {
+				q := values
+				switch {
+				case req.Min != nil && req.Max != nil:
+					q = q.Where("(number < ? OR number > ?)", *req.Min, *req.Max)
+				case req.Min != nil:
+					q = q.Where("number < ?", *req.Min)
+				default:
+					q = q.Where("number > ?", *req.Max)
+				}
+				var n int64
+				if err := q.Count(&n).Error; err != nil {
+					return err
+				}
+				if n > 0 {
+					return &attributeInUseError{fmt.Sprintf("มีสินค้า %d รายการที่ค่าอยู่นอกช่วง %s", n, numberRange(req.Min, req.Max, req.Unit))}
+				}
+			}
+		}
+		return tx.Model(&entity.CategoryAttribute{}).Where("id = ?", before.ID).
+			Updates(map[string]interface{}{
+				"name": after.Name, "unit": after.Unit, "min": after.Min, "max": after.Max,
+				"required": after.Required, "filterable": after.Filterable, "position": after.Position,
+			}).Error
+	})
+	if err != nil {
+		var inUse *attributeInUseError
+		if errors.As(err, &inUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": inUse.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "แก้ไขข้อมูลจำเพาะไม่สำเร็จ"})
+		return
+	}
+
+	recordAudit(c, auditEvent{Action: "category.attribute.update", TargetType: "category_attribute", TargetID: before.ID,
+		Before: before, After: after})
+	c.JSON(http.StatusOK, gin.H{"message": "แก้ไขข้อมูลจำเพาะสำเร็จ", "data": after})
+}
+
+// ลบ attribute พร้อมค่าที่สินค้ากรอกไว้ทั้งหมด
+func DeleteCategoryAttribute(c *gin.Context) {
+	catID, err1 := parseUintParam(c, "id")
+	attrID, err2 := parseUintParam(c, "attrId")
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id ไม่ถูกต้อง"})
+		return
+	}
+	db := config.DB()
+	var before entity.CategoryAttribute
+	if err := db.Preload("Options").Where("id = ? AND category_id = ?", attrID, catID).First(&before).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบข้อมูลจำเพาะ"})
+		return
+	}
+
+	var removed int64
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("attribute_id = ?", before.ID).Delete(&entity.ProductAttributeValue{})
+		if res.Error != nil {
+			return res.Error
+		}
+		removed = res.RowsAffected
+		if err := tx.Unscoped().Where("attribute_id = ?", before.ID).Delete(&entity.CategoryAttributeOption{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.CategoryAttribute{}, before.ID).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ลบข้อมูลจำเพาะไม่สำเร็จ"})
+		return
+	}
+
+	recordAudit(c, auditEvent{Action: "category.attribute.delete", TargetType: "category_attribute", TargetID: before.ID,
+		Detail: fmt.Sprintf("ลบค่าของสินค้า %d รายการ", removed), Before: before})
+	c.JSON(http.StatusOK, gin.H{"message": "ลบข้อมูลจำเพาะสำเร็จ", "removed_values": removed})
+}
+
+// ---------- filter / facet ในหน้ารายการสินค้า ----------
+
+// attrFilter = filter 1 key จาก ?attr[key]=...
+//
+//	enum    attr[brand]=apple,samsung (ค่าใดค่าหนึ่ง)
+//	number  attr[storage]=64..256, 64.., ..256 หรือ 128
+//	boolean attr[5g]=true
+type attrFilter struct {
+	Key    string
+	IDs    []uint // attribute ทุกตัวที่ใช้ key นี้ (หมวดที่ไม่เกี่ยวกันใช้ key ซ้ำได้)
+	Type   string
+	Values []string
+	Min    *float64
+	Max    *float64
+	Bool   *bool
+}
+
+// parseAttrFilters แปลง ?attr[key]=value เป็น filter (ต้องรู้ชนิดจาก DB)
+func parseAttrFilters(db *gorm.DB, raw map[string]string) ([]attrFilter, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, normKey(k))
+	}
+	var attrs []entity.CategoryAttribute
+	if err := db.Where("key IN ? AND filterable = ?", keys, true).Find(&attrs).Error; err != nil {
+		return nil, err
+	}
+	byKey := make(map[string][]entity.CategoryAttribute, len(attrs))
+	for _, a := range attrs {
+		byKey[a.Key] = append(byKey[a.Key], a)
+	}
+
+	out := make([]attrFilter, 0, len(raw))
+	for k, v := range raw {
+		key := normKey(k)
+		list := byKey[key]
+		if len(list) == 0 {
+			return nil, fmt.Errorf("ไม่มีข้อมูลจำเพาะ %q ที่ใช้กรองได้", k)
+		}
+		f := attrFilter{Key: key, Type: list[0].Type}
+		for _, a := range list {
+			if a.Type != f.Type {
+				return nil, fmt.Errorf("ข้อมูลจำเพาะ %q มีหลายชนิด", k)
+			}
+			f.IDs = append(f.IDs, a.ID)
+		}
+		v = strings.TrimSpace(v)
+		switch f.Type {
+		case entity.AttrEnum:
+			for _, s := range strings.Split(v, ",") {
+				if s = normKey(s); s != "" {
+					f.Values = append(f.Values, s)
+				}
+			}
+			if len(f.Values) == 0 {
+				return nil, fmt.Errorf("attr[%s] ไม่ถูกต้อง", k)
+			}
+		case entity.AttrNumber:
+			lo, hi, found := strings.Cut(v, "..")
+			if !found {
+				hi = lo
+			}
+			for _, p := range []struct {
+				s   string
+				dst **float64
+			}{{lo, &f.Min}, {hi, &f.Max}} {
+				if s := strings.TrimSpace(p.s); s != "" {
+					n, err := strconv.ParseFloat(s, 64)
+					if err != nil {
+						return nil, fmt.Errorf("attr[%s] ต้องเป็นตัวเลขหรือช่วง เช่น 64..256", k)
+					}
+					*p.dst = &n
+				}
+			}
+			if f.Min == nil && f.Max == nil {
+				return nil, fmt.Errorf("attr[%s] ต้องเป็นตัวเลขหรือช่วง เช่น 64..256", k)
+			}
+		case entity.AttrBool:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("attr[%s] ต้องเป็น true หรือ false", k)
+			}
+			f.Bool = &b
+		}
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+func (af attrFilter) apply(q *gorm.DB) *gorm.DB {
+	cond := "v.product_id = p.product_id AND v.attribute_id IN ?"
+	args := []interface{}{af.IDs}
+	switch af.Type {
+	case entity.AttrEnum:
+		cond += " AND LOWER(v.value) IN ?"
+		args = append(args, af.Values)
+	case entity.AttrNumber:
+		if af.Min != nil {
+			cond += " AND v.number >= ?"
+			args = append(args, *af.Min)
+		}
+		if af.Max != nil {
+			cond += " AND v.number <= ?"
+			args = append(args, *af.Max)
+		}
+	case entity.AttrBool:
+		cond += " AND v.bool = ?"
+		args = append(args, *af.Bool)
+	}
+	return q.Where("EXISTS (SELECT 1 FROM product_attribute_values v WHERE "+cond+")", args...)
+}
+
+type attrValueFacet struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type attributeFacet struct {
+	Key    string           `json:"key"`
+	Name   string           `json:"name"`
+	Type   string           `json:"type"`
+	Unit   string           `json:"unit,omitempty"`
+	Values []attrValueFacet `json:"values,omitempty"` // enum / boolean
+	Min    *float64         `json:"min,omitempty"`    // number: ช่วงค่าที่มีจริง
+	Max    *float64         `json:"max,omitempty"`
+	Count  int64            `json:"count"` // สินค้าที่กรอกช่องนี้
+}
+
+// attributeFacets นับ facet ของ attribute ที่ใช้กับทุกหมวดที่เลือก (ไม่เลือกหมวด = ไม่มี facet)
+// แบบ disjunctive เหมือน facet หมวด: นับ key ไหนไม่ใส่ filter ของ key นั้นเอง
+func attributeFacets(db *gorm.DB, f productListFilter) ([]attributeFacet, error) {
+	out := []attributeFacet{}
+	if len(f.CategoryIDs) == 0 {
+		return out, nil
+	}
+	var cats []entity.Category
+	if err := db.Select("id", "path").Where("id IN ?", f.CategoryIDs).Find(&cats).Error; err != nil {
+		return nil, err
+	}
+	if len(cats) == 0 {
+		return out, nil
+	}
+	// หมวดบรรพบุรุษร่วมของทุกหมวดที่เลือก
+	common := pathIDs(cats[0].Path)
+	for _, cat := range cats[1:] {
+		in := map[uint]bool{}
+		for _, id := range pathIDs(cat.Path) {
+			in[id] = true
+		}
+		kept := common[:0]
+		for _, id := range common {
+			if in[id] {
+				kept = append(kept, id)
+			}
+		}
+		common = kept
+	}
+	attrs, err := chainAttributes(db, common)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range attrs {
+		if !a.Filterable || a.Type == entity.AttrText {
+			continue
+		}
+		q := f.apply(listBase(db), "attr:"+a.Key).
+			Joins("JOIN product_attribute_values v ON v.product_id = p.product_id AND v.attribute_id = ?", a.ID)
+		fc := attributeFacet{Key: a.Key, Name: a.Name, Type: a.Type, Unit: a.Unit}
+		switch a.Type {
+		case entity.AttrNumber:
+			var r struct {
+				Min, Max *float64
+				N        int64
+			}
+			if err := q.Select("MIN(v.number) AS min, MAX(v.number) AS max, COUNT(*) AS n").Scan(&r).Error; err != nil {
+				return nil, err
+			}
+			fc.Min, fc.Max, fc.Count = r.Min, r.Max, r.N
+		case entity.AttrEnum, entity.AttrBool:
+			col := "v.value"
+			if a.Type == entity.AttrBool {
+				col = "CASE WHEN v.bool THEN 'true' ELSE 'false' END"
+			}
+			var rows []attrValueFacet
+			if err := q.Select(col + " AS value, COUNT(*) AS count").Group(col).Scan(&rows).Error; err != nil {
+				return nil, err
+			}
+			counts := make(map[string]int64, len(rows))
+			for _, r := range rows {
+				counts[r.Value] = r.Count
+				fc.Count += r.Count
+			}
+			// เรียงตาม options (รวมค่าที่ยังไม่มีสินค้า ให้หน้าเว็บแสดงครบ)
+			values := []string{"true", "false"}
+			if a.Type == entity.AttrEnum {
+				values = attributeOptionValues(a.Options)
+			}
+			fc.Values = make([]attrValueFacet, len(values))
+			for i, v := range values {
+				fc.Values[i] = attrValueFacet{Value: v, Count: counts[v]}
+			}
+		}
+		out = append(out, fc)
+	}
+	return out, nil
+}
@@ -0,0 +1,220 @@
+// controller/audit.go
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// auditEvent = ข้อมูลที่ handler ต้องระบุเอง ที่เหลือ (ผู้กระทำ / IP / UA) ดึงจาก context
+type auditEvent struct {
+	Action     string
+	Status     string // ว่าง = success
+	Detail     string
+	TargetType string
+	TargetID   interface{}
+	Before     interface{} // สถานะก่อนแก้ (nil = สร้างใหม่)
+	After      interface{} // สถานะหลังแก้ (nil = ลบ)
+
+	// ระบุผู้กระทำเองเมื่อยังไม่มีใน context (เช่น ตอน login / register)
+	Actor *entity.Member
+}
+
+// recordAudit เขียนไม่สำเร็จแค่ log ไว้ ไม่ทำให้ request ล้ม
+func recordAudit(c *gin.Context, e auditEvent) {
+	ev := entity.AuditEvent{
+		Action:     e.Action,
+		Status:     e.Status,
+		Detail:     truncate(e.Detail, 255),
+		TargetType: e.TargetType,
+		IP:         c.ClientIP(),
+		UserAgent:  truncate(c.Request.UserAgent(), 255),
+	}
+	if ev.Status == "" {
+		ev.Status = entity.AuditSuccess
+	}
+	if e.TargetID != nil {
+		ev.TargetID = fmt.Sprint(e.TargetID)
+	}
+
+	if e.Actor != nil {
+		if e.Actor.ID != 0 {
+			id := e.Actor.ID
+			ev.ActorID = &id
+		}
+		ev.ActorUsername = e.Actor.UserName
+		ev.ActorRole = e.Actor.Role
+	} else if mid, ok := c.Get("member_id"); ok {
+		id := mid.(uint)
+		ev.ActorID = &id
+		ev.ActorUsername = c.GetString("username")
+		ev.ActorRole = c.GetString("role")
+	}
+	if kid, ok := c.Get("api_key_id"); ok {
+		id := kid.(uint)
+		ev.APIKeyID = &id
+	}
+
+	if e.Before != nil || e.After != nil {
+		if d := auditDiff(e.Before, e.After); len(d) > 0 {
+			if b, err := json.Marshal(d); err == nil {
+				ev.Changes = b
+			}
+		}
+	}
+
+	if err := config.DB().Create(&ev).Error; err != nil {
+		log.Printf("audit %s: %v", e.Action, err)
+	}
+}
+
+type auditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// เทียบ before/after ผ่าน JSON (ฟิลด์ json:"-" เช่นรหัสผ่าน จึงไม่หลุดมาใน log)
+func auditDiff(before, after interface{}) map[string]auditChange {
+	b, a := flattenForAudit(before), flattenForAudit(after)
+	out := map[string]auditChange{}
+	for k, v := range b {
+		if w := a[k]; !reflect.DeepEqual(v, w) {
+			out[k] = auditChange{From: v, To: w}
+		}
+	}
+	for k, w := range a {
+		if _, ok := b[k]; !ok && w != nil {
+			out[k] = auditChange{To: w}
+		}
+	}
+	return out
+}
+
+// แปลง struct เป็น map แบน ๆ (ซ้อนกันใช้ a.b) ข้าม timestamp ของ gorm และ array ความสัมพันธ์
+func flattenForAudit(v interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	if v == nil {
+		return out
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return out
+	}
+	var m map[string]interface{}
+	if json.Unmarshal(raw, &m) != nil {
+		return out
+	}
+	var walk func(prefix string, m map[string]interface{})
+	walk = func(prefix string, m map[string]interface{}) {
+		for k, val := range m {
+			switch k {
+			case "CreatedAt", "UpdatedAt", "DeletedAt":
+				continue
+			}
+			switch x := val.(type) {
+			case map[string]interface{}:
+				walk(prefix+k+".", x)
+			case []interface{}:
+				// relation list ไม่เก็บ (ใหญ่และไม่ได้แก้ผ่าน endpoint เหล่านี้)
+			default:
+				out[prefix+k] = x
+			}
+		}
+	}
+	walk("", m)
+	return out
+}
+
+// ---------- GET /api/admin/audit-events ----------
+// filter: actor_id, action (ลงท้าย * = prefix เช่น auth.*), status, target_type, target_id, ip, from, to
+// แบ่งหน้า: page (เริ่ม 1), page_size (สูงสุด 200)
+
+func ListAuditEvents(c *gin.Context) {
+	q := config.DB().Model(&entity.AuditEvent{})
+
+	if v := c.Query("actor_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid actor_id"})
+			return
+		}
+		q = q.Where("actor_id = ?", id)
+	}
+	if v := c.Query("action"); v != "" {
+		if strings.HasSuffix(v, "*") {
+			q = q.Where("action LIKE ?", strings.TrimSuffix(v, "*")+"%")
+		} else {
+			q = q.Where("action = ?", v)
+		}
+	}
+	for _, f := range []string{"status", "target_type", "target_id", "ip"} {
+		if v := c.Query(f); v != "" {
+			q = q.Where(f+" = ?", v)
+		}
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := parseTimePtr(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid from", "error": err.Error()})
+			return
+		}
+		q = q.Where("created_at >= ?", *t)
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := parseTimePtr(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid to", "error": err.Error()})
+			return
+		}
+		if len(v) == len("2006-01-02") {
+			*t = t.Add(24 * time.Hour) // ใส่แค่วันที่ = รวมทั้งวันนั้น
+		}
+		q = q.Where("created_at < ?", *t)
+	}
+
+	page, size := pageParams(c, 50, 200)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "query failed"})
+		return
+	}
+	var events []entity.AuditEvent
+	if err := q.Order("id DESC").Offset((page - 1) * size).Limit(size).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "query failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      events,
+		"page":      page,
+		"page_size": size,
+		"total":     total,
+	})
+}
+
+// page / page_size จาก query (ค่าเพี้ยนใช้ค่าเริ่มต้น)
+func pageParams(c *gin.Context, def, max int) (page, size int) {
+	page, _ = strconv.Atoi(c.Query("page"))
+	if page < 1 {
+		page = 1
+	}
+	size, _ = strconv.Atoi(c.Query("page_size"))
+	if size < 1 {
+		size = def
+	}
+	if size > max {
+		size = max
+	}
+	return page, size
+}
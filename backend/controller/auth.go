@@ -1,8 +1,10 @@
 package controller
 
 import (
+	"errors"
+	"log"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 
 	"example.com/GROUB/config"
@@ -14,8 +16,10 @@ import (
 )
 
 type AuthClaims struct {
-	MemberID uint   `json:"member_id"`
-	Username string `json:"username"`
+	MemberID  uint   `json:"member_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID uint   `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -33,6 +37,9 @@ type RegisterDTO struct {
 	GenderID  *uint   `json:"genderID"`
 }
 
+// hash ของรหัสสุ่ม ใช้เทียบตอนไม่พบ username
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
 type LoginReq struct {
 	Username string `json:"username" binding:"required,min=1"`
 	Password string `json:"password" binding:"required,min=1"`
@@ -127,6 +134,7 @@ func Register(c *gin.Context) {
 		m = entity.Member{
 			UserName: req.Username,
 			Password: string(hashed),
+			Role:     entity.RoleBuyer,
 			PeopleID: p.ID,
 		}
 		if err := tx.Create(&m).Error; err != nil {
@@ -138,24 +146,23 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// ออก JWT
-	secret := os.Getenv("SECRET")
-	if secret == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "server misconfigured: SECRET not set"})
-		return
-	}
-	claims := AuthClaims{
-		MemberID: m.ID,
-		Username: m.UserName,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			// Issuer: "groub-api",
-		},
+	recordAudit(c, auditEvent{Action: "auth.register", TargetType: "member", TargetID: m.ID, Actor: &m,
+		After: gin.H{"username": m.UserName, "role": m.Role, "email": p.Email}})
+
+	// ส่งลิงก์ยืนยันอีเมล (ส่งไม่ผ่านก็ยังสมัครสำเร็จ ขอใหม่ได้ทีหลัง)
+	if p.Email != "" {
+		if err := sendVerifyEmail(m.ID, p.Email); err != nil {
+			log.Println("send verify mail:", err)
+		}
 	}
-	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := tok.SignedString([]byte(secret))
+
+	// ออก JWT + refresh token (ผูกกับ session ใหม่)
+	toks, err := issueTokens(c, m)
 	if err != nil {
+		if errors.Is(err, errSecretMissing) {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "server misconfigured: SECRET not set"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "cannot sign token"})
 		return
 	}
@@ -171,6 +178,7 @@ func Register(c *gin.Context) {
 		"user": gin.H{
 			"id":       m.ID,
 			"username": m.UserName,
+			"role":     m.Role,
 			"people": gin.H{
 				"id":        p.ID,
 				"firstName": p.FirstName,
@@ -183,7 +191,9 @@ func Register(c *gin.Context) {
 				"genderID":  p.GenderID,
 			},
 		},
-		"token": signed,
+		"token":         toks.AccessToken,
+		"refresh_token": toks.RefreshToken,
+		"expires_in":    int(accessTokenTTL.Seconds()),
 	})
 }
 
@@ -195,86 +205,104 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// ถูกล็อกจากการเดารหัสผ่านอยู่หรือไม่ (ทั้งต่อ username และต่อ IP)
+	keys := loginKeys(req.Username, c.ClientIP())
+	if wait := loginLimiter.retryAfter(keys...); wait > 0 {
+		secs := int(wait.Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(secs))
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": "too many failed attempts, try again later", "retry_after": secs})
+		recordAudit(c, auditEvent{Action: "auth.login", Status: entity.AuditFailure, Detail: "locked out",
+			Actor: &entity.Member{UserName: req.Username}})
+		return
+	}
+
 	db := config.DB()
 
 	// หา member + preload ความสัมพันธ์
 	var m entity.Member
-	if err := db.
+	err := db.
 		Preload("People").
 		Preload("Seller").
 		Preload("Seller.ShopProfile").
 		Where("LOWER(user_name) = LOWER(?)", req.Username).
-		First(&m).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "user not found"})
-			return
-		}
+		First(&m).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
 		return
 	}
 
-	// ตรวจรหัสผ่าน
-	if err := bcrypt.CompareHashAndPassword([]byte(m.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "password invalid"})
+	// ตรวจรหัสผ่าน (ไม่พบ user ก็เทียบกับ hash หลอก ให้เวลาตอบเท่ากัน)
+	hash := []byte(m.Password)
+	if err != nil {
+		hash = dummyPasswordHash
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(req.Password)) != nil || err != nil {
+		loginLimiter.fail(keys...)
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid username or password"})
+		ev := auditEvent{Action: "auth.login", Status: entity.AuditFailure, Detail: "invalid credentials",
+			Actor: &entity.Member{UserName: req.Username}}
+		if err == nil {
+			ev.Actor, ev.TargetType, ev.TargetID = &m, "member", m.ID
+		}
+		recordAudit(c, ev)
 		return
 	}
+	// สำเร็จ -> ล้างเฉพาะตัวนับของ username (ตัวนับ IP ปล่อยให้หมดอายุเอง)
+	loginLimiter.reset(keys[0])
 
-	// สถานะ seller/hasShop
-	var sellerID *uint
-	hasShop := false
-	if m.Seller.ID != 0 {
-		sellerID = &m.Seller.ID
-		if m.Seller.ShopProfile.ID != 0 {
-			hasShop = true
-		}
+	detail := "password"
+	if m.TOTPEnabled {
+		detail = "password ok, 2FA pending"
 	}
+	recordAudit(c, auditEvent{Action: "auth.login", Detail: detail, TargetType: "member", TargetID: m.ID, Actor: &m})
+
+	completeLogin(c, db, m)
+}
 
-	// ออก JWT
-	secret := os.Getenv("SECRET")
-	if secret == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "server misconfigured: SECRET is empty"})
+// ยืนยันตัวตนขั้นแรกผ่านแล้ว (รหัสผ่าน / ผู้ให้บริการภายนอก)
+// เปิด 2FA ไว้ -> ยังไม่ออก token ให้ยืนยันรหัสจากแอปก่อน (POST /api/login/2fa)
+func completeLogin(c *gin.Context, db *gorm.DB, m entity.Member) {
+	if m.TOTPEnabled && m.DeactivatedAt == nil {
+		challenge, err := createAuthToken(db, m.ID, entity.TokenMFAChallenge, "", mfaChallengeTTL)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"challenge":    challenge,
+			"expires_in":   int(mfaChallengeTTL.Seconds()),
+			"message":      "2FA code required",
+		})
 		return
 	}
-	claims := AuthClaims{
-		MemberID: m.ID,
-		Username: m.UserName,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+
+	respondLogin(c, m)
+}
+
+// ออก token แล้วตอบ payload แบบเดียวกับ Login (m ต้อง preload People, Seller, Seller.ShopProfile)
+func respondLogin(c *gin.Context, m entity.Member) {
+	if m.DeactivatedAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid username or password"})
+		return
 	}
-	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := tok.SignedString([]byte(secret))
+
+	// ออก JWT + refresh token (ผูกกับ session ใหม่)
+	toks, err := issueTokens(c, m)
 	if err != nil {
+		if errors.Is(err, errSecretMissing) {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "server misconfigured: SECRET is empty"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "token error"})
 		return
 	}
 
-	// format วันเกิด
-	birthday := ""
-	if !m.People.BirthDay.IsZero() {
-		birthday = m.People.BirthDay.Format("2006-01-02")
-	}
-
 	c.JSON(http.StatusOK, gin.H{
-		"user": gin.H{
-			"id":       m.ID,
-			"username": m.UserName,
-			"people": gin.H{
-				"id":        m.People.ID,
-				"firstName": m.People.FirstName,
-				"lastName":  m.People.LastName,
-				"email":     m.People.Email,
-				"age":       m.People.Age,
-				"phone":     m.People.Phone,
-				"birthday":  birthday,
-				"address":   m.People.Address,
-				"genderID":  m.People.GenderID,
-			},
-			"sellerID": sellerID, // null ถ้ายังไม่เป็นผู้ขาย
-			"hasShop":  hasShop,  // true เมื่อมี ShopProfile
-		},
-		"token":   signed,
-		"message": "Login success",
+		"user":          userPayload(m),
+		"token":         toks.AccessToken,
+		"refresh_token": toks.RefreshToken,
+		"expires_in":    int(accessTokenTTL.Seconds()),
+		"message":       "Login success",
 	})
 }
@@ -0,0 +1,555 @@
+// controller/catalog.go
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/inventory"
+	"example.com/GROUB/pricing"
+	"example.com/GROUB/spreadsheet"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxImportFileSize = 5 << 20
+	maxImportRows     = 1000
+	imageSeparator    = "|"
+)
+
+// คอลัมน์ของไฟล์นำเข้า/ส่งออก (post_id ว่าง = สร้างใหม่, มี = แก้โพสต์เดิม)
+// category ใช้ชื่อเต็มแบบ "แม่ > ลูก" (ตอนนำเข้าใส่ชื่อเดียวได้ถ้าไม่ซ้ำกับหมวดอื่น)
+var catalogColumns = []string{"post_id", "name", "description", "price", "quantity", "category", "images"}
+
+type importError struct {
+	Row     int    `json:"row"` // เลขแถวตามในไฟล์ (header = 1)
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+type importResult struct {
+	Row    int    `json:"row"`
+	Action string `json:"action"` // create | update
+	PostID uint   `json:"post_id,omitempty"`
+}
+
+type importRow struct {
+	Line        int
+	Name        string
+	Description string
+	Price       int
+	Quantity    int
+	CategoryID  uint
+	Images      []string
+
+	post        *entity.Post_a_New_Product // nil = สร้างใหม่
+	hasVariants bool
+}
+
+// ราคา/จำนวนจาก Excel อาจมาเป็น "100" หรือ "100.0"
+func parseWholeNumber(s string) (int, bool) {
+	s = strings.TrimSpace(strings.ReplaceAll(s, ",", ""))
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != float64(int(f)) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+func splitImages(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, imageSeparator) {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// รูปต้องเป็นไฟล์ที่อัปโหลดผ่าน /api/upload-Product แล้วเท่านั้น (ความเป็นเจ้าของดู foreignImagePaths)
+func validProductImagePath(p string) bool {
+	return strings.HasPrefix(p, "/uploads/products/") && path.Clean(p) == p
+}
+
+// foreignImagePaths คืน path ใน paths ที่แถวนอกร้านนี้อ้างอยู่ (รูปสินค้า/variant ร้านอื่น, รูปรีวิว)
+// ห้ามนำเข้า ไม่งั้นนำเข้ารอบถัดไปโดยตัดรูปออกจะลบไฟล์ของคนอื่นได้
+func foreignImagePaths(db *gorm.DB, sellerID uint, paths []string) (map[string]bool, error) {
+	out := map[string]bool{}
+	if len(paths) == 0 {
+		return out, nil
+	}
+	own := db.Unscoped().Model(&entity.Product{}).Select("id").Where("seller_id = ?", sellerID)
+	queries := []*gorm.DB{
+		db.Unscoped().Model(&entity.ProductImage{}).Where("image_path IN ? AND (product_id IS NULL OR product_id NOT IN (?))", paths, own),
+		db.Unscoped().Model(&entity.ProductVariant{}).Where("image_path IN ? AND product_id NOT IN (?)", paths, own),
+		db.Unscoped().Model(&entity.ReviewPhoto{}).Where("image_path IN ?", paths),
+	}
+	for _, q := range queries {
+		var found []string
+		if err := q.Distinct().Pluck("image_path", &found).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range found {
+			out[p] = true
+		}
+	}
+	return out, nil
+}
+
+func imagePaths(imgs []entity.ProductImage) []string {
+	out := make([]string, len(imgs))
+	for i, im := range imgs {
+		out[i] = im.ImagePath
+	}
+	return out
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseImport ตรวจทุกแถว คืนแถวที่ผ่าน + error รายแถว (ถ้ามี error ห้ามบันทึก)
+func parseImport(db *gorm.DB, sellerID uint, table [][]string) ([]importRow, []importError, error) {
+	var errs []importError
+	if len(table) == 0 {
+		return nil, []importError{{Row: 1, Message: "ไฟล์ว่าง"}}, nil
+	}
+
+	col := map[string]int{}
+	for i, h := range table[0] {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if h != "" {
+			col[h] = i
+		}
+	}
+	for _, name := range catalogColumns[1:] {
+		if _, ok := col[name]; !ok {
+			errs = append(errs, importError{Row: 1, Column: name, Message: "ไม่พบคอลัมน์นี้ในแถวหัวตาราง"})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs, nil
+	}
+	if len(table)-1 > maxImportRows {
+		return nil, []importError{{Row: maxImportRows + 2, Message: fmt.Sprintf("นำเข้าได้ไม่เกิน %d แถวต่อไฟล์", maxImportRows)}}, nil
+	}
+
+	labels, err := categoryLabels(db)
+	if err != nil {
+		return nil, nil, err
+	}
+	cats := newCategoryResolver(labels)
+
+	var posts []entity.Post_a_New_Product
+	if err := db.Preload("Product.ProductImage").Where("seller_id = ?", sellerID).Find(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+	postByID := make(map[uint]*entity.Post_a_New_Product, len(posts))
+	for i := range posts {
+		postByID[posts[i].ID] = &posts[i]
+	}
+	var variantProducts []uint
+	if err := db.Model(&entity.ProductVariant{}).Distinct().
+		Where("product_id IN (?)", db.Model(&entity.Product{}).Select("id").Where("seller_id = ?", sellerID)).
+		Pluck("product_id", &variantProducts).Error; err != nil {
+		return nil, nil, err
+	}
+	hasVariants := make(map[uint]bool, len(variantProducts))
+	for _, id := range variantProducts {
+		hasVariants[id] = true
+	}
+
+	var allImages []string
+	if j, ok := col["images"]; ok {
+		for _, rec := range table[1:] {
+			if j < len(rec) {
+				allImages = append(allImages, splitImages(rec[j])...)
+			}
+		}
+	}
+	foreign, err := foreignImagePaths(db, sellerID, allImages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []importRow
+	seenPost := map[uint]int{}
+	for i, rec := range table[1:] {
+		line := i + 2
+		if isBlankRecord(rec) {
+			continue
+		}
+		get := func(name string) string {
+			if j, ok := col[name]; ok && j < len(rec) {
+				return strings.TrimSpace(rec[j])
+			}
+			return ""
+		}
+		bad := func(column, msg string) {
+			errs = append(errs, importError{Row: line, Column: column, Message: msg})
+		}
+		before := len(errs)
+		r := importRow{Line: line, Name: get("name"), Description: get("description"), Images: splitImages(get("images"))}
+
+		if v := get("post_id"); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if p, ok := postByID[uint(id)]; err != nil || !ok {
+				bad("post_id", "ไม่พบโพสต์นี้ในร้านของคุณ")
+			} else if prev, dup := seenPost[p.ID]; dup {
+				bad("post_id", fmt.Sprintf("ซ้ำกับแถวที่ %d", prev))
+			} else {
+				seenPost[p.ID] = line
+				r.post = p
+				r.hasVariants = p.Product_ID != nil && hasVariants[*p.Product_ID]
+			}
+		}
+
+		if r.Name == "" || len([]rune(r.Name)) > 200 {
+			bad("name", "ต้องไม่ว่างและยาวไม่เกิน 200 ตัวอักษร")
+		}
+		if r.Description == "" {
+			bad("description", "ต้องไม่ว่าง")
+		}
+		if n, ok := parseWholeNumber(get("price")); !ok || n <= 0 {
+			bad("price", "ต้องเป็นจำนวนเต็มมากกว่า 0")
+		} else {
+			r.Price = n
+		}
+		if n, ok := parseWholeNumber(get("quantity")); !ok || n < 0 {
+			bad("quantity", "ต้องเป็นจำนวนเต็มไม่ติดลบ")
+		} else {
+			r.Quantity = n
+		}
+		if id, err := cats.resolve(get("category")); err != nil {
+			bad("category", err.Error())
+		} else {
+			r.CategoryID = id
+		}
+
+		// รูปเดิมที่ไม่ได้แก้ไม่ต้องตรวจ path ซ้ำ (ข้อมูลเก่าอาจไม่ตรงรูปแบบ)
+		var oldImages []string
+		if r.post != nil {
+			oldImages = imagePaths(r.post.Product.ProductImage)
+		}
+		if len(r.Images) == 0 {
+			bad("images", "ต้องมีรูปอย่างน้อย 1 รูป (คั่นหลายรูปด้วย "+imageSeparator+")")
+		} else if !sameStrings(r.Images, oldImages) {
+			for _, p := range r.Images {
+				if !validProductImagePath(p) {
+					bad("images", fmt.Sprintf("path รูป %q ต้องขึ้นต้นด้วย /uploads/products/", p))
+					break
+				}
+				if foreign[p] && !containsString(oldImages, p) {
+					bad("images", fmt.Sprintf("รูป %q เป็นของร้านอื่น อัปโหลดรูปใหม่แทน", p))
+					break
+				}
+			}
+		}
+
+		// สินค้าที่มี variant: ราคา/จำนวนมาจาก variant แก้ผ่านไฟล์ไม่ได้ (ค่าเดิมที่ export มาผ่านได้)
+		if r.hasVariants && len(errs) == before &&
+			(r.Price != r.post.Product.Price || r.Quantity != r.post.Product.Quantity) {
+			bad("price", "สินค้านี้มีตัวเลือก แก้ราคา/จำนวนที่หน้าสินค้า")
+		}
+
+		if len(errs) == before {
+			rows = append(rows, r)
+		}
+	}
+	if len(rows) == 0 && len(errs) == 0 {
+		errs = append(errs, importError{Row: 2, Message: "ไม่มีข้อมูลสินค้า"})
+	}
+	return rows, errs, nil
+}
+
+func isBlankRecord(rec []string) bool {
+	for _, v := range rec {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// applyImport บันทึกทุกแถวใน transaction เดียว คืนผลรายแถว + product id ที่ต้อง reindex + รูปเก่าที่ต้องลบไฟล์
+func applyImport(tx *gorm.DB, sellerID uint, actor *uint, rows []importRow) ([]importResult, []uint, []string, error) {
+	results := make([]importResult, 0, len(rows))
+	productIDs := make([]uint, 0, len(rows))
+	var removed []string
+
+	for _, r := range rows {
+		if r.post == nil {
+			product := entity.Product{
+				Name:        r.Name,
+				Description: r.Description,
+				Price:       r.Price,
+				PriceMax:    r.Price,
+				SellerID:    sellerID,
+			}
+			if err := tx.Create(&product).Error; err != nil {
+				return nil, nil, nil, err
+			}
+			post := entity.Post_a_New_Product{Product_ID: &product.ID, Category_ID: &r.CategoryID, SellerID: &sellerID}
+			if err := tx.Create(&post).Error; err != nil {
+				return nil, nil, nil, err
+			}
+			imgs := make([]entity.ProductImage, len(r.Images))
+			for i, p := range r.Images {
+				imgs[i] = entity.ProductImage{ImagePath: p, Product_ID: &product.ID}
+			}
+			if err := tx.Create(&imgs).Error; err != nil {
+				return nil, nil, nil, err
+			}
+			if r.Quantity > 0 {
+				if _, err := inventory.Restock(tx, inventory.Line{ProductID: product.ID, Quantity: r.Quantity}, actor, "import"); err != nil {
+					return nil, nil, nil, fmt.Errorf("แถว %d: %w", r.Line, err)
+				}
+			}
+			if err := pricing.Record(tx, product.ID, actor, entity.PriceSourceImport); err != nil {
+				return nil, nil, nil, err
+			}
+			results = append(results, importResult{Row: r.Line, Action: "create", PostID: post.ID})
+			productIDs = append(productIDs, product.ID)
+			continue
+		}
+
+		pid := *r.post.Product_ID
+		upd := map[string]interface{}{"name": r.Name, "description": r.Description}
+		if !r.hasVariants {
+			upd["price"], upd["price_max"] = r.Price, r.Price
+		}
+		if err := tx.Model(&entity.Product{}).Where("id = ?", pid).Updates(upd).Error; err != nil {
+			return nil, nil, nil, err
+		}
+		if err := tx.Model(&entity.Post_a_New_Product{}).Where("id = ?", r.post.ID).Update("category_id", r.CategoryID).Error; err != nil {
+			return nil, nil, nil, err
+		}
+		if !r.hasVariants {
+			if _, err := inventory.SetQuantity(tx, inventory.Line{ProductID: pid, Quantity: r.Quantity}, actor, "import"); err != nil {
+				return nil, nil, nil, fmt.Errorf("แถว %d: %w", r.Line, err)
+			}
+		}
+		if err := pricing.Record(tx, pid, actor, entity.PriceSourceImport); err != nil {
+			return nil, nil, nil, err
+		}
+		if old := imagePaths(r.post.Product.ProductImage); !sameStrings(old, r.Images) {
+			if err := tx.Where("product_id = ?", pid).Delete(&entity.ProductImage{}).Error; err != nil {
+				return nil, nil, nil, err
+			}
+			imgs := make([]entity.ProductImage, len(r.Images))
+			for i, p := range r.Images {
+				imgs[i] = entity.ProductImage{ImagePath: p, Product_ID: &pid}
+			}
+			if err := tx.Create(&imgs).Error; err != nil {
+				return nil, nil, nil, err
+			}
+			for _, p := range old {
+				if !containsString(r.Images, p) {
+					removed = append(removed, p)
+				}
+			}
+		}
+		results = append(results, importResult{Row: r.Line, Action: "update", PostID: r.post.ID})
+		productIDs = append(productIDs, pid)
+	}
+	return results, productIDs, removed, nil
+}
+
+func containsString(xs []string, x string) bool {
+	for _, v := range xs {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------- POST /api/seller/products/import?dry_run=true (multipart: file) ----------
+// ตรวจทุกแถวก่อน มี error แม้แถวเดียว = ไม่บันทึกอะไรเลย (422 + error รายแถว)
+// dry_run = ตรวจอย่างเดียว คืนผลว่าแต่ละแถวจะสร้างหรือแก้
+
+func ImportProducts(c *gin.Context) {
+	s, ok := currentSeller(c)
+	if !ok {
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize+1<<20)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ต้องแนบไฟล์ในฟิลด์ file"})
+		return
+	}
+	if fh.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "ไฟล์ใหญ่เกิน 5MB"})
+		return
+	}
+	format, err := spreadsheet.FormatFromName(fh.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "อ่านไฟล์ไม่สำเร็จ"})
+		return
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "อ่านไฟล์ไม่สำเร็จ"})
+		return
+	}
+	// header + maxImportRows แถว, คอลัมน์เผื่อคอลัมน์อื่นที่ผู้ใช้เพิ่มเองไว้บ้าง
+	table, err := spreadsheet.Read(format, data, spreadsheet.Limits{Rows: maxImportRows + 1, Cols: len(catalogColumns) + 16})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "อ่านไฟล์ไม่สำเร็จ: " + err.Error()})
+		return
+	}
+
+	db := config.DB()
+	rows, errs, err := parseImport(db, s.ID, table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ตรวจไฟล์ไม่สำเร็จ"})
+		return
+	}
+	if len(errs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   fmt.Sprintf("พบข้อผิดพลาด %d รายการ ยังไม่ได้บันทึกข้อมูล", len(errs)),
+			"dry_run": dryRun,
+			"errors":  errs,
+		})
+		return
+	}
+
+	if dryRun {
+		results := make([]importResult, len(rows))
+		created, updated := 0, 0
+		for i, r := range rows {
+			if r.post == nil {
+				results[i] = importResult{Row: r.Line, Action: "create"}
+				created++
+			} else {
+				results[i] = importResult{Row: r.Line, Action: "update", PostID: r.post.ID}
+				updated++
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"dry_run": true, "created": created, "updated": updated, "results": results, "errors": []importError{}})
+		return
+	}
+
+	actor := contextMemberID(c)
+	var results []importResult
+	var productIDs []uint
+	var removed []string
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		results, productIDs, removed, err = applyImport(tx, s.ID, actor, rows)
+		return err
+	}); err != nil {
+		c.JSON(stockErrorStatus(err), gin.H{"error": "นำเข้าไม่สำเร็จ: " + err.Error()})
+		return
+	}
+
+	reindexProducts(db, productIDs...)
+	for _, p := range removed {
+		removeUnusedUnder(db, "products", p)
+	}
+
+	created := 0
+	for _, r := range results {
+		if r.Action == "create" {
+			created++
+		}
+	}
+	recordAudit(c, auditEvent{
+		Action:     "product.import",
+		Detail:     fmt.Sprintf("%s: created=%d updated=%d", fh.Filename, created, len(results)-created),
+		TargetType: "seller",
+		TargetID:   s.ID,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run": false,
+		"created": created,
+		"updated": len(results) - created,
+		"results": results,
+		"errors":  []importError{},
+	})
+}
+
+// ---------- GET /api/seller/products/export?format=csv|xlsx ----------
+// คอลัมน์เดียวกับไฟล์นำเข้า แก้แล้วนำเข้ากลับได้เลย
+
+func ExportProducts(c *gin.Context) {
+	s, ok := currentSeller(c)
+	if !ok {
+		return
+	}
+	format := strings.ToLower(c.DefaultQuery("format", spreadsheet.FormatCSV))
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": spreadsheet.ErrUnsupportedFormat.Error()})
+		return
+	}
+
+	var posts []entity.Post_a_New_Product
+	if err := config.DB().
+		Preload("Product.ProductImage", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }).
+		Where("seller_id = ?", s.ID).
+		Order("id").
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลสินค้าได้"})
+		return
+	}
+
+	labels, err := categoryLabels(config.DB())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลสินค้าได้"})
+		return
+	}
+
+	table := [][]string{catalogColumns}
+	for _, p := range posts {
+		if p.Product_ID == nil || p.Product.ID == 0 {
+			continue
+		}
+		table = append(table, []string{
+			strconv.FormatUint(uint64(p.ID), 10),
+			p.Product.Name,
+			p.Product.Description,
+			strconv.Itoa(p.Product.Price),
+			strconv.Itoa(p.Product.Quantity),
+			labels[derefUint(p.Category_ID)],
+			strings.Join(imagePaths(p.Product.ProductImage), imageSeparator),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(format, &buf, table); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "สร้างไฟล์ไม่สำเร็จ"})
+		return
+	}
+	name := fmt.Sprintf("catalog-%s.%s", time.Now().Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	c.Data(http.StatusOK, spreadsheet.ContentType(format), buf.Bytes())
+}
@@ -0,0 +1,244 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/inventory"
+	"example.com/GROUB/spreadsheet"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type catalogFixture struct {
+	router   *gin.Engine
+	seller   entity.Seller
+	post     entity.Post_a_New_Product // โพสต์เดิมของร้าน (รูป mine.png)
+	category uint
+}
+
+// ร้านที่ทดสอบมีโพสต์เดิม 1 โพสต์, อีกร้านมีรูป other.png ที่ห้ามนำเข้ามาใช้
+func setupCatalog(t *testing.T) catalogFixture {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	t.Setenv("SECRET", "test-secret")
+	t.Setenv("ADMIN_USERNAME", "")
+	gin.SetMode(gin.TestMode)
+	config.ConnectionDB()
+	config.SetupDatabase()
+	db := config.DB()
+
+	mustCreate := func(v interface{}) {
+		t.Helper()
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	var cat entity.Category
+	if err := db.Where("name = ? AND parent_id IS NULL", "อาหาร").First(&cat).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	member := entity.Member{UserName: "shop1", Role: entity.RoleSeller}
+	mustCreate(&member)
+	seller := entity.Seller{Name: "shop1", MemberID: member.ID}
+	mustCreate(&seller)
+	other := entity.Seller{Name: "shop2", MemberID: member.ID + 1000}
+	mustCreate(&other)
+
+	newPost := func(s entity.Seller, name, img string) entity.Post_a_New_Product {
+		t.Helper()
+		product := entity.Product{Name: name, Description: "เดิม", Price: 100, PriceMax: 100, SellerID: s.ID}
+		mustCreate(&product)
+		mustCreate(&entity.ProductImage{ImagePath: img, Product_ID: &product.ID})
+		if _, err := inventory.Restock(db, inventory.Line{ProductID: product.ID, Quantity: 5}, nil, "test"); err != nil {
+			t.Fatal(err)
+		}
+		post := entity.Post_a_New_Product{Product_ID: &product.ID, Category_ID: &cat.ID, SellerID: &s.ID}
+		mustCreate(&post)
+		return post
+	}
+	post := newPost(seller, "ข้าวสาร", "/uploads/products/mine.png")
+	newPost(other, "ของร้านอื่น", "/uploads/products/other.png")
+
+	r := gin.New()
+	r.POST("/api/seller/products/import", func(c *gin.Context) { c.Set("member_id", member.ID) }, ImportProducts)
+	return catalogFixture{router: r, seller: seller, post: post, category: cat.ID}
+}
+
+func (f catalogFixture) upload(t *testing.T, filename string, data []byte, dryRun bool) (int, map[string]interface{}) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(data)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/seller/products/import?dry_run=%t", dryRun), &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	var out map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("%d %s", w.Code, w.Body.String())
+	}
+	return w.Code, out
+}
+
+func countProducts(t *testing.T, db *gorm.DB, sellerID uint) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&entity.Product{}).Where("seller_id = ?", sellerID).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestParseImportReportsErrorsPerRow(t *testing.T) {
+	f := setupCatalog(t)
+	postID := fmt.Sprint(f.post.ID)
+	table := [][]string{
+		{"\ufeffPost_ID", "Name", "Description", "Price", "Quantity", "Category", "Images"},
+		{"", "ขนม", "อร่อย", "1,200.0", "3", "อาหาร", "/uploads/products/a.png | /uploads/products/b.png"},
+		{"999999", "ไม่มีโพสต์", "x", "10", "1", "อาหาร", "/uploads/products/a.png"},
+		{"", "", "", "0", "-1", "ไม่มีหมวดนี้", ""},
+		{"", "ราคาทศนิยม", "x", "9.5", "1.0", "อาหาร", "/uploads/../secret.png"},
+		{"", "", "", "", "", "", ""}, // แถวว่างข้ามไป ไม่นับเป็น error
+		{"", "ขโมยรูป", "x", "10", "1", "อาหาร", "/uploads/products/other.png"},
+		{postID, "ข้าวสารใหม่", "เดิม", "120", "7", "อาหาร", "/uploads/products/mine.png"},
+		{postID, "ซ้ำ", "x", "10", "1", "อาหาร", "/uploads/products/mine.png"},
+	}
+	rows, errs, err := parseImport(config.DB(), f.seller.ID, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []importError{
+		{Row: 3, Column: "post_id"},
+		{Row: 4, Column: "name"},
+		{Row: 4, Column: "description"},
+		{Row: 4, Column: "price"},
+		{Row: 4, Column: "quantity"},
+		{Row: 4, Column: "category"},
+		{Row: 4, Column: "images"},
+		{Row: 5, Column: "price"},
+		{Row: 5, Column: "images"},
+		{Row: 7, Column: "images"},
+		{Row: 9, Column: "post_id"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %+v", errs)
+	}
+	for i, e := range errs {
+		if e.Row != want[i].Row || e.Column != want[i].Column || e.Message == "" {
+			t.Errorf("error %d = %+v, want row %d column %s", i, e, want[i].Row, want[i].Column)
+		}
+	}
+
+	// แถวที่ผ่านยังคืนมาให้ (แต่ผู้เรียกต้องไม่บันทึกเมื่อมี error)
+	if len(rows) != 2 || rows[0].Line != 2 || rows[1].Line != 8 {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if r := rows[0]; r.Price != 1200 || r.Quantity != 3 || r.CategoryID != f.category || len(r.Images) != 2 || r.post != nil {
+		t.Fatalf("create row = %+v", r)
+	}
+	if r := rows[1]; r.post == nil || r.post.ID != f.post.ID || r.Quantity != 7 {
+		t.Fatalf("update row = %+v", r)
+	}
+}
+
+func TestParseImportHeaderAndEmpty(t *testing.T) {
+	f := setupCatalog(t)
+	db := config.DB()
+
+	_, errs, _ := parseImport(db, f.seller.ID, [][]string{{"name", "price"}})
+	if len(errs) != 4 || errs[0].Row != 1 || errs[0].Column != "description" || errs[3].Column != "images" {
+		t.Fatalf("missing columns: %+v", errs)
+	}
+	_, errs, _ = parseImport(db, f.seller.ID, nil)
+	if len(errs) != 1 || errs[0].Row != 1 {
+		t.Fatalf("empty file: %+v", errs)
+	}
+	_, errs, _ = parseImport(db, f.seller.ID, [][]string{catalogColumns, {"", " "}})
+	if len(errs) != 1 || errs[0].Row != 2 {
+		t.Fatalf("header only: %+v", errs)
+	}
+}
+
+func TestImportProductsDryRun(t *testing.T) {
+	f := setupCatalog(t)
+	db := config.DB()
+	good := [][]string{
+		catalogColumns,
+		{"", "ขนมปัง", "อบใหม่", "45", "10", "อาหาร", "/uploads/products/bread.png"},
+		{fmt.Sprint(f.post.ID), "ข้าวสารหอมมะลิ", "5 กก.", "150", "2", "อาหาร", "/uploads/products/mine.png"},
+	}
+
+	for _, format := range []string{spreadsheet.FormatCSV, spreadsheet.FormatXLSX} {
+		t.Run("dry run "+format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := spreadsheet.Write(format, &buf, good); err != nil {
+				t.Fatal(err)
+			}
+			code, body := f.upload(t, "products."+format, buf.Bytes(), true)
+			if code != http.StatusOK || body["dry_run"] != true || body["created"] != 1.0 || body["updated"] != 1.0 {
+				t.Fatalf("%d %v", code, body)
+			}
+			results := body["results"].([]interface{})
+			if len(results) != 2 || results[1].(map[string]interface{})["post_id"] != float64(f.post.ID) {
+				t.Fatalf("results = %v", results)
+			}
+			// ตรวจอย่างเดียว ไม่บันทึก
+			if n := countProducts(t, db, f.seller.ID); n != 1 {
+				t.Fatalf("products after dry run = %d, want 1", n)
+			}
+			var p entity.Product
+			db.First(&p, *f.post.Product_ID)
+			if p.Name != "ข้าวสาร" || p.Price != 100 {
+				t.Fatalf("product changed by dry run: %+v", p)
+			}
+		})
+	}
+
+	t.Run("errors save nothing", func(t *testing.T) {
+		var buf bytes.Buffer
+		spreadsheet.Write(spreadsheet.FormatCSV, &buf, append(good, []string{"", "ไม่มีรูป", "x", "10", "1", "อาหาร", ""}))
+		for _, dry := range []bool{true, false} {
+			code, body := f.upload(t, "products.csv", buf.Bytes(), dry)
+			errs, _ := body["errors"].([]interface{})
+			if code != http.StatusUnprocessableEntity || len(errs) != 1 || errs[0].(map[string]interface{})["row"] != 4.0 {
+				t.Fatalf("dry=%t: %d %v", dry, code, body)
+			}
+		}
+		if n := countProducts(t, db, f.seller.ID); n != 1 {
+			t.Fatalf("products after rejected import = %d, want 1", n)
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		var buf bytes.Buffer
+		spreadsheet.Write(spreadsheet.FormatCSV, &buf, good)
+		code, body := f.upload(t, "products.csv", buf.Bytes(), false)
+		if code != http.StatusOK || body["dry_run"] != false || body["created"] != 1.0 || body["updated"] != 1.0 {
+			t.Fatalf("%d %v", code, body)
+		}
+		if n := countProducts(t, db, f.seller.ID); n != 2 {
+			t.Fatalf("products after import = %d, want 2", n)
+		}
+		var p entity.Product
+		db.First(&p, *f.post.Product_ID)
+		if p.Name != "ข้าวสารหอมมะลิ" || p.Price != 150 || p.Quantity != 2 {
+			t.Fatalf("updated product = %+v", p)
+		}
+	})
+}
@@ -0,0 +1,354 @@
+// controller/category.go
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ตัวคั่นชื่อหมวดแบบเต็ม เช่น "อิเล็กทรอนิก > มือถือ > เคส"
+const categoryPathSep = " > "
+
+var errCategoryNameTaken = errors.New("มีหมวดหมู่ชื่อนี้อยู่แล้วในระดับเดียวกัน")
+
+// ชื่อซ้ำกับหมวดพี่น้อง (parent เดียวกัน) หรือไม่ ไม่นับตัวเอง (exceptID)
+func categoryNameTaken(db *gorm.DB, parentID *uint, name string, exceptID uint) bool {
+	q := db.Model(&entity.Category{}).Where("LOWER(name) = LOWER(?) AND id <> ?", name, exceptID)
+	if parentID == nil {
+		q = q.Where("parent_id IS NULL")
+	} else {
+		q = q.Where("parent_id = ?", *parentID)
+	}
+	var n int64
+	q.Count(&n)
+	return n > 0
+}
+
+// subquery id ของหมวดที่เลือกและลูกหลานทั้งหมด (ใช้กับ IN (?))
+func categorySubtreeIDs(db *gorm.DB, ids []uint) *gorm.DB {
+	return db.Table("categories d").
+		Joins("JOIN categories a ON d.path LIKE a.path || '%'").
+		Where("a.id IN ? AND d.deleted_at IS NULL", ids).
+		Select("d.id")
+}
+
+// ชื่อเต็มของทุกหมวด (id -> "แม่ > ลูก") ใช้ตอนนำเข้า/ส่งออกสินค้า
+func categoryLabels(db *gorm.DB) (map[uint]string, error) {
+	var cats []entity.Category
+	if err := db.Select("id", "name", "path").Find(&cats).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(cats))
+	for _, c := range cats {
+		names[c.ID] = strings.TrimSpace(c.Name)
+	}
+	labels := make(map[uint]string, len(cats))
+	for _, c := range cats {
+		parts := make([]string, 0, 4)
+		for _, id := range pathIDs(c.Path) {
+			if n, ok := names[id]; ok {
+				parts = append(parts, n)
+			}
+		}
+		labels[c.ID] = strings.Join(parts, categoryPathSep)
+	}
+	return labels, nil
+}
+
+// "/1/5/12/" -> [1 5 12]
+func pathIDs(path string) []uint {
+	var ids []uint
+	for _, s := range strings.Split(strings.Trim(path, "/"), "/") {
+		if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			ids = append(ids, uint(n))
+		}
+	}
+	return ids
+}
+
+type categoryNode struct {
+	ID         uint            `json:"id"`
+	Name       string          `json:"name"`
+	ParentID   *uint           `json:"parent_id"`
+	Depth      int             `json:"depth"`
+	PostCount  int64           `json:"post_count"`  // โพสต์ในหมวดนี้เอง
+	TotalCount int64           `json:"total_count"` // รวมหมวดลูกหลาน
+	Children   []*categoryNode `json:"children"`
+}
+
+// ---------- GET /api/categories/tree?root= ----------
+
+func GetCategoryTree(c *gin.Context) {
+	db := config.DB()
+	q := db.Model(&entity.Category{}).Order("depth, name, id")
+	var rootID uint
+	if v := c.Query("root"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "root ไม่ถูกต้อง"})
+			return
+		}
+		var root entity.Category
+		if err := db.First(&root, n).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบหมวดหมู่"})
+			return
+		}
+		rootID = root.ID
+		q = q.Where("path LIKE ?", root.Path+"%")
+	}
+	var cats []entity.Category
+	if err := q.Find(&cats).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ดึงข้อมูลหมวดหมู่ไม่สำเร็จ"})
+		return
+	}
+
+	var counts []struct {
+		CategoryID uint
+		N          int64
+	}
+	if err := listBase(db).
+		Select("p.category_id AS category_id, COUNT(*) AS n").
+		Group("p.category_id").
+		Scan(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ดึงข้อมูลหมวดหมู่ไม่สำเร็จ"})
+		return
+	}
+	own := make(map[uint]int64, len(counts))
+	for _, r := range counts {
+		own[r.CategoryID] = r.N
+	}
+
+	nodes := make(map[uint]*categoryNode, len(cats))
+	roots := []*categoryNode{}
+	// เรียงตาม depth แล้ว parent มาก่อนลูกเสมอ
+	for _, cat := range cats {
+		n := &categoryNode{ID: cat.ID, Name: cat.Name, ParentID: cat.ParentID, Depth: cat.Depth,
+			PostCount: own[cat.ID], Children: []*categoryNode{}}
+		nodes[cat.ID] = n
+		if p, ok := nodes[derefUint(cat.ParentID)]; ok && cat.ID != rootID {
+			p.Children = append(p.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+	// รวมยอดจากลูกขึ้นไปหาแม่ (ไล่จากลึกสุดขึ้นมา)
+	for i := len(cats) - 1; i >= 0; i-- {
+		n := nodes[cats[i].ID]
+		n.TotalCount += n.PostCount
+		if p, ok := nodes[derefUint(n.ParentID)]; ok && n.ID != rootID {
+			p.TotalCount += n.TotalCount
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": roots})
+}
+
+func derefUint(p *uint) uint {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// ---------- GET /api/categories/:id/path ----------
+// breadcrumb จากหมวดบนสุดลงมาถึงหมวดนี้
+
+func GetCategoryPath(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category id ไม่ถูกต้อง"})
+		return
+	}
+	db := config.DB()
+	var cat entity.Category
+	if err := db.First(&cat, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบหมวดหมู่"})
+		return
+	}
+	var chain []entity.Category
+	if err := db.Where("id IN ?", pathIDs(cat.Path)).Order("depth").Find(&chain).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ดึงข้อมูลหมวดหมู่ไม่สำเร็จ"})
+		return
+	}
+	crumbs := make([]gin.H, 0, len(chain))
+	for _, x := range chain {
+		crumbs = append(crumbs, gin.H{"id": x.ID, "name": x.Name, "depth": x.Depth})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": crumbs})
+}
+
+// ---------- PUT /api/categories/:id/move ----------
+// ย้ายหมวด (พร้อมลูกหลานทั้งหมด) ไปอยู่ใต้ parent_id ใหม่, parent_id = null คือขึ้นไปเป็นหมวดบนสุด
+
+type MoveCategoryReq struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+func MoveCategory(c *gin.Context) {
+	var req MoveCategoryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category id ไม่ถูกต้อง"})
+		return
+	}
+	db := config.DB()
+	var before entity.Category
+	if err := db.First(&before, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบหมวดหมู่"})
+		return
+	}
+
+	after := before
+	err = db.Transaction(func(tx *gorm.DB) error {
+		parentPath, depth := "", 0
+		if req.ParentID != nil {
+			var parent entity.Category
+			if err := tx.First(&parent, *req.ParentID).Error; err != nil {
+				return gorm.ErrRecordNotFound
+			}
+			if strings.HasPrefix(parent.Path, before.Path) {
+				return errCategoryCycle
+			}
+			parentPath, depth = parent.Path, parent.Depth+1
+		}
+		if categoryNameTaken(tx, req.ParentID, before.Name, before.ID) {
+			return errCategoryNameTaken
+		}
+		// key ของข้อมูลจำเพาะใน subtree ต้องไม่ชนกับของหมวดแม่ใหม่
+		var clash []string
+		if err := tx.Model(&entity.CategoryAttribute{}).
+			Where("category_id IN ?", pathIDs(parentPath)).
+			Where("key IN (?)", tx.Model(&entity.CategoryAttribute{}).Select("key").
+				Where("category_id IN (?)", tx.Model(&entity.Category{}).Select("id").Where("path LIKE ?", before.Path+"%"))).
+			Pluck("key", &clash).Error; err != nil {
+			return err
+		}
+		if len(clash) > 0 {
+			return &attributeClashError{keys: clash}
+		}
+
+		after.ParentID = req.ParentID
+		after.Path = entity.CategoryPath(parentPath, before.ID)
+		after.Depth = depth
+		if err := tx.Model(&entity.Category{}).Where("id = ?", before.ID).
+			Update("parent_id", req.ParentID).Error; err != nil {
+			return err
+		}
+		// เปลี่ยน prefix ของ path ทั้ง subtree (รวมหมวดที่ถูกลบไปแล้ว ให้ข้อมูลยังสอดคล้อง)
+		if err := tx.Unscoped().Model(&entity.Category{}).
+			Where("path LIKE ?", before.Path+"%").
+			UpdateColumns(map[string]interface{}{
+				"path":  gorm.Expr("? || substr(path, ?)", after.Path, len(before.Path)+1),
+				"depth": gorm.Expr("depth + ?", after.Depth-before.Depth),
+			}).Error; err != nil {
+			return err
+		}
+		// ข้อมูลจำเพาะที่สืบทอดจากหมวดแม่เดิมไม่ใช้กับสินค้าใน subtree แล้ว
+		return pruneAttributeValues(tx, after.Path)
+	})
+	var clash *attributeClashError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบหมวดหมู่แม่"})
+		return
+	case errors.Is(err, errCategoryCycle):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errCategoryNameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.As(err, &clash):
+		c.JSON(http.StatusConflict, gin.H{"error": clash.Error(), "keys": clash.keys})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ย้ายหมวดหมู่ไม่สำเร็จ"})
+		return
+	}
+
+	recordAudit(c, auditEvent{Action: "category.move", TargetType: "category", TargetID: before.ID,
+		Before: before, After: after})
+	c.JSON(http.StatusOK, gin.H{"message": "ย้ายหมวดหมู่สำเร็จ", "data": after})
+}
+
+var errCategoryCycle = errors.New("ย้ายหมวดไปไว้ใต้ตัวเองหรือหมวดลูกของตัวเองไม่ได้")
+
+// ย้ายหมวดแล้ว key ข้อมูลจำเพาะใน subtree ชนกับของหมวดแม่ใหม่
+type attributeClashError struct {
+	keys []string
+}
+
+func (e *attributeClashError) Error() string {
+	return fmt.Sprintf("ข้อมูลจำเพาะ %s มีอยู่แล้วในหมวดแม่ปลายทาง ต้องลบหรือเปลี่ยนก่อนย้าย", strings.Join(e.keys, ", "))
+}
+
+// ย้ายโพสต์ทั้งหมด (รวมที่อยู่ในถังขยะ) จากหมวด from ไปหมวด to
+func reassignCategoryPosts(tx *gorm.DB, from, to uint) (int64, error) {
+	res := tx.Unscoped().Model(&entity.Post_a_New_Product{}).
+		Where("category_id = ?", from).
+		Update("category_id", to)
+	return res.RowsAffected, res.Error
+}
+
+// ลบหมวดที่ยังมีหมวดย่อย หรือยังมีสินค้าโดยไม่ได้ระบุหมวดปลายทาง
+type categoryInUseError struct {
+	children, posts int64
+}
+
+func (e *categoryInUseError) Error() string {
+	if e.children > 0 {
+		return fmt.Sprintf("หมวดนี้ยังมีหมวดย่อย %d หมวด ต้องย้ายหรือลบหมวดย่อยก่อน", e.children)
+	}
+	return fmt.Sprintf("หมวดนี้ยังมีสินค้า %d รายการ ระบุ reassign_to เพื่อย้ายสินค้าไปหมวดอื่นก่อนลบ", e.posts)
+}
+
+// หา id หมวดจากชื่อที่ผู้ใช้พิมพ์: ชื่อเต็ม "แม่ > ลูก" หรือชื่อเดียวถ้าไม่ซ้ำกับหมวดอื่น
+type categoryResolver struct {
+	full map[string]uint
+	leaf map[string][]uint
+}
+
+func newCategoryResolver(labels map[uint]string) categoryResolver {
+	r := categoryResolver{full: make(map[string]uint, len(labels)), leaf: make(map[string][]uint, len(labels))}
+	for id, label := range labels {
+		key := normCategoryLabel(label)
+		r.full[key] = id
+		parts := strings.Split(key, categoryPathSep)
+		last := parts[len(parts)-1]
+		r.leaf[last] = append(r.leaf[last], id)
+	}
+	return r
+}
+
+func (r categoryResolver) resolve(name string) (uint, error) {
+	key := normCategoryLabel(name)
+	if id, ok := r.full[key]; ok {
+		return id, nil
+	}
+	switch ids := r.leaf[key]; len(ids) {
+	case 0:
+		return 0, fmt.Errorf("ไม่พบหมวดหมู่ %q", name)
+	case 1:
+		return ids[0], nil
+	}
+	return 0, fmt.Errorf("มีหมวดชื่อ %q หลายหมวด ให้ระบุชื่อเต็ม เช่น \"หมวดแม่%sหมวดลูก\"", name, categoryPathSep)
+}
+
+// "A>b >  C" -> "a > b > c"
+func normCategoryLabel(s string) string {
+	parts := strings.Split(s, ">")
+	for i, p := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(p))
+	}
+	return strings.Join(parts, categoryPathSep)
+}
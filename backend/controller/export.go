@@ -0,0 +1,354 @@
+// controller/export.go
+package controller
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/storage"
+	"example.com/GROUB/uploadgc"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ไฟล์ zip เก็บใน storage ใต้ uploadgc.ExportPrefix (ไม่เสิร์ฟผ่าน /uploads ต้องโหลดผ่าน API เท่านั้น)
+// พ้น exportTTL แล้ว uploadgc ลบไฟล์ให้ในรอบเก็บกวาด
+const exportTTL = 7 * 24 * time.Hour // อายุไฟล์หลังสร้างเสร็จ
+
+// RecoverDataExports เรียกตอนสตาร์ต: งานที่ค้างจากรอบก่อน (server ดับกลางทาง) ให้ถือว่าล้มเหลว
+func RecoverDataExports() {
+	if err := config.DB().Model(&entity.DataExport{}).
+		Where("status IN ?", []string{entity.ExportPending, entity.ExportRunning}).
+		Updates(map[string]interface{}{
+			"status": entity.ExportFailed,
+			"error":  "interrupted by server restart",
+		}).Error; err != nil {
+		log.Println("recover data exports:", err)
+	}
+}
+
+// ---------- POST /api/me/export ----------
+
+func RequestDataExport(c *gin.Context) {
+	mid, ok := c.Get("member_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+	memberID := mid.(uint)
+	db := config.DB()
+
+	// มีงานค้างอยู่แล้ว -> คืนงานเดิม ไม่สร้างซ้ำ
+	var running entity.DataExport
+	if err := db.Where("member_id = ? AND status IN ?", memberID,
+		[]string{entity.ExportPending, entity.ExportRunning}).
+		First(&running).Error; err == nil {
+		c.JSON(http.StatusAccepted, gin.H{"data": running})
+		return
+	}
+
+	job := entity.DataExport{MemberID: memberID, Status: entity.ExportPending}
+	if err := db.Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "create export failed"})
+		return
+	}
+
+	go runDataExport(job.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{"data": job})
+}
+
+// ---------- GET /api/me/export/:id ----------
+
+func GetDataExport(c *gin.Context) {
+	job, ok := findMyExport(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// ---------- GET /api/me/export/:id/download ----------
+
+func DownloadDataExport(c *gin.Context) {
+	job, ok := findMyExport(c)
+	if !ok {
+		return
+	}
+	if job.Status != entity.ExportReady {
+		c.JSON(http.StatusConflict, gin.H{"message": "export is not ready", "status": job.Status})
+		return
+	}
+	if job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"message": "export expired, please request a new one"})
+		return
+	}
+	ctx := c.Request.Context()
+	st := storage.Default()
+	rc, err := st.Get(ctx, job.FilePath)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusGone, gin.H{"message": "export expired, please request a new one"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "download failed"})
+		return
+	}
+	defer rc.Close()
+	c.DataFromReader(http.StatusOK, job.SizeBytes, "application/zip", rc, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, path.Base(job.FilePath)),
+	})
+}
+
+func findMyExport(c *gin.Context) (*entity.DataExport, bool) {
+	mid, ok := c.Get("member_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return nil, false
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid id"})
+		return nil, false
+	}
+
+	var job entity.DataExport
+	if err := config.DB().Where("id = ? AND member_id = ?", id, mid.(uint)).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "export not found"})
+		return nil, false
+	}
+	return &job, true
+}
+
+/* ===================== background job ===================== */
+
+func runDataExport(jobID uint) {
+	db := config.DB()
+
+	var job entity.DataExport
+	if err := db.First(&job, jobID).Error; err != nil {
+		log.Println("data export: load job:", err)
+		return
+	}
+	_ = db.Model(&job).Update("status", entity.ExportRunning).Error
+
+	key, size, err := buildDataExport(db, job)
+	if err != nil {
+		log.Printf("data export #%d failed: %v", job.ID, err)
+		_ = db.Model(&job).Updates(map[string]interface{}{
+			"status": entity.ExportFailed,
+			"error":  err.Error(),
+		}).Error
+		return
+	}
+
+	// ไฟล์ export รุ่นก่อน ๆ ของคนนี้ไม่ต้องเก็บแล้ว
+	var old []entity.DataExport
+	if db.Where("member_id = ? AND id <> ? AND file_path <> ''", job.MemberID, job.ID).Find(&old).Error == nil {
+		for _, o := range old {
+			if err := storage.Default().Delete(context.Background(), o.FilePath); err != nil {
+				log.Printf("data export: remove %s: %v", o.FilePath, err)
+			}
+			_ = db.Model(&o).Update("file_path", "").Error
+		}
+	}
+
+	now := time.Now()
+	exp := now.Add(exportTTL)
+	_ = db.Model(&job).Updates(map[string]interface{}{
+		"status":       entity.ExportReady,
+		"file_path":    key,
+		"size_bytes":   size,
+		"completed_at": now,
+		"expires_at":   exp,
+	}).Error
+}
+
+// เก็บทุกอย่างที่ผูกกับ member ลง zip: ไฟล์ JSON แยกหมวด + ไฟล์แนบใต้ files/
+func buildDataExport(db *gorm.DB, job entity.DataExport) (key string, size int64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	var m entity.Member
+	if err := db.Preload("People").Preload("People.Gender").First(&m, job.MemberID).Error; err != nil {
+		return "", 0, err
+	}
+
+	sections := map[string]interface{}{}
+	var files []string // path ใต้ uploads/ ที่ต้องแนบ
+
+	sections["account.json"] = gin.H{"member": m, "people": m.People}
+
+	// --- seller + shop + posts/products ---
+	var seller entity.Seller
+	if err := db.Where("member_id = ?", m.ID).First(&seller).Error; err == nil {
+		var shop entity.ShopProfile
+		_ = db.Preload("ShopAddress").Preload("Category").Where("seller_id = ?", seller.ID).First(&shop).Error
+		sections["seller.json"] = gin.H{"seller": seller, "shop_profile": shop}
+		files = append(files, shop.LogoPath)
+
+		var posts []entity.Post_a_New_Product
+		if err := db.Unscoped().
+			Preload("Product", func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() }).
+			Preload("Product.ProductImage", func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() }).
+			Preload("Category").
+			Where("seller_id = ?", seller.ID).
+			Find(&posts).Error; err != nil {
+			return "", 0, err
+		}
+		sections["products.json"] = posts
+		for _, p := range posts {
+			for _, im := range p.Product.ProductImage {
+				files = append(files, im.ImagePath)
+			}
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", 0, err
+	}
+
+	// --- orders + discount usages ---
+	var orders []entity.Order
+	if err := db.Preload("DiscountUsages").Preload("Items").Where("member_id = ?", m.ID).Find(&orders).Error; err != nil {
+		return "", 0, err
+	}
+	sections["orders.json"] = orders
+
+	var usages []entity.DiscountUsage
+	if err := db.Preload("Discountcode").Where("member_id = ?", m.ID).Find(&usages).Error; err != nil {
+		return "", 0, err
+	}
+	sections["discount_usages.json"] = usages
+
+	// --- รีวิวที่เขียน ---
+	var reviews []entity.Review
+	if err := db.Preload("Photos").Where("member_id = ?", m.ID).Find(&reviews).Error; err != nil {
+		return "", 0, err
+	}
+	sections["reviews.json"] = reviews
+	for _, r := range reviews {
+		for _, ph := range r.Photos {
+			files = append(files, ph.ImagePath)
+		}
+	}
+
+	// --- DM threads + posts + attachments ---
+	var threads []entity.DMThread
+	if err := db.Preload("User1").Preload("User2").
+		Preload("Posts", func(tx *gorm.DB) *gorm.DB { return tx.Order("created_at ASC") }).
+		Preload("Posts.Files").
+		Where("user1_id = ? OR user2_id = ?", m.ID, m.ID).
+		Find(&threads).Error; err != nil {
+		return "", 0, err
+	}
+	sections["messages.json"] = threads
+	for _, th := range threads {
+		for _, p := range th.Posts {
+			for _, f := range p.Files {
+				files = append(files, f.FileURL)
+			}
+		}
+	}
+
+	// --- เขียน zip ลงไฟล์ชั่วคราวก่อน (ต้องรู้ขนาดตอนส่งขึ้น storage) แล้วค่อย Put ทีเดียว กันไฟล์ครึ่ง ๆ ---
+	out, err := os.CreateTemp("", "export_*.zip")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	werr := writeExportZip(zw, sections, files)
+	if cerr := zw.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		return "", 0, werr
+	}
+	if size, err = out.Seek(0, io.SeekEnd); err != nil {
+		return "", 0, err
+	}
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+
+	key = fmt.Sprintf("%s%d/export_%d_%s.zip", uploadgc.ExportPrefix, m.ID, job.ID, time.Now().Format("20060102150405"))
+	if err := storage.Default().Put(context.Background(), key, out, size, "application/zip"); err != nil {
+		return "", 0, err
+	}
+	return key, size, nil
+}
+
+func writeExportZip(zw *zip.Writer, sections map[string]interface{}, files []string) error {
+	for name, v := range sections {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, f := range files {
+		key, ok := uploadKey(f)
+		if !ok || seen[key] || !exportableKey(key) {
+			continue
+		}
+		seen[key] = true
+		if err := copyIntoZip(zw, key, "files/uploads/"+key); err != nil {
+			// ไฟล์หายจาก storage ไม่ควรทำให้ทั้ง export ล้ม
+			log.Printf("data export: skip %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// โฟลเดอร์ที่ผู้ใช้อัปโหลดไฟล์ของตัวเองได้ path ที่เก็บใน DB มาจาก client
+// จึงแนบได้เฉพาะใต้โฟลเดอร์เหล่านี้ (ห้ามหลุดไปถึง exports/ ของคนอื่นหรือ quarantine/)
+var exportableDirs = []string{"products/", "logo/", "Discountcode/", dmUploadDir + "/", reviewUploadDir + "/"}
+
+func exportableKey(key string) bool {
+	if strings.HasPrefix(key, uploadgc.ExportPrefix) || strings.HasPrefix(key, uploadgc.QuarantinePrefix) {
+		return false
+	}
+	for _, d := range exportableDirs {
+		if strings.HasPrefix(key, d) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyIntoZip(zw *zip.Writer, key, name string) error {
+	f, err := storage.Default().Get(context.Background(), key)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,184 @@
+// controller/image.go
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"example.com/GROUB/imaging"
+	"example.com/GROUB/storage"
+	"example.com/GROUB/trash"
+	"example.com/GROUB/uploadgc"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// อายุลิงก์ presign ที่ /uploads redirect ไป (เฉพาะ driver s3)
+const uploadURLTTL = time.Hour
+
+// รูปที่อัปโหลดแล้ว: url ตัวเต็ม + url ทุกขนาด (thumb / small / medium / original)
+type uploadedImage struct {
+	URL    string            `json:"url"`
+	Width  int               `json:"width"`
+	Height int               `json:"height"`
+	Sizes  map[string]string `json:"sizes"`
+}
+
+// saveImage ตรวจ + ล้างเมตาดาต้า + ย่อรูปจากไฟล์ที่อัปโหลด แล้วเขียนทุกขนาดลง storage ใต้ dir
+// error จากไฟล์ของผู้ใช้เช็กได้ด้วย imaging.IsInvalid
+func saveImage(ctx context.Context, fh *multipart.FileHeader, dir, prefix string) (*uploadedImage, error) {
+	if fh.Size > imaging.MaxFileSize {
+		return nil, imaging.ErrTooLarge
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, imaging.MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	res, err := imaging.Process(data)
+	if err != nil {
+		return nil, err
+	}
+	st := storage.Default()
+	rnd := make([]byte, 4)
+	_, _ = rand.Read(rnd)
+	original := path.Join(dir, fmt.Sprintf("%s%d%s%s", prefix, time.Now().UnixNano(), hex.EncodeToString(rnd), res.Ext))
+
+	out := &uploadedImage{URL: storage.URL(original), Sizes: map[string]string{}}
+	var written []string
+	for _, v := range res.Variants {
+		key := imaging.VariantPath(original, v.Name)
+		if err := st.Put(ctx, key, bytes.NewReader(v.Data), int64(len(v.Data)), res.ContentType); err != nil {
+			for _, w := range written {
+				_ = st.Delete(ctx, w)
+			}
+			return nil, err
+		}
+		written = append(written, key)
+		out.Sizes[v.Name] = storage.URL(key)
+		if v.Name == imaging.Original {
+			out.Width, out.Height = v.Width, v.Height
+		}
+	}
+	return out, nil
+}
+
+// ลบรูปที่ saveImage เขียนไว้ (ใช้ตอนอัปโหลดหลายไฟล์แล้วมีไฟล์หลัง ๆ ไม่ผ่าน)
+func removeUploadedImages(ctx context.Context, imgs []*uploadedImage) {
+	for _, img := range imgs {
+		for _, u := range img.Sizes {
+			if key, ok := storage.KeyFromURL(u); ok {
+				_ = storage.Default().Delete(ctx, key)
+			}
+		}
+	}
+}
+
+// บันทึกรูปทุกไฟล์ใน form field แบบได้ทั้งหมดหรือไม่ได้เลย (ตอบ error ให้แล้วถ้าไม่สำเร็จ)
+func saveImageFiles(c *gin.Context, files []*multipart.FileHeader, dir string) ([]*uploadedImage, bool) {
+	imgs := make([]*uploadedImage, 0, len(files))
+	for _, fh := range files {
+		img, err := saveImage(c.Request.Context(), fh, dir, "")
+		if err != nil {
+			removeUploadedImages(c.Request.Context(), imgs)
+			if imaging.IsInvalid(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %s", fh.Filename, err.Error())})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถอัปโหลดรูปภาพได้"})
+			}
+			return nil, false
+		}
+		imgs = append(imgs, img)
+	}
+	return imgs, true
+}
+
+// ลบไฟล์รูปพร้อมรูปย่อทุกขนาด (key ต้องผ่านการตรวจ prefix มาแล้ว)
+// เรียกหลัง commit แล้ว ลบไม่สำเร็จแค่ log ไว้ ไฟล์ค้างเก็บกวาดทีหลังได้
+func removeImageFiles(key string) {
+	st := storage.Default()
+	for _, k := range imaging.VariantPaths(key) {
+		if err := st.Delete(context.Background(), k); err != nil {
+			log.Printf("remove upload %s: %v", k, err)
+		}
+	}
+}
+
+// uploadKey แปลง URL/path ที่เก็บใน DB เป็น key ใน storage (ข้ามลิงก์ภายนอก)
+func uploadKey(p string) (string, bool) {
+	if p == "" {
+		return "", false
+	}
+	return storage.KeyFromURL(p)
+}
+
+// ลบไฟล์ (พร้อมรูปย่อ) อย่างปลอดภัยเฉพาะใต้ dir ใน storage
+func safeRemoveUnder(dir, p string) {
+	key, ok := uploadKey(p)
+	if !ok || !strings.HasPrefix(key, dir+"/") {
+		return
+	}
+	removeImageFiles(key)
+}
+
+// เหมือน safeRemoveUnder แต่ข้ามไฟล์ที่ยังมีแถวอื่นอ้างอยู่ (path รูปใช้ซ้ำข้ามแถวได้)
+// ต้องเรียกหลัง commit เพื่อให้เห็นแถวที่เพิ่งลบไปแล้ว
+func removeUnusedUnder(db *gorm.DB, dir, p string) {
+	if trash.StillUsed(db, p) {
+		return
+	}
+	safeRemoveUnder(dir, p)
+}
+
+// ---------- GET /uploads/*key ----------
+// local = เสิร์ฟไฟล์จากดิสก์, s3 = redirect ไปลิงก์ presign (ไฟล์ไม่ผ่าน API)
+func ServeUpload(c *gin.Context) {
+	key, err := storage.CleanKey(c.Param("key"))
+	if err != nil || strings.HasPrefix(key, uploadgc.QuarantinePrefix) || strings.HasPrefix(key, uploadgc.ExportPrefix) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	st := storage.Default()
+	if _, ok := st.(*storage.Local); !ok {
+		u, err := st.Presign(c.Request.Context(), key, uploadURLTTL)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		// cache redirect ได้ไม่เกินอายุลิงก์
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(uploadURLTTL/time.Second)/2))
+		c.Redirect(http.StatusFound, u)
+		return
+	}
+
+	rc, err := st.Get(c.Request.Context(), key)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer rc.Close()
+	f, ok := rc.(io.ReadSeeker)
+	if !ok {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	var mod time.Time
+	if info, err := st.Stat(c.Request.Context(), key); err == nil {
+		mod = info.ModTime
+	}
+	http.ServeContent(c.Writer, c.Request, path.Base(key), mod, f)
+}
@@ -0,0 +1,265 @@
+// controller/inventory.go
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/inventory"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// member_id ใน context (ไม่มี = nil เช่น route ที่ไม่ต้องล็อกอิน)
+func contextMemberID(c *gin.Context) *uint {
+	mid, ok := c.Get("member_id")
+	if !ok {
+		return nil
+	}
+	id := mid.(uint)
+	return &id
+}
+
+// แปลง error ของ inventory เป็น HTTP status
+func stockErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, inventory.ErrInsufficientStock),
+		errors.Is(err, inventory.ErrBelowReserved),
+		errors.Is(err, inventory.ErrConcurrentUpdate),
+		errors.Is(err, inventory.ErrReservationClosed):
+		return http.StatusConflict
+	case errors.Is(err, inventory.ErrInvalidQuantity),
+		errors.Is(err, inventory.ErrVariantRequired),
+		errors.Is(err, inventory.ErrNoLines),
+		errors.Is(err, inventory.ErrLineLimit):
+		return http.StatusBadRequest
+	case errors.Is(err, inventory.ErrReservationLimit):
+		return http.StatusTooManyRequests
+	case errors.Is(err, inventory.ErrNotFound),
+		errors.Is(err, inventory.ErrReservationNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// หาสินค้าของผู้ขายที่ล็อกอินอยู่จาก :id (product id)
+func findMyProduct(c *gin.Context) (*entity.Product, bool) {
+	s, ok := currentSeller(c)
+	if !ok {
+		return nil, false
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "product id ไม่ถูกต้อง"})
+		return nil, false
+	}
+	var p entity.Product
+	if err := config.DB().Where("id = ? AND seller_id = ?", id, s.ID).First(&p).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบสินค้านี้"})
+		return nil, false
+	}
+	return &p, true
+}
+
+type stockLevel struct {
+	VariantID *uint  `json:"variant_id"`
+	SKU       string `json:"sku,omitempty"`
+	Quantity  int    `json:"quantity"`
+	Reserved  int    `json:"reserved"`
+	Available int    `json:"available"`
+}
+
+// ---------- GET /api/seller/products/:id/stock ----------
+
+func GetProductStock(c *gin.Context) {
+	p, ok := findMyProduct(c)
+	if !ok {
+		return
+	}
+	var variants []entity.ProductVariant
+	if err := config.DB().Where("product_id = ?", p.ID).Order("position, id").Find(&variants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลสต็อกได้"})
+		return
+	}
+	levels := make([]stockLevel, 0, len(variants))
+	for _, v := range variants {
+		id := v.ID
+		levels = append(levels, stockLevel{VariantID: &id, SKU: v.SKU, Quantity: v.Quantity, Reserved: v.Reserved, Available: v.Quantity - v.Reserved})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"product_id": p.ID,
+		"quantity":   p.Quantity,
+		"reserved":   p.Reserved,
+		"available":  p.Quantity - p.Reserved,
+		"variants":   levels,
+	})
+}
+
+// ---------- GET /api/seller/products/:id/stock/movements?variant_id=&type=&page=&page_size= ----------
+
+func ListStockMovements(c *gin.Context) {
+	p, ok := findMyProduct(c)
+	if !ok {
+		return
+	}
+	page, size := pageParams(c, 50, 200)
+
+	q := config.DB().Model(&entity.StockMovement{}).Where("product_id = ?", p.ID)
+	if v := c.Query("variant_id"); v != "" {
+		q = q.Where("variant_id = ?", v)
+	}
+	if v := c.Query("type"); v != "" {
+		q = q.Where("type = ?", v)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงประวัติสต็อกได้"})
+		return
+	}
+	var rows []entity.StockMovement
+	if err := q.Order("id DESC").Offset((page - 1) * size).Limit(size).Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงประวัติสต็อกได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows, "page": page, "page_size": size, "total": total})
+}
+
+// ---------- POST /api/seller/products/:id/stock ----------
+// restock / return = รับเข้า quantity ชิ้น, adjust = ตั้งจำนวนในคลังเป็น quantity
+
+type StockChangeReq struct {
+	Type      string `json:"type" binding:"required,oneof=restock return adjust"`
+	VariantID *uint  `json:"variant_id"`
+	Quantity  int    `json:"quantity" binding:"min=0"`
+	Note      string `json:"note" binding:"max=255"`
+}
+
+func ChangeProductStock(c *gin.Context) {
+	var req StockChangeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	p, ok := findMyProduct(c)
+	if !ok {
+		return
+	}
+
+	line := inventory.Line{ProductID: p.ID, VariantID: req.VariantID, Quantity: req.Quantity}
+	actor := contextMemberID(c)
+	db := config.DB()
+
+	var mv *entity.StockMovement
+	var err error
+	switch req.Type {
+	case entity.StockRestock:
+		mv, err = inventory.Restock(db, line, actor, req.Note)
+	case entity.StockReturn:
+		mv, err = inventory.Return(db, line, actor, req.Note)
+	default:
+		mv, err = inventory.SetQuantity(db, line, actor, req.Note)
+	}
+	if err != nil {
+		c.JSON(stockErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if mv == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "จำนวนเท่าเดิม ไม่มีการเปลี่ยนแปลง"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "อัปเดตสต็อกสำเร็จ", "data": mv})
+}
+
+// ---------- POST /api/reservations ----------
+// จองของระหว่าง checkout ได้ทั้งหมดหรือไม่ได้เลย หมดเวลาแล้วถูกปล่อยคืนอัตโนมัติ
+
+type ReserveReq struct {
+	Items []inventory.Line `json:"items" binding:"required,min=1,max=50"`
+}
+
+func reservationResponse(rs []entity.StockReservation) gin.H {
+	h := gin.H{"data": rs}
+	if len(rs) > 0 {
+		h["ref"] = rs[0].Ref
+		h["expires_at"] = rs[0].ExpiresAt
+	}
+	return h
+}
+
+func CreateReservation(c *gin.Context) {
+	var req ReserveReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	memberID := *contextMemberID(c)
+
+	// ซื้อได้เฉพาะสินค้าที่เปิดขายอยู่
+	if l, err := unpublishedLine(req.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ตรวจสอบสินค้าไม่สำเร็จ"})
+		return
+	} else if l != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "สินค้านี้ยังไม่เปิดขาย", "item": l})
+		return
+	}
+
+	rs, err := inventory.Reserve(config.DB(), memberID, req.Items, inventory.DefaultTTL)
+	if err != nil {
+		h := gin.H{"error": err.Error()}
+		var le *inventory.LineError
+		if errors.As(err, &le) {
+			h["item"] = le.Line
+		}
+		c.JSON(stockErrorStatus(err), h)
+		return
+	}
+	c.JSON(http.StatusCreated, reservationResponse(rs))
+}
+
+// ---------- GET /api/reservations/:ref ----------
+
+func GetReservation(c *gin.Context) {
+	rs, err := inventory.Get(config.DB(), *contextMemberID(c), c.Param("ref"))
+	if err != nil {
+		c.JSON(stockErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reservationResponse(rs))
+}
+
+// ---------- POST /api/reservations/:ref/commit ----------
+// ยืนยันการซื้อ: ตัดสต็อก + สร้าง order ใน transaction เดียวกัน
+
+func CommitReservation(c *gin.Context) {
+	memberID := *contextMemberID(c)
+	var rs []entity.StockReservation
+	var order *entity.Order
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		if rs, err = inventory.Commit(tx, memberID, c.Param("ref")); err != nil {
+			return err
+		}
+		order, err = createOrder(tx, memberID, rs)
+		return err
+	})
+	if err != nil {
+		c.JSON(stockErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h := reservationResponse(rs)
+	h["order"] = order
+	c.JSON(http.StatusOK, h)
+}
+
+// ---------- DELETE /api/reservations/:ref ----------
+
+func ReleaseReservation(c *gin.Context) {
+	rs, err := inventory.Release(config.DB(), *contextMemberID(c), c.Param("ref"))
+	if err != nil {
+		c.JSON(stockErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reservationResponse(rs))
+}
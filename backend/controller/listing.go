@@ -0,0 +1,404 @@
+// controller/listing.go
+package controller
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	listDefaultLimit = 24
+	listMaxLimit     = 100
+)
+
+// ลำดับการเรียง (ทุกแบบใช้ post id มากไปน้อยตัดสินเมื่อค่าเท่ากัน เพื่อให้ cursor ไม่ซ้ำ/ไม่ตกหล่น)
+const (
+	sortNewest    = "newest"
+	sortPriceAsc  = "price_asc"
+	sortPriceDesc = "price_desc"
+	sortPopular   = "popular"
+)
+
+// ช่วงราคาสำหรับ facet (max = 0 คือไม่มีเพดาน)
+var priceBuckets = []struct{ Min, Max int }{
+	{0, 100}, {100, 500}, {500, 1000}, {1000, 5000}, {5000, 0},
+}
+
+var errBadCursor = errors.New("invalid cursor")
+
+// productListFilter = filter จาก query string ของหน้ารายการสินค้า
+type productListFilter struct {
+	CategoryIDs    []uint
+	SellerID       *uint
+	ShopCategoryID *uint
+	MinPrice       *int
+	MaxPrice       *int
+	InStock        bool
+	Province       string
+	Sort           string
+	Attrs          []attrFilter // ?attr[key]=value
+}
+
+func parseUintList(s string) ([]uint, error) {
+	var out []uint
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, uint(n))
+	}
+	return out, nil
+}
+
+func parseListFilter(c *gin.Context, db *gorm.DB) (productListFilter, error) {
+	f := productListFilter{Sort: c.DefaultQuery("sort", sortNewest)}
+	switch f.Sort {
+	case sortNewest, sortPriceAsc, sortPriceDesc, sortPopular:
+	default:
+		return f, fmt.Errorf("sort ต้องเป็น %s, %s, %s หรือ %s", sortNewest, sortPriceAsc, sortPriceDesc, sortPopular)
+	}
+
+	if v := c.Query("category_id"); v != "" {
+		ids, err := parseUintList(v)
+		if err != nil {
+			return f, errors.New("category_id ไม่ถูกต้อง")
+		}
+		f.CategoryIDs = ids
+	}
+	for _, p := range []struct {
+		name string
+		dst  **uint
+	}{{"seller_id", &f.SellerID}, {"shop_category_id", &f.ShopCategoryID}} {
+		if v := c.Query(p.name); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return f, errors.New(p.name + " ไม่ถูกต้อง")
+			}
+			id := uint(n)
+			*p.dst = &id
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  **int
+	}{{"min_price", &f.MinPrice}, {"max_price", &f.MaxPrice}} {
+		if v := c.Query(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return f, errors.New(p.name + " ไม่ถูกต้อง")
+			}
+			*p.dst = &n
+		}
+	}
+	if v := c.Query("in_stock"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, errors.New("in_stock ไม่ถูกต้อง")
+		}
+		f.InStock = b
+	}
+	f.Province = strings.TrimSpace(c.Query("province"))
+	attrs, err := parseAttrFilters(db, c.QueryMap("attr"))
+	if err != nil {
+		return f, err
+	}
+	f.Attrs = attrs
+	return f, nil
+}
+
+// base query: โพสต์ที่เปิดขายอยู่ + สินค้า + ร้าน + ที่อยู่ร้าน
+func listBase(db *gorm.DB) *gorm.DB {
+	return db.Table("post_a_new_products AS p").
+		Joins("JOIN products pr ON pr.id = p.product_id AND pr.deleted_at IS NULL").
+		Joins("LEFT JOIN shop_profiles sp ON sp.seller_id = p.seller_id AND sp.deleted_at IS NULL").
+		Joins("LEFT JOIN shop_addresses sa ON sa.id = sp.address_id AND sa.deleted_at IS NULL").
+		Where("p.deleted_at IS NULL AND p.status = ?", entity.PostPublished)
+}
+
+// productPublished = สินค้านี้มีโพสต์ที่เปิดขายอยู่ (เงื่อนไขเดียวกับ listBase)
+// endpoint สาธารณะที่รับ product id ตรง ๆ ต้องเช็คก่อน ไม่งั้นดูข้อมูลของโพสต์ร่าง/ปิดขาย/ลบแล้วได้
+func productPublished(db *gorm.DB, productID uint) (bool, error) {
+	var n int64
+	err := listBase(db).Where("p.product_id = ?", productID).Limit(1).Count(&n).Error
+	return n > 0, err
+}
+
+// apply ใส่ filter ลง query; skip = ชื่อ filter ที่ไม่ต้องใส่ (ใช้ตอนนับ facet ของ filter นั้นเอง)
+func (f productListFilter) apply(q *gorm.DB, skip string) *gorm.DB {
+	if len(f.CategoryIDs) > 0 && skip != "category" {
+		// รวมหมวดย่อยทุกระดับของหมวดที่เลือก
+		q = q.Where("p.category_id IN (?)", categorySubtreeIDs(q.Session(&gorm.Session{NewDB: true}), f.CategoryIDs))
+	}
+	if f.SellerID != nil {
+		q = q.Where("p.seller_id = ?", *f.SellerID)
+	}
+	if f.ShopCategoryID != nil {
+		q = q.Where("sp.shop_category_id = ?", *f.ShopCategoryID)
+	}
+	if skip != "price" {
+		if f.MinPrice != nil {
+			q = q.Where("pr.price >= ?", *f.MinPrice)
+		}
+		if f.MaxPrice != nil {
+			q = q.Where("pr.price <= ?", *f.MaxPrice)
+		}
+	}
+	if f.InStock {
+		q = q.Where("pr.quantity - pr.reserved > 0")
+	}
+	if f.Province != "" {
+		q = q.Where("LOWER(sa.province) = LOWER(?)", f.Province)
+	}
+	for _, af := range f.Attrs {
+		if skip != "attr:"+af.Key {
+			q = af.apply(q)
+		}
+	}
+	return q
+}
+
+// cursor = ค่าของคีย์เรียง + post id ของแถวสุดท้ายในหน้าก่อน
+type listCursor struct {
+	Sort  string `json:"s"`
+	Value int    `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+func (lc listCursor) encode() string {
+	b, _ := json.Marshal(lc)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s, sort string) (*listCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errBadCursor
+	}
+	var lc listCursor
+	if json.Unmarshal(b, &lc) != nil || lc.Sort != sort || lc.ID == 0 {
+		return nil, errBadCursor
+	}
+	return &lc, nil
+}
+
+// คอลัมน์ที่ใช้เรียง + ทิศทาง
+func sortColumn(sort string) (col string, asc bool) {
+	switch sort {
+	case sortPriceAsc:
+		return "pr.price", true
+	case sortPriceDesc:
+		return "pr.price", false
+	case sortPopular:
+		return "pr.sold_count", false
+	}
+	return "", false
+}
+
+func applySort(q *gorm.DB, sort string, cur *listCursor) *gorm.DB {
+	col, asc := sortColumn(sort)
+	if col == "" {
+		if cur != nil {
+			q = q.Where("p.id < ?", cur.ID)
+		}
+		return q.Order("p.id DESC")
+	}
+	if cur != nil {
+		op := "<"
+		if asc {
+			op = ">"
+		}
+		q = q.Where(fmt.Sprintf("(%s %s ?) OR (%s = ? AND p.id < ?)", col, op, col), cur.Value, cur.Value, cur.ID)
+	}
+	dir := "DESC"
+	if asc {
+		dir = "ASC"
+	}
+	return q.Order(col + " " + dir).Order("p.id DESC")
+}
+
+type categoryFacet struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	ParentID *uint  `json:"parent_id"`
+	Count    int64  `json:"count"`
+}
+
+type priceFacet struct {
+	Min   int   `json:"min"`
+	Max   *int  `json:"max"` // null = ไม่มีเพดาน
+	Count int64 `json:"count"`
+}
+
+// นับ facet แบบ disjunctive: facet หมวดไม่ถูกจำกัดด้วย filter หมวดเอง (เลือกหลายหมวดได้)
+// facet ของข้อมูลจำเพาะอยู่ที่ attributeFacets
+func listFacets(db *gorm.DB, f productListFilter) ([]categoryFacet, []priceFacet, error) {
+	cats := []categoryFacet{}
+	if err := f.apply(listBase(db), "category").
+		Joins("JOIN categories c ON c.id = p.category_id AND c.deleted_at IS NULL").
+		Select("c.id AS id, c.name AS name, c.parent_id AS parent_id, COUNT(*) AS count").
+		Group("c.id, c.name, c.parent_id").
+		Order("count DESC, c.id").
+		Scan(&cats).Error; err != nil {
+		return nil, nil, err
+	}
+
+	cases := make([]string, 0, len(priceBuckets))
+	args := make([]interface{}, 0, len(priceBuckets)*2)
+	for i, b := range priceBuckets {
+		if b.Max > 0 {
+			cases = append(cases, fmt.Sprintf("SUM(CASE WHEN pr.price >= ? AND pr.price < ? THEN 1 ELSE 0 END) AS b%d", i))
+			args = append(args, b.Min, b.Max)
+		} else {
+			cases = append(cases, fmt.Sprintf("SUM(CASE WHEN pr.price >= ? THEN 1 ELSE 0 END) AS b%d", i))
+			args = append(args, b.Min)
+		}
+	}
+	counts := make([]sql.NullInt64, len(priceBuckets))
+	dest := make([]interface{}, len(counts))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	if err := f.apply(listBase(db), "price").
+		Select(strings.Join(cases, ", "), args...).
+		Row().Scan(dest...); err != nil {
+		return nil, nil, err
+	}
+	prices := make([]priceFacet, len(priceBuckets))
+	for i, b := range priceBuckets {
+		pf := priceFacet{Min: b.Min, Count: counts[i].Int64}
+		if b.Max > 0 {
+			max := b.Max
+			pf.Max = &max
+		}
+		prices[i] = pf
+	}
+	return cats, prices, nil
+}
+
+// listProducts = รายการโพสต์แบบแบ่งหน้าด้วย cursor + filter + sort + facet
+// sellerID != nil = บังคับเฉพาะร้านนั้น (หน้าร้าน)
+func listProducts(c *gin.Context, sellerID *uint) {
+	db := config.DB()
+	f, err := parseListFilter(c, db)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if sellerID != nil {
+		f.SellerID = sellerID
+	}
+	cur, err := decodeCursor(c.Query("cursor"), f.Sort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cursor ไม่ถูกต้อง"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit <= 0 {
+		limit = listDefaultLimit
+	}
+	if limit > listMaxLimit {
+		limit = listMaxLimit
+	}
+
+	// 1) หา id ของหน้านี้ (ขอเกิน 1 แถวไว้ดูว่ามีหน้าถัดไปไหม)
+	var rows []struct {
+		ID        uint
+		Price     int
+		SoldCount int
+	}
+	if err := applySort(f.apply(listBase(db), ""), f.Sort, cur).
+		Select("p.id AS id, pr.price AS price, pr.sold_count AS sold_count").
+		Limit(limit + 1).
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลสินค้าได้"})
+		return
+	}
+
+	var next *string
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		lc := listCursor{Sort: f.Sort, ID: last.ID}
+		switch f.Sort {
+		case sortPriceAsc, sortPriceDesc:
+			lc.Value = last.Price
+		case sortPopular:
+			lc.Value = last.SoldCount
+		}
+		s := lc.encode()
+		next = &s
+	}
+
+	// 2) preload เฉพาะแถวของหน้านี้ แล้วเรียงตามลำดับเดิม
+	posts := make([]entity.Post_a_New_Product, 0, len(rows))
+	if len(rows) > 0 {
+		ids := make([]uint, len(rows))
+		for i, r := range rows {
+			ids[i] = r.ID
+		}
+		var loaded []entity.Post_a_New_Product
+		if err := preloadAttributes(db).Preload("Product.ProductImage").Preload("Category").Preload("Seller").Preload("Seller.ShopProfile").
+			Where("id IN ?", ids).
+			Find(&loaded).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลสินค้าได้"})
+			return
+		}
+		byID := make(map[uint]entity.Post_a_New_Product, len(loaded))
+		for _, p := range loaded {
+			byID[p.ID] = p
+		}
+		for _, id := range ids {
+			if p, ok := byID[id]; ok {
+				posts = append(posts, p)
+			}
+		}
+		fillLowestPrices(db, postProducts(posts)...)
+	}
+
+	// 3) total + facet (ไม่ขึ้นกับ cursor)
+	var total int64
+	if err := f.apply(listBase(db), "").Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลสินค้าได้"})
+		return
+	}
+	cats, prices, err := listFacets(db, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลสินค้าได้"})
+		return
+	}
+	attrs, err := attributeFacets(db, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลสินค้าได้"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        posts,
+		"next_cursor": next,
+		"total":       total,
+		"facets": gin.H{
+			"categories": cats,
+			"price":      prices,
+			"attributes": attrs,
+		},
+	})
+}
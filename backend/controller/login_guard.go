@@ -0,0 +1,97 @@
+// controller/login_guard.go
+package controller
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// กันเดารหัสผ่าน: นับครั้งที่ล็อกอินพลาดต่อ username และต่อ IP
+// พลาดครบจำนวนที่ให้ฟรีแล้วล็อก loginBaseDelay และเพิ่มเป็นเท่าตัวทุกครั้งที่พลาดต่อ (สูงสุด loginMaxDelay)
+// IP ได้โควตามากกว่าเพราะหลายคนอาจใช้ IP เดียวกัน (NAT)
+const (
+	loginFreeAttempts   = 5
+	loginFreeAttemptsIP = 20
+	loginBaseDelay      = 30 * time.Second
+	loginMaxDelay       = 30 * time.Minute
+	loginForgetAfter    = 24 * time.Hour // ไม่พลาดเลยนานเท่านี้ -> ลืมประวัติ
+)
+
+type loginFailure struct {
+	count       int
+	lockedUntil time.Time
+	lastFail    time.Time
+}
+
+type loginGuard struct {
+	mu       sync.Mutex
+	failures map[string]*loginFailure
+}
+
+var loginLimiter = &loginGuard{failures: map[string]*loginFailure{}}
+
+func loginKeys(username, ip string) []string {
+	return []string{"u:" + strings.ToLower(strings.TrimSpace(username)), "ip:" + ip}
+}
+
+// ยังถูกล็อกอยู่ไหม คืนเวลาที่ต้องรอนานสุดของทุก key
+func (g *loginGuard) retryAfter(keys ...string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+	for _, k := range keys {
+		f, ok := g.failures[k]
+		if !ok {
+			continue
+		}
+		if now.Sub(f.lastFail) > loginForgetAfter {
+			delete(g.failures, k)
+			continue
+		}
+		if d := f.lockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+func (g *loginGuard) fail(keys ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	for _, k := range keys {
+		f, ok := g.failures[k]
+		if !ok || now.Sub(f.lastFail) > loginForgetAfter {
+			f = &loginFailure{}
+			g.failures[k] = f
+		}
+		f.count++
+		f.lastFail = now
+		free := loginFreeAttempts
+		if strings.HasPrefix(k, "ip:") {
+			free = loginFreeAttemptsIP
+		}
+		if over := f.count - free; over >= 0 {
+			delay := loginMaxDelay
+			if over < 16 {
+				delay = loginBaseDelay << over
+			}
+			if delay > loginMaxDelay {
+				delay = loginMaxDelay
+			}
+			f.lockedUntil = now.Add(delay)
+		}
+	}
+}
+
+func (g *loginGuard) reset(keys ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, k := range keys {
+		delete(g.failures, k)
+	}
+}
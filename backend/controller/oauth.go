@@ -0,0 +1,396 @@
+// controller/oauth.go
+package controller
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/oauth"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ผู้ใช้ต้องกลับมาจากหน้า login ของผู้ให้บริการภายในเวลานี้
+const oauthStateTTL = 10 * time.Minute
+
+// cookie ผูก state กับเบราว์เซอร์ที่กด start: callback ต้องมาจากเบราว์เซอร์เดียวกัน
+// กันคนร้ายส่งลิงก์ callback (code + state ของตัวเอง) ให้เหยื่อกดแล้วเหยื่อล็อกอินเป็นบัญชีคนร้าย
+const oauthStateCookie = "oauth_state"
+
+func setOAuthStateCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, value, maxAge, "/api/oauth", "", strings.HasPrefix(os.Getenv("APP_URL"), "https://"), true)
+}
+
+var (
+	errIdentityLinked = errors.New("this external account is already linked to another user")
+	errEmailExists    = errors.New("email already registered, log in with your password and link this account from settings")
+)
+
+// ---------- GET /api/oauth/providers ----------
+
+func ListOAuthProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": oauth.Names()})
+}
+
+// ---------- GET /api/oauth/:provider/start ----------
+
+// คืนลิงก์ให้ frontend redirect ไป (ผู้ให้บริการจะ redirect กลับมาพร้อม code + state)
+func StartOAuth(c *gin.Context) {
+	startOAuth(c, nil)
+}
+
+// ---------- POST /api/oauth/:provider/link ----------
+
+// เหมือน start แต่ผลลัพธ์คือผูกบัญชีภายนอกเข้ากับ member ที่ล็อกอินอยู่
+func StartOAuthLink(c *gin.Context) {
+	mid, ok := c.Get("member_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+	id := mid.(uint)
+	startOAuth(c, &id)
+}
+
+func startOAuth(c *gin.Context, linkMemberID *uint) {
+	p, err := oauth.Get(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	state, err := oauth.RandomString(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "cannot create state"})
+		return
+	}
+	nonce, err := oauth.RandomString(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "cannot create state"})
+		return
+	}
+	verifier, err := oauth.NewCodeVerifier()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "cannot create state"})
+		return
+	}
+
+	authURL, err := p.AuthCodeURL(c.Request.Context(), state, oauth.CodeChallengeS256(verifier), nonce)
+	if err != nil {
+		log.Printf("oauth %s: %v", p.Name(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"message": "identity provider unavailable"})
+		return
+	}
+
+	db := config.DB()
+	// ล้าง state ที่หมดอายุไปพร้อมกัน ไม่ให้ตารางโตเรื่อย ๆ
+	_ = db.Unscoped().Where("expires_at < ?", time.Now().Add(-time.Hour)).Delete(&entity.OAuthState{}).Error
+
+	if err := db.Create(&entity.OAuthState{
+		StateHash:    hashToken(state),
+		Provider:     p.Name(),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		LinkMemberID: linkMemberID,
+		ExpiresAt:    time.Now().Add(oauthStateTTL),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+		return
+	}
+
+	setOAuthStateCookie(c, state, int(oauthStateTTL.Seconds()))
+	c.JSON(http.StatusOK, gin.H{
+		"auth_url":   authURL,
+		"state":      state,
+		"expires_in": int(oauthStateTTL.Seconds()),
+	})
+}
+
+// ---------- GET /api/oauth/:provider/callback?code=&state= ----------
+// หน้า frontend ที่ผู้ให้บริการ redirect กลับไป (OAUTH_<NAME>_REDIRECT_URL) เรียกต่อพร้อม cookie (withCredentials)
+
+func OAuthCallback(c *gin.Context) {
+	if e := c.Query("error"); e != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "login cancelled or denied", "error": e})
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "code and state are required"})
+		return
+	}
+	bound, _ := c.Cookie(oauthStateCookie)
+	setOAuthStateCookie(c, "", -1)
+	if subtle.ConstantTimeCompare([]byte(bound), []byte(state)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid or expired state"})
+		return
+	}
+
+	p, err := oauth.Get(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	db := config.DB()
+
+	// state ใช้ได้ครั้งเดียว (mark used แบบมีเงื่อนไข กันเรียก callback ซ้ำพร้อมกัน)
+	var st entity.OAuthState
+	if err := db.Where("state_hash = ? AND provider = ?", hashToken(state), p.Name()).First(&st).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid or expired state"})
+		return
+	}
+	now := time.Now()
+	if st.UsedAt != nil || now.After(st.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid or expired state"})
+		return
+	}
+	res := db.Model(&entity.OAuthState{}).Where("id = ? AND used_at IS NULL", st.ID).Update("used_at", now)
+	if res.Error != nil || res.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid or expired state"})
+		return
+	}
+
+	ident, err := p.Exchange(c.Request.Context(), code, st.CodeVerifier, st.Nonce)
+	if err != nil {
+		log.Printf("oauth %s exchange: %v", p.Name(), err)
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "external login failed"})
+		return
+	}
+
+	// --- ผูกบัญชี ---
+	if st.LinkMemberID != nil {
+		if err := linkIdentity(db, *st.LinkMemberID, p.Name(), ident); err != nil {
+			if errors.Is(err, errIdentityLinked) {
+				c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "link failed"})
+			return
+		}
+		recordAudit(c, auditEvent{Action: "auth.identity_link", Detail: p.Name(), TargetType: "member",
+			TargetID: *st.LinkMemberID, Actor: &entity.Member{Model: gorm.Model{ID: *st.LinkMemberID}},
+			After: gin.H{"provider": p.Name(), "email": ident.Email}})
+		c.JSON(http.StatusOK, gin.H{"message": "account linked", "provider": p.Name()})
+		return
+	}
+
+	// --- ล็อกอิน / สมัครใหม่ ---
+	var memberID uint
+	var ext entity.ExternalIdentity
+	err = db.Where("provider = ? AND subject = ?", p.Name(), ident.Subject).First(&ext).Error
+	switch {
+	case err == nil:
+		memberID = ext.MemberID
+		_ = db.Model(&ext).Updates(map[string]interface{}{"email": ident.Email, "last_login_at": now}).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		memberID, err = registerExternal(db, p.Name(), ident)
+		if err != nil {
+			if errors.Is(err, errEmailExists) {
+				c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "register failed", "error": err.Error()})
+			return
+		}
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+		return
+	}
+
+	m, err := loadMemberWithProfile(db, memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+		return
+	}
+	recordAudit(c, auditEvent{Action: "auth.login", Detail: "oauth:" + p.Name(), TargetType: "member", TargetID: m.ID, Actor: &m})
+	completeLogin(c, db, m)
+}
+
+func linkIdentity(db *gorm.DB, memberID uint, provider string, ident *oauth.Identity) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var ext entity.ExternalIdentity
+		err := tx.Where("provider = ? AND subject = ?", provider, ident.Subject).First(&ext).Error
+		if err == nil {
+			if ext.MemberID != memberID {
+				return errIdentityLinked
+			}
+			return nil // ผูกไว้แล้ว
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return tx.Create(&entity.ExternalIdentity{
+			MemberID: memberID,
+			Provider: provider,
+			Subject:  ident.Subject,
+			Email:    ident.Email,
+		}).Error
+	})
+}
+
+// สมัครสมาชิกใหม่จากข้อมูลผู้ให้บริการ (ไม่มีรหัสผ่าน ตั้งทีหลังได้ผ่าน "ลืมรหัสผ่าน")
+// อีเมลซ้ำกับบัญชีเดิม -> ไม่ผูกให้อัตโนมัติ กันยึดบัญชีผ่านผู้ให้บริการที่ไม่ได้ยืนยันอีเมลจริง
+func registerExternal(db *gorm.DB, provider string, ident *oauth.Identity) (uint, error) {
+	var memberID uint
+	err := db.Transaction(func(tx *gorm.DB) error {
+		email := strings.TrimSpace(ident.Email)
+		if email != "" {
+			var n int64
+			if err := tx.Model(&entity.People{}).Where("LOWER(email) = LOWER(?)", email).Count(&n).Error; err != nil {
+				return err
+			}
+			if n > 0 {
+				return errEmailExists
+			}
+		}
+
+		username, err := uniqueUsername(tx, ident)
+		if err != nil {
+			return err
+		}
+
+		first, last := ident.GivenName, ident.FamilyName
+		if first == "" && last == "" {
+			first, last, _ = strings.Cut(strings.TrimSpace(ident.Name), " ")
+		}
+		if first == "" {
+			first = username
+		}
+
+		now := time.Now()
+		p := entity.People{FirstName: first, LastName: strings.TrimSpace(last), Email: email}
+		if email != "" && ident.EmailVerified {
+			p.EmailVerifiedAt = &now
+		}
+		if err := tx.Create(&p).Error; err != nil {
+			return err
+		}
+
+		m := entity.Member{
+			UserName: username,
+			Password: "!", // ไม่ใช่ bcrypt hash -> ล็อกอินด้วยรหัสผ่านไม่ได้
+			Role:     entity.RoleBuyer,
+			PeopleID: p.ID,
+		}
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&entity.ExternalIdentity{
+			MemberID:    m.ID,
+			Provider:    provider,
+			Subject:     ident.Subject,
+			Email:       email,
+			LastLoginAt: &now,
+		}).Error; err != nil {
+			return err
+		}
+		memberID = m.ID
+		return nil
+	})
+	return memberID, err
+}
+
+var usernameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// ตั้ง username จาก preferred_username / ส่วนหน้าของอีเมล แล้วเติมเลขท้ายถ้าซ้ำ
+func uniqueUsername(tx *gorm.DB, ident *oauth.Identity) (string, error) {
+	base := ident.Username
+	if base == "" {
+		base, _, _ = strings.Cut(ident.Email, "@")
+	}
+	base = usernameUnsafe.ReplaceAllString(base, "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	name := base
+	for i := 2; i < 1000; i++ {
+		var n int64
+		if err := tx.Model(&entity.Member{}).Where("LOWER(user_name) = LOWER(?)", name).Count(&n).Error; err != nil {
+			return "", err
+		}
+		if n == 0 {
+			return name, nil
+		}
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	suffix, err := oauth.RandomString(6)
+	if err != nil {
+		return "", err
+	}
+	return base + "_" + suffix, nil
+}
+
+// ---------- DELETE /api/oauth/:provider/link ----------
+
+func UnlinkOAuth(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	provider := strings.ToLower(c.Param("provider"))
+	db := config.DB()
+
+	var ids []entity.ExternalIdentity
+	if err := db.Where("member_id = ?", m.ID).Find(&ids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+		return
+	}
+	found := false
+	for _, id := range ids {
+		if id.Provider == provider {
+			found = true
+		}
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"message": "account not linked"})
+		return
+	}
+	// ไม่มีรหัสผ่าน และนี่คือช่องทางเดียวที่เหลือ -> ห้ามถอด ไม่งั้นเข้าบัญชีไม่ได้อีก
+	if !hasPassword(m) && len(ids) == 1 {
+		c.JSON(http.StatusConflict, gin.H{"message": "set a password before unlinking your only sign-in method"})
+		return
+	}
+
+	if err := db.Unscoped().Where("member_id = ? AND provider = ?", m.ID, provider).
+		Delete(&entity.ExternalIdentity{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "unlink failed"})
+		return
+	}
+	recordAudit(c, auditEvent{Action: "auth.identity_unlink", Detail: provider, TargetType: "member", TargetID: m.ID})
+	c.JSON(http.StatusOK, gin.H{"message": "account unlinked"})
+}
+
+func hasPassword(m *entity.Member) bool {
+	return strings.HasPrefix(m.Password, "$2")
+}
+
+// ---------- GET /api/me/identities ----------
+
+func ListMyIdentities(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	var ids []entity.ExternalIdentity
+	if err := config.DB().Where("member_id = ?", m.ID).Order("id").Find(&ids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ids, "has_password": hasPassword(m)})
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/oauth"
+	"example.com/GROUB/oauth/mockoidc"
+	"github.com/gin-gonic/gin"
+)
+
+const testRedirectURL = "http://localhost:5173/oauth/mock/callback"
+
+// เดินทั้ง flow เหมือนเบราว์เซอร์: start -> หน้า authorize ของ mock -> redirect กลับหน้าเว็บ -> หน้าเว็บเรียก callback
+func TestOAuthFlowWithMockIssuer(t *testing.T) {
+	t.Chdir(t.TempDir()) // groub.db ของเทสต์
+	t.Setenv("SECRET", "test-secret")
+	t.Setenv("ADMIN_USERNAME", "")
+	gin.SetMode(gin.TestMode)
+	config.ConnectionDB()
+	config.SetupDatabase()
+
+	mock, err := mockoidc.New("", "groub", "groub-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := httptest.NewServer(mock)
+	defer idp.Close()
+	mock.Issuer = idp.URL
+	oauth.Register(oauth.NewOIDC("mock", idp.URL, "groub", "groub-secret", testRedirectURL, []string{"openid", "email", "profile"}))
+
+	r := gin.New()
+	r.GET("/api/oauth/:provider/start", StartOAuth)
+	r.GET("/api/oauth/:provider/callback", OAuthCallback)
+	api := httptest.NewServer(r)
+	defer api.Close()
+
+	t.Run("login creates member and reuses it", func(t *testing.T) {
+		b := newBrowser(t)
+		code, state := b.authorize(t, api.URL, idp.URL, "sub=alice&email=alice@example.com")
+		res, body := b.get(t, api.URL+"/api/oauth/mock/callback?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(state))
+		if res.StatusCode != http.StatusOK || body["token"] == nil || body["refresh_token"] == nil {
+			t.Fatalf("callback: %d %v", res.StatusCode, body)
+		}
+
+		var ext entity.ExternalIdentity
+		if err := config.DB().Where("provider = ? AND subject = ?", "mock", "alice").First(&ext).Error; err != nil {
+			t.Fatalf("external identity not saved: %v", err)
+		}
+
+		// state ใช้ซ้ำไม่ได้ (cookie ถูกล้างแล้วด้วย)
+		if res, _ := b.get(t, api.URL+"/api/oauth/mock/callback?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(state)); res.StatusCode != http.StatusBadRequest {
+			t.Fatalf("replayed callback: %d, want 400", res.StatusCode)
+		}
+
+		// ล็อกอินรอบสองด้วย sub เดิม = member เดิม
+		code, state = b.authorize(t, api.URL, idp.URL, "sub=alice&email=alice@example.com")
+		if res, body := b.get(t, api.URL+"/api/oauth/mock/callback?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(state)); res.StatusCode != http.StatusOK {
+			t.Fatalf("second login: %d %v", res.StatusCode, body)
+		}
+		var n int64
+		config.DB().Model(&entity.ExternalIdentity{}).Where("subject = ?", "alice").Count(&n)
+		if n != 1 {
+			t.Fatalf("identities for alice = %d, want 1", n)
+		}
+	})
+
+	t.Run("callback from another browser is rejected", func(t *testing.T) {
+		attacker := newBrowser(t)
+		code, state := attacker.authorize(t, api.URL, idp.URL, "sub=mallory")
+		cb := api.URL + "/api/oauth/mock/callback?code=" + url.QueryEscape(code) + "&state=" + url.QueryEscape(state)
+
+		victim := newBrowser(t)
+		if res, _ := victim.get(t, cb); res.StatusCode != http.StatusBadRequest {
+			t.Fatalf("callback without state cookie: %d, want 400", res.StatusCode)
+		}
+		// เบราว์เซอร์ที่เริ่ม flow เองยังใช้ต่อได้
+		if res, body := attacker.get(t, cb); res.StatusCode != http.StatusOK {
+			t.Fatalf("callback from starting browser: %d %v", res.StatusCode, body)
+		}
+	})
+
+	t.Run("state cookie is HttpOnly and scoped to oauth", func(t *testing.T) {
+		res, err := http.Get(api.URL + "/api/oauth/mock/start")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		var found bool
+		for _, ck := range res.Cookies() {
+			if ck.Name != oauthStateCookie {
+				continue
+			}
+			found = true
+			if !ck.HttpOnly || ck.Path != "/api/oauth" || ck.MaxAge <= 0 || ck.SameSite != http.SameSiteLaxMode {
+				t.Fatalf("cookie attributes: %+v", ck)
+			}
+		}
+		if !found {
+			t.Fatal("start did not set the state cookie")
+		}
+	})
+}
+
+// browser = http.Client ที่มี cookie jar และไม่ตาม redirect เอง
+type browser struct{ *http.Client }
+
+func newBrowser(t *testing.T) browser {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return browser{&http.Client{
+		Jar:           jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}}
+}
+
+func (b browser) get(t *testing.T, u string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+	res, err := b.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var body map[string]interface{}
+	_ = json.NewDecoder(res.Body).Decode(&body)
+	return res, body
+}
+
+// authorize = กด start แล้วผ่านหน้า login ของ mock คืน code/state ที่ถูกส่งกลับมาหน้าเว็บ
+func (b browser) authorize(t *testing.T, apiURL, idpURL, identity string) (code, state string) {
+	t.Helper()
+	res, body := b.get(t, apiURL+"/api/oauth/mock/start")
+	authURL, _ := body["auth_url"].(string)
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(authURL, idpURL+"/authorize?") {
+		t.Fatalf("start: %d %v", res.StatusCode, body)
+	}
+
+	res, err := b.Get(authURL + "&" + identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	loc, err := res.Location()
+	if res.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("authorize: %d %v", res.StatusCode, err)
+	}
+	if got := loc.Scheme + "://" + loc.Host + loc.Path; got != testRedirectURL {
+		t.Fatalf("redirected to %s, want %s", got, testRedirectURL)
+	}
+	return loc.Query().Get("code"), loc.Query().Get("state")
+}
@@ -0,0 +1,64 @@
+// controller/order.go
+package controller
+
+import (
+	"net/http"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// createOrder สร้าง order จากการจองที่ยืนยันแล้ว (เรียกใน transaction เดียวกับ inventory.Commit)
+// ราคาต่อชิ้นใช้ราคาปัจจุบันของ variant / สินค้า ณ ตอนยืนยัน
+func createOrder(tx *gorm.DB, memberID uint, rs []entity.StockReservation) (*entity.Order, error) {
+	order := entity.Order{MemberID: memberID}
+	for _, r := range rs {
+		var p entity.Product
+		if err := tx.Select("id", "name", "price", "seller_id").First(&p, r.ProductID).Error; err != nil {
+			return nil, err
+		}
+		price := p.Price
+		if r.VariantID != nil {
+			var v entity.ProductVariant
+			if err := tx.Select("id", "price").First(&v, *r.VariantID).Error; err != nil {
+				return nil, err
+			}
+			price = v.Price
+		}
+		order.Items = append(order.Items, entity.OrderItem{
+			ProductID: r.ProductID,
+			VariantID: r.VariantID,
+			SellerID:  p.SellerID,
+			Name:      p.Name,
+			UnitPrice: price,
+			Quantity:  r.Quantity,
+		})
+		order.TotalPrice += price * r.Quantity
+	}
+	if err := tx.Omit("Member").Create(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// ---------- GET /api/me/orders ----------
+
+func ListMyOrders(c *gin.Context) {
+	memberID := *contextMemberID(c)
+	page, size := pageParams(c, 20, 100)
+
+	q := config.DB().Model(&entity.Order{}).Where("member_id = ?", memberID)
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงคำสั่งซื้อได้"})
+		return
+	}
+	var orders []entity.Order
+	if err := q.Preload("Items").Order("id DESC").Offset((page - 1) * size).Limit(size).Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงคำสั่งซื้อได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": orders, "page": page, "page_size": size, "total": total})
+}
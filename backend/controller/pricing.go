@@ -0,0 +1,147 @@
+// controller/pricing.go
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/pricing"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	priceHistoryDefaultDays = 90
+	priceHistoryMaxDays     = 365
+)
+
+func postProducts(posts []entity.Post_a_New_Product) []*entity.Product {
+	out := make([]*entity.Product, len(posts))
+	for i := range posts {
+		out[i] = &posts[i].Product
+	}
+	return out
+}
+
+// fillLowestPrices เติม lowest_price_30d ให้สินค้าที่จะส่งกลับ (ไม่มีประวัติ = ราคาปัจจุบัน)
+// อ่านไม่ได้ก็ปล่อยเป็น null ไม่ทำให้ทั้งหน้าล้ม
+func fillLowestPrices(db *gorm.DB, products ...*entity.Product) {
+	ids := make([]uint, 0, len(products))
+	for _, p := range products {
+		if p.ID != 0 {
+			ids = append(ids, p.ID)
+		}
+	}
+	lowest, err := pricing.Lowest(db, ids, time.Now())
+	if err != nil {
+		log.Println("lowest price:", err)
+		return
+	}
+	for _, p := range products {
+		if p.ID == 0 {
+			continue
+		}
+		v, ok := lowest[p.ID]
+		if !ok || p.Price < v {
+			v = p.Price
+		}
+		p.LowestPrice30d = &v
+	}
+}
+
+// จุดในกราฟราคา (ไม่เปิดเผยว่าใครแก้)
+type pricePoint struct {
+	At        time.Time `json:"at"`
+	VariantID *uint     `json:"variant_id,omitempty"`
+	OldPrice  *int      `json:"old_price"`
+	NewPrice  int       `json:"new_price"`
+	Source    string    `json:"source"`
+}
+
+// ---------- GET /api/products/:id/price-history?days=90&variant_id= ----------
+// ประวัติราคาของสินค้า (หรือ variant) เก่าไปใหม่ แถวแรกอาจเก่ากว่า days = ราคาที่มีผลอยู่ตอนต้นช่วง
+// เฉพาะสินค้าที่เปิดขายอยู่ (โพสต์ร่าง/ปิดขาย/ลบแล้ว = 404)
+
+func GetPriceHistory(c *gin.Context) {
+	productID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "product id ไม่ถูกต้อง"})
+		return
+	}
+	days := priceHistoryDefaultDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > priceHistoryMaxDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days ต้องอยู่ระหว่าง 1-" + strconv.Itoa(priceHistoryMaxDays)})
+			return
+		}
+		days = n
+	}
+
+	db := config.DB()
+	if ok, err := productPublished(db, productID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ดึงประวัติราคาไม่สำเร็จ"})
+		return
+	} else if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบสินค้า"})
+		return
+	}
+	var product entity.Product
+	if err := db.Select("id", "price").First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบสินค้า"})
+		return
+	}
+	current := product.Price
+
+	q := db.Model(&entity.PriceChange{}).Where("product_id = ?", product.ID)
+	if v := c.Query("variant_id"); v != "" {
+		vid, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "variant_id ไม่ถูกต้อง"})
+			return
+		}
+		var variant entity.ProductVariant
+		if err := db.Select("id", "price").Where("id = ? AND product_id = ?", vid, product.ID).First(&variant).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบ variant ของสินค้านี้"})
+			return
+		}
+		q = q.Where("variant_id = ?", variant.ID)
+		current = variant.Price
+	} else {
+		q = q.Where("variant_id IS NULL")
+	}
+	q = q.Session(&gorm.Session{})
+
+	now := time.Now()
+	since := now.AddDate(0, 0, -days)
+	var rows []entity.PriceChange
+	if err := q.Where("created_at >= ?", since).Order("created_at, id").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ดึงประวัติราคาไม่สำเร็จ"})
+		return
+	}
+	var before entity.PriceChange
+	if err := q.Where("created_at < ?", since).Order("created_at DESC, id DESC").Limit(1).Find(&before).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ดึงประวัติราคาไม่สำเร็จ"})
+		return
+	}
+	if before.ID != 0 {
+		rows = append([]entity.PriceChange{before}, rows...)
+	}
+
+	points := make([]pricePoint, len(rows))
+	for i, r := range rows {
+		points[i] = pricePoint{At: r.CreatedAt, VariantID: r.VariantID, OldPrice: r.OldPrice, NewPrice: r.NewPrice, Source: r.Source}
+	}
+	fillLowestPrices(db, &product)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":             points,
+		"days":             days,
+		"current_price":    current,
+		"lowest_price_30d": product.LowestPrice30d, // ของสินค้า (ไม่แยก variant)
+	})
+}
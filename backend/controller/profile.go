@@ -0,0 +1,368 @@
+// controller/profile.go
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/inventory"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	errUsernameTaken = errors.New("username already exists")
+	errEmailTaken    = errors.New("email already exists")
+)
+
+func loadMemberWithProfile(db *gorm.DB, id uint) (entity.Member, error) {
+	var m entity.Member
+	err := db.Preload("People").
+		Preload("Seller").
+		Preload("Seller.ShopProfile").
+		First(&m, id).Error
+	return m, err
+}
+
+// ---------- PUT /api/me ----------
+
+type UpdateMeReq struct {
+	Username  *string `json:"username"  binding:"omitempty,min=1,max=50"`
+	FirstName *string `json:"firstName" binding:"omitempty,min=1,max=100"`
+	LastName  *string `json:"lastName"  binding:"omitempty,min=1,max=100"`
+	Email     *string `json:"email"     binding:"omitempty,email"`
+	Age       *int    `json:"age"       binding:"omitempty,min=0,max=150"`
+	Phone     *string `json:"phone"     binding:"omitempty,max=20"`
+	Birthday  *string `json:"birthday"` // RFC3339 หรือ YYYY-MM-DD, "" = ล้าง
+	Address   *string `json:"address"   binding:"omitempty,max=500"`
+	GenderID  *uint   `json:"genderID"`
+}
+
+func UpdateMe(c *gin.Context) {
+	var req UpdateMeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid payload", "error": err.Error()})
+		return
+	}
+
+	mid, ok := c.Get("member_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+	memberID := mid.(uint)
+
+	db := config.DB()
+	m, err := loadMemberWithProfile(db, memberID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	}
+
+	var bday *time.Time
+	if req.Birthday != nil {
+		if bday, err = parseTimePtr(strings.TrimSpace(*req.Birthday)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid birthday", "error": err.Error()})
+			return
+		}
+	}
+
+	emailChanged := false
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		// --- members ---
+		if req.Username != nil {
+			name := strings.TrimSpace(*req.Username)
+			if !strings.EqualFold(name, m.UserName) {
+				var n int64
+				if err := tx.Model(&entity.Member{}).
+					Where("LOWER(user_name) = LOWER(?) AND id <> ?", name, m.ID).
+					Count(&n).Error; err != nil {
+					return err
+				}
+				if n > 0 {
+					return errUsernameTaken
+				}
+			}
+			if err := tx.Model(&entity.Member{}).Where("id = ?", m.ID).Update("user_name", name).Error; err != nil {
+				return err
+			}
+		}
+
+		// --- peoples (partial) ---
+		upd := map[string]interface{}{}
+		if req.FirstName != nil {
+			upd["first_name"] = strings.TrimSpace(*req.FirstName)
+		}
+		if req.LastName != nil {
+			upd["last_name"] = strings.TrimSpace(*req.LastName)
+		}
+		if req.Email != nil {
+			email := strings.TrimSpace(*req.Email)
+			if !strings.EqualFold(email, m.People.Email) {
+				if email != "" {
+					var n int64
+					if err := tx.Model(&entity.People{}).
+						Where("LOWER(email) = LOWER(?) AND id <> ?", email, m.PeopleID).
+						Count(&n).Error; err != nil {
+						return err
+					}
+					if n > 0 {
+						return errEmailTaken
+					}
+				}
+				// อีเมลใหม่ต้องยืนยันใหม่
+				upd["email_verified_at"] = nil
+				emailChanged = email != ""
+			}
+			upd["email"] = email
+		}
+		if req.Age != nil {
+			upd["age"] = *req.Age
+		}
+		if req.Phone != nil {
+			upd["phone"] = strings.TrimSpace(*req.Phone)
+		}
+		if req.Birthday != nil {
+			if bday != nil {
+				upd["birth_day"] = *bday
+			} else {
+				upd["birth_day"] = time.Time{}
+			}
+		}
+		if req.Address != nil {
+			upd["address"] = strings.TrimSpace(*req.Address)
+		}
+		if req.GenderID != nil {
+			if *req.GenderID != 0 {
+				if err := tx.First(&entity.Gender{}, *req.GenderID).Error; err != nil {
+					return fmt.Errorf("invalid genderID: %w", err)
+				}
+			}
+			upd["gender_id"] = *req.GenderID
+		}
+
+		if len(upd) > 0 {
+			if err := tx.Model(&entity.People{}).Where("id = ?", m.PeopleID).Updates(upd).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		switch {
+		case errors.Is(err, errUsernameTaken), errors.Is(err, errEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid genderID"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "update profile failed", "error": err.Error()})
+		}
+		return
+	}
+
+	m, _ = loadMemberWithProfile(db, memberID)
+	if emailChanged {
+		if err := sendVerifyEmail(m.ID, m.People.Email); err != nil {
+			log.Println("send verify mail:", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "profile updated", "user": userPayload(m)})
+}
+
+// ---------- PUT /api/me/password ----------
+
+type ChangePasswordReq struct {
+	CurrentPassword string `json:"current_password"` // บัญชีที่ยังไม่มีรหัสผ่าน (สมัครผ่าน OIDC) ไม่ต้องส่ง
+	NewPassword     string `json:"new_password"     binding:"required,min=8"`
+}
+
+// บัญชีที่ไม่มีรหัสผ่านยืนยันตัวตนซ้ำด้วยการล็อกอินใหม่ (ผ่าน OIDC) แล้วใช้ session นั้นภายในช่วงนี้
+const reauthWindow = 10 * time.Minute
+
+// reauthenticate ยืนยันตัวตนซ้ำก่อนทำเรื่องสำคัญ (เปลี่ยนรหัส, ลบบัญชี)
+// มีรหัสผ่าน = password ต้องตรง, ไม่มีรหัสผ่าน = session ที่ใช้อยู่ต้องเพิ่งล็อกอินภายใน reauthWindow
+// ไม่ผ่านจะตอบ 401 ให้แล้ว
+func reauthenticate(c *gin.Context, m *entity.Member, password string) bool {
+	if hasPassword(m) {
+		if bcrypt.CompareHashAndPassword([]byte(m.Password), []byte(password)) != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "password invalid"})
+			return false
+		}
+		return true
+	}
+	var n int64
+	if err := config.DB().Model(&entity.Session{}).
+		Where("id = ? AND member_id = ? AND revoked_at IS NULL AND created_at >= ?",
+			c.GetUint("session_id"), m.ID, time.Now().Add(-reauthWindow)).
+		Count(&n).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+		return false
+	}
+	if n == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "please sign in again to confirm", "reauth_required": true})
+		return false
+	}
+	return true
+}
+
+func ChangePassword(c *gin.Context) {
+	var req ChangePasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	firstPassword := !hasPassword(m)
+	if !reauthenticate(c, m, req.CurrentPassword) {
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "cannot hash password"})
+		return
+	}
+
+	sid := c.GetUint("session_id")
+	if err := config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(m).Update("password", string(hashed)).Error; err != nil {
+			return err
+		}
+		// เตะอุปกรณ์อื่นออก เหลือ session ที่ใช้เปลี่ยนรหัสอยู่
+		return tx.Model(&entity.Session{}).
+			Where("member_id = ? AND id <> ? AND revoked_at IS NULL", m.ID, sid).
+			Update("revoked_at", time.Now()).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "change password failed"})
+		return
+	}
+
+	if firstPassword {
+		c.JSON(http.StatusOK, gin.H{"message": "password set"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
+
+// ---------- DELETE /api/me ----------
+
+type DeleteMeReq struct {
+	Password string `json:"password"` // บัญชีที่ไม่มีรหัสผ่านใช้การล็อกอินใหม่แทน (ดู reauthenticate)
+	Code     string `json:"code"`     // ต้องส่งถ้าเปิด 2FA
+}
+
+// ลบบัญชี = ล้างข้อมูลส่วนตัว แต่เก็บแถว members ไว้ให้ order / DM / discount usage ยังอ้างถึงได้
+func DeleteMe(c *gin.Context) {
+	var req DeleteMeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !reauthenticate(c, m, req.Password) {
+		return
+	}
+
+	var hiddenProducts []uint // สินค้าของผู้ขายที่ต้องเอาออกจากดัชนีค้นหา
+	if err := config.DB().Transaction(func(tx *gorm.DB) error {
+		if m.TOTPEnabled {
+			valid, err := verifySecondFactor(tx, m, req.Code)
+			if err != nil {
+				return err
+			}
+			if !valid {
+				return errTokenInvalid
+			}
+		}
+
+		now := time.Now()
+		// "!" ไม่ใช่ bcrypt hash -> ล็อกอินด้วยรหัสใด ๆ ไม่ได้อีก
+		if err := tx.Model(m).Updates(map[string]interface{}{
+			"user_name":      fmt.Sprintf("deleted_%d", m.ID),
+			"password":       "!",
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"deactivated_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.People{}).Where("id = ?", m.PeopleID).Updates(map[string]interface{}{
+			"first_name":        "Deleted",
+			"last_name":         "User",
+			"email":             "",
+			"email_verified_at": nil,
+			"age":               0,
+			"phone":             "",
+			"birth_day":         time.Time{},
+			"address":           "",
+			"gender_id":         0,
+		}).Error; err != nil {
+			return err
+		}
+
+		// ผู้ขาย: ล้างชื่อ/ที่อยู่ผู้ขาย และซ่อนโพสต์ขาย (สินค้ายังอยู่ให้ order เดิมอ้างถึง)
+		var seller entity.Seller
+		if err := tx.Where("member_id = ?", m.ID).First(&seller).Error; err == nil {
+			if err := tx.Model(&seller).Updates(map[string]interface{}{
+				"name":    "Deleted seller",
+				"address": "",
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&entity.Post_a_New_Product{}).Where("seller_id = ? AND product_id IS NOT NULL", seller.ID).
+				Pluck("product_id", &hiddenProducts).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("seller_id = ?", seller.ID).Delete(&entity.Post_a_New_Product{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&entity.SellerAPIKey{}).
+				Where("seller_id = ? AND revoked_at IS NULL", seller.ID).
+				Update("revoked_at", now).Error; err != nil {
+				return err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := inventory.ReleaseAll(tx, m.ID); err != nil {
+			return err
+		}
+		if err := tx.Where("member_id = ?", m.ID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("member_id = ?", m.ID).Delete(&entity.ExternalIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.AuthToken{}).
+			Where("member_id = ? AND used_at IS NULL", m.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return revokeAllSessions(tx, m.ID)
+	}); err != nil {
+		if errors.Is(err, errTokenInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid 2FA code"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "delete account failed"})
+		return
+	}
+
+	reindexProducts(config.DB(), hiddenProducts...)
+
+	c.JSON(http.StatusOK, gin.H{"message": "account deleted"})
+}
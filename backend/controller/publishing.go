@@ -0,0 +1,97 @@
+// controller/publishing.go
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/inventory"
+	"example.com/GROUB/publishing"
+	"github.com/gin-gonic/gin"
+)
+
+func publishErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, publishing.ErrInvalidTransition),
+		errors.Is(err, publishing.ErrConcurrentUpdate):
+		return http.StatusConflict
+	case errors.Is(err, publishing.ErrInvalidStatus),
+		errors.Is(err, publishing.ErrPublishAtRequired),
+		errors.Is(err, publishing.ErrUnpublishAt):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// ---------- PUT /api/seller/posts/:id/status ----------
+// เปลี่ยนสถานะโพสต์ / ตั้งเวลาเปิด-พักการขาย (ส่งสถานะเดิม = แก้แค่เวลา)
+
+type PostStatusReq struct {
+	Status      string     `json:"status" binding:"required"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+func UpdatePostStatus(c *gin.Context) {
+	var req PostStatusReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	s, ok := currentSeller(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "post id ไม่ถูกต้อง"})
+		return
+	}
+	db := config.DB()
+	var post entity.Post_a_New_Product
+	if err := db.Where("id = ? AND seller_id = ?", id, s.ID).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบโพสต์นี้"})
+		return
+	}
+	before := post
+
+	if err := publishing.Transition(db, &post, publishing.Schedule{
+		Status: req.Status, PublishAt: req.PublishAt, UnpublishAt: req.UnpublishAt,
+	}); err != nil {
+		h := gin.H{"error": err.Error()}
+		if errors.Is(err, publishing.ErrInvalidTransition) {
+			h["allowed"] = publishing.Allowed(before.Status)
+		}
+		c.JSON(publishErrorStatus(err), h)
+		return
+	}
+
+	recordAudit(c, auditEvent{Action: "post.status_change", Detail: before.Status + " -> " + post.Status,
+		TargetType: "post", TargetID: post.ID,
+		Before: gin.H{"status": before.Status, "publish_at": before.PublishAt, "unpublish_at": before.UnpublishAt},
+		After:  gin.H{"status": post.Status, "publish_at": post.PublishAt, "unpublish_at": post.UnpublishAt}})
+	c.JSON(http.StatusOK, gin.H{"message": "อัปเดตสถานะโพสต์สำเร็จ", "data": post, "allowed": publishing.Allowed(post.Status)})
+}
+
+// สินค้าที่ไม่ได้เปิดขาย (ไม่มีโพสต์ published) ในรายการ ใช้กันจองสินค้าที่ยังไม่เปิดขาย
+func unpublishedLine(lines []inventory.Line) (*inventory.Line, error) {
+	ids := make([]uint, 0, len(lines))
+	for _, l := range lines {
+		ids = append(ids, l.ProductID)
+	}
+	var live []uint
+	if err := config.DB().Model(&entity.Post_a_New_Product{}).
+		Where("product_id IN ? AND status = ?", ids, entity.PostPublished).
+		Pluck("product_id", &live).Error; err != nil {
+		return nil, err
+	}
+	for i := range lines {
+		if !containsUint(live, lines[i].ProductID) {
+			return &lines[i], nil
+		}
+	}
+	return nil, nil
+}
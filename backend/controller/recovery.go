@@ -0,0 +1,262 @@
+// controller/recovery.go
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/mailer"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	passwordResetTTL = 1 * time.Hour
+	emailVerifyTTL   = 48 * time.Hour
+)
+
+var errTokenInvalid = errors.New("token invalid or expired")
+
+// ลิงก์ในอีเมลชี้ไปหน้าเว็บ (APP_URL) ให้หน้าเว็บเรียก API ต่อ
+func appURL(path string, token string) string {
+	base := os.Getenv("APP_URL")
+	if base == "" {
+		base = "http://localhost:5173"
+	}
+	return strings.TrimRight(base, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// สร้าง token ใหม่ (ยกเลิกตัวเก่าที่ยังไม่ได้ใช้ของจุดประสงค์เดียวกัน) คืนตัวจริงไว้ส่งเมล
+func createAuthToken(tx *gorm.DB, memberID uint, purpose, email string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	if err := tx.Model(&entity.AuthToken{}).
+		Where("member_id = ? AND purpose = ? AND used_at IS NULL", memberID, purpose).
+		Update("used_at", now).Error; err != nil {
+		return "", err
+	}
+
+	raw, hash, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	t := entity.AuthToken{
+		MemberID:  memberID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		Email:     email,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := tx.Create(&t).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// ใช้ token (mark used แบบมีเงื่อนไข กันใช้ซ้ำพร้อมกัน)
+func consumeAuthToken(tx *gorm.DB, raw, purpose string) (*entity.AuthToken, error) {
+	var t entity.AuthToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", hashToken(raw), purpose).First(&t).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errTokenInvalid
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if t.UsedAt != nil || now.After(t.ExpiresAt) {
+		return nil, errTokenInvalid
+	}
+
+	res := tx.Model(&entity.AuthToken{}).
+		Where("id = ? AND used_at IS NULL", t.ID).
+		Update("used_at", now)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, errTokenInvalid
+	}
+	return &t, nil
+}
+
+func sendVerifyEmail(memberID uint, email string) error {
+	raw, err := createAuthToken(config.DB(), memberID, entity.TokenEmailVerify, email, emailVerifyTTL)
+	if err != nil {
+		return err
+	}
+	return mailer.Default().Send(mailer.Message{
+		To:      email,
+		Subject: "ยืนยันอีเมลของคุณ",
+		Body: fmt.Sprintf("กดลิงก์นี้เพื่อยืนยันอีเมล (หมดอายุใน %d ชั่วโมง)\n\n%s\n",
+			int(emailVerifyTTL.Hours()), appURL("/verify-email", raw)),
+	})
+}
+
+// ---------- POST /api/password/forgot ----------
+
+type ForgotPasswordReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+func ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+
+	// ตอบเหมือนกันเสมอ ไม่บอกว่ามีอีเมลนี้ในระบบหรือไม่
+	ok := gin.H{"message": "if the email exists, a reset link has been sent"}
+
+	db := config.DB()
+	var m entity.Member
+	if err := db.Preload("People").
+		Joins("JOIN peoples ON peoples.id = members.people_id AND peoples.deleted_at IS NULL").
+		Where("LOWER(peoples.email) = LOWER(?)", strings.TrimSpace(req.Email)).
+		First(&m).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Println("forgot password lookup:", err)
+		}
+		c.JSON(http.StatusOK, ok)
+		return
+	}
+
+	raw, err := createAuthToken(db, m.ID, entity.TokenPasswordReset, m.People.Email, passwordResetTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "cannot create reset token"})
+		return
+	}
+	if err := mailer.Default().Send(mailer.Message{
+		To:      m.People.Email,
+		Subject: "ตั้งรหัสผ่านใหม่",
+		Body: fmt.Sprintf("มีคำขอตั้งรหัสผ่านใหม่สำหรับบัญชี %s\nกดลิงก์นี้ภายใน %d นาที (ถ้าไม่ได้ขอ ไม่ต้องทำอะไร)\n\n%s\n",
+			m.UserName, int(passwordResetTTL.Minutes()), appURL("/reset-password", raw)),
+	}); err != nil {
+		log.Println("send reset mail:", err)
+	}
+
+	c.JSON(http.StatusOK, ok)
+}
+
+// ---------- POST /api/password/reset ----------
+
+type ResetPasswordReq struct {
+	Token       string `json:"token"        binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+func ResetPassword(c *gin.Context) {
+	var req ResetPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "cannot hash password"})
+		return
+	}
+
+	if err := config.DB().Transaction(func(tx *gorm.DB) error {
+		t, err := consumeAuthToken(tx, req.Token, entity.TokenPasswordReset)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.Member{}).
+			Where("id = ?", t.MemberID).
+			Update("password", string(hashed)).Error; err != nil {
+			return err
+		}
+		// รหัสเปลี่ยน -> เตะทุกอุปกรณ์ออก
+		return revokeAllSessions(tx, t.MemberID)
+	}); err != nil {
+		if errors.Is(err, errTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "reset link is invalid or expired"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "reset password failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password has been reset"})
+}
+
+// ---------- POST /api/email/verify/request ----------
+
+func RequestEmailVerification(c *gin.Context) {
+	mid, ok := c.Get("member_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	var m entity.Member
+	if err := config.DB().Preload("People").First(&m, mid.(uint)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	}
+	if m.People.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "no email on this account"})
+		return
+	}
+	if m.People.EmailVerifiedAt != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "email already verified"})
+		return
+	}
+
+	if err := sendVerifyEmail(m.ID, m.People.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "cannot send verification email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
+
+// ---------- POST /api/email/verify ----------
+
+type VerifyEmailReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func VerifyEmail(c *gin.Context) {
+	var req VerifyEmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+
+	if err := config.DB().Transaction(func(tx *gorm.DB) error {
+		t, err := consumeAuthToken(tx, req.Token, entity.TokenEmailVerify)
+		if err != nil {
+			return err
+		}
+		var m entity.Member
+		if err := tx.Preload("People").First(&m, t.MemberID).Error; err != nil {
+			return err
+		}
+		// เปลี่ยนอีเมลไปแล้วหลังขอ token -> ลิงก์เก่าใช้ไม่ได้
+		if !strings.EqualFold(m.People.Email, t.Email) {
+			return errTokenInvalid
+		}
+		return tx.Model(&entity.People{}).
+			Where("id = ?", m.PeopleID).
+			Update("email_verified_at", time.Now()).Error
+	}); err != nil {
+		if errors.Is(err, errTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "verification link is invalid or expired"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "verify email failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
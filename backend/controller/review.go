@@ -0,0 +1,520 @@
+// controller/review.go
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	reviewUploadDir = "reviews" // ใต้ /uploads
+	maxReviewPhotos = 5
+)
+
+var (
+	errReviewPhoto     = errors.New("รูปรีวิวต้องอัปโหลดผ่าน /api/reviews/photos")
+	errReviewPhotoUsed = errors.New("รูปนี้เป็นของรีวิวอื่น กรุณาอัปโหลดรูปใหม่")
+)
+
+// สรุปคะแนนรีวิว (ไม่นับรีวิวที่ถูกซ่อน)
+type ratingSummary struct {
+	Average      float64       `json:"average"`
+	Count        int64         `json:"count"`
+	Distribution map[int]int64 `json:"distribution"` // ดาว -> จำนวนรีวิว
+}
+
+// คะแนนรวมของสินค้า (col = "product_id") หรือทั้งร้าน (col = "seller_id")
+func loadRatingSummary(db *gorm.DB, col string, id uint) (ratingSummary, error) {
+	s := ratingSummary{Distribution: map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+	rows, err := db.Model(&entity.Review{}).
+		Select("rating, COUNT(*)").
+		Where(col+" = ? AND hidden_at IS NULL", id).
+		Group("rating").Rows()
+	if err != nil {
+		return s, err
+	}
+	defer rows.Close()
+	var sum int64
+	for rows.Next() {
+		var rating int
+		var n int64
+		if err := rows.Scan(&rating, &n); err != nil {
+			return s, err
+		}
+		s.Distribution[rating] = n
+		s.Count += n
+		sum += int64(rating) * n
+	}
+	if s.Count > 0 {
+		s.Average = math.Round(float64(sum)/float64(s.Count)*100) / 100
+	}
+	return s, rows.Err()
+}
+
+// คำนวณคะแนนเฉลี่ยของสินค้าใหม่ เก็บไว้ที่ products ให้หน้ารายการสินค้าใช้ได้เลย
+func recomputeRating(tx *gorm.DB, productID uint) error {
+	s, err := loadRatingSummary(tx, "product_id", productID)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&entity.Product{}).Where("id = ?", productID).
+		UpdateColumns(map[string]interface{}{"rating_avg": s.Average, "rating_count": s.Count}).Error
+}
+
+// เคยสั่งซื้อสินค้านี้จริงหรือไม่ (ต้องมี order ที่ยืนยันแล้ว)
+func hasPurchased(db *gorm.DB, memberID, productID uint) (bool, error) {
+	var n int64
+	err := db.Model(&entity.OrderItem{}).
+		Joins("JOIN orders o ON o.id = order_items.order_id AND o.deleted_at IS NULL").
+		Where("o.member_id = ? AND order_items.product_id = ?", memberID, productID).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// รูปต้องเป็นไฟล์ที่อัปโหลดไว้ใต้ /uploads/reviews เท่านั้น และต้องไม่ใช่รูปของรีวิวอื่น
+// (reviewID = รีวิวที่กำลังแก้, สร้างใหม่ = 0) ไม่งั้นแก้/ลบรีวิวตัวเองแล้วไฟล์ของคนอื่นหายไปด้วย
+func reviewPhotos(ctx context.Context, db *gorm.DB, reviewID uint, paths []string) ([]entity.ReviewPhoto, error) {
+	out := make([]entity.ReviewPhoto, 0, len(paths))
+	st := storage.Default()
+	for _, p := range paths {
+		key, ok := uploadKey(p)
+		if !ok || !strings.HasPrefix(key, reviewUploadDir+"/") {
+			return nil, errReviewPhoto
+		}
+		if _, err := st.Stat(ctx, key); err != nil {
+			return nil, errReviewPhoto
+		}
+		var n int64
+		if err := db.Unscoped().Model(&entity.ReviewPhoto{}).
+			Where("image_path = ? AND review_id <> ?", storage.URL(key), reviewID).
+			Count(&n).Error; err != nil {
+			return nil, errReviewPhoto
+		} else if n > 0 {
+			return nil, errReviewPhotoUsed
+		}
+		out = append(out, entity.ReviewPhoto{ImagePath: storage.URL(key)})
+	}
+	return out, nil
+}
+
+// หารีวิวจาก :id (ไม่พบ = ตอบ 404 ให้แล้ว)
+func findReview(c *gin.Context) (*entity.Review, bool) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "review id ไม่ถูกต้อง"})
+		return nil, false
+	}
+	var r entity.Review
+	if err := config.DB().Preload("Photos").First(&r, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบรีวิวนี้"})
+		return nil, false
+	}
+	return &r, true
+}
+
+// ---------- POST /api/reviews/photos ----------
+
+func UploadReviewPhotos(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	files := form.File["files"]
+	if len(files) == 0 || len(files) > maxReviewPhotos {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("อัปโหลดได้ 1-%d รูป", maxReviewPhotos)})
+		return
+	}
+	imgs, ok := saveImageFiles(c, files, reviewUploadDir)
+	if !ok {
+		return
+	}
+	urls := make([]string, 0, len(imgs))
+	for _, img := range imgs {
+		urls = append(urls, img.URL)
+	}
+	c.JSON(http.StatusOK, gin.H{"urls": urls, "images": imgs})
+}
+
+// ---------- POST /api/products/:id/reviews ----------
+// รีวิวได้เฉพาะคนที่เคยสั่งซื้อสินค้านี้ คนละ 1 รีวิวต่อสินค้า (แก้ไขภายหลังได้)
+
+type ReviewReq struct {
+	Rating int      `json:"rating" binding:"required,min=1,max=5"`
+	Text   string   `json:"text" binding:"max=2000"`
+	Photos []string `json:"photos" binding:"max=5"`
+}
+
+func CreateReview(c *gin.Context) {
+	var req ReviewReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง (rating 1-5, รูปไม่เกิน 5 รูป)"})
+		return
+	}
+	productID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "product id ไม่ถูกต้อง"})
+		return
+	}
+	memberID := *contextMemberID(c)
+	db := config.DB()
+
+	var p entity.Product
+	if err := db.Select("id", "seller_id").First(&p, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบสินค้านี้"})
+		return
+	}
+	var own int64
+	db.Model(&entity.Seller{}).Where("id = ? AND member_id = ?", p.SellerID, memberID).Count(&own)
+	if own > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "ไม่สามารถรีวิวสินค้าของร้านตัวเองได้"})
+		return
+	}
+	bought, err := hasPurchased(db, memberID, p.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ตรวจสอบประวัติการสั่งซื้อไม่สำเร็จ"})
+		return
+	}
+	if !bought {
+		c.JSON(http.StatusForbidden, gin.H{"error": "รีวิวได้เฉพาะสินค้าที่เคยสั่งซื้อ"})
+		return
+	}
+	photos, err := reviewPhotos(c.Request.Context(), db, 0, req.Photos)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var n int64
+	db.Model(&entity.Review{}).Where("product_id = ? AND member_id = ?", p.ID, memberID).Count(&n)
+	if n > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "คุณรีวิวสินค้านี้แล้ว (แก้ไขรีวิวเดิมได้)"})
+		return
+	}
+
+	r := entity.Review{
+		ProductID: p.ID,
+		MemberID:  memberID,
+		SellerID:  p.SellerID,
+		Rating:    req.Rating,
+		Text:      strings.TrimSpace(req.Text),
+		Photos:    photos,
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&r).Error; err != nil {
+			return err
+		}
+		return recomputeRating(tx, p.ID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "บันทึกรีวิวไม่สำเร็จ"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "บันทึกรีวิวสำเร็จ", "data": r})
+}
+
+// ---------- PUT /api/reviews/:id ----------
+// แก้ไขรีวิวของตัวเอง (photos = ชุดใหม่ทั้งหมด ไม่ส่ง = คงเดิม)
+
+type UpdateReviewReq struct {
+	Rating int       `json:"rating" binding:"required,min=1,max=5"`
+	Text   string    `json:"text" binding:"max=2000"`
+	Photos *[]string `json:"photos" binding:"omitempty,max=5"`
+}
+
+func UpdateReview(c *gin.Context) {
+	var req UpdateReviewReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง (rating 1-5, รูปไม่เกิน 5 รูป)"})
+		return
+	}
+	r, ok := findReview(c)
+	if !ok {
+		return
+	}
+	if r.MemberID != *contextMemberID(c) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบรีวิวนี้"})
+		return
+	}
+	db := config.DB()
+	var photos []entity.ReviewPhoto
+	if req.Photos != nil {
+		var err error
+		if photos, err = reviewPhotos(c.Request.Context(), db, r.ID, *req.Photos); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	old := r.Photos
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(r).Updates(map[string]interface{}{
+			"rating": req.Rating,
+			"text":   strings.TrimSpace(req.Text),
+		}).Error; err != nil {
+			return err
+		}
+		if req.Photos != nil {
+			if err := tx.Unscoped().Where("review_id = ?", r.ID).Delete(&entity.ReviewPhoto{}).Error; err != nil {
+				return err
+			}
+			for i := range photos {
+				photos[i].ReviewID = r.ID
+			}
+			if len(photos) > 0 {
+				if err := tx.Create(&photos).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return recomputeRating(tx, r.ProductID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "แก้ไขรีวิวไม่สำเร็จ"})
+		return
+	}
+
+	// ลบไฟล์รูปที่ไม่ได้ใช้แล้วหลัง commit
+	if req.Photos != nil {
+		keep := map[string]bool{}
+		for _, ph := range photos {
+			keep[ph.ImagePath] = true
+		}
+		for _, ph := range old {
+			if !keep[ph.ImagePath] {
+				removeUnusedUnder(db, reviewUploadDir, ph.ImagePath)
+			}
+		}
+	}
+
+	_ = db.Preload("Photos").First(r, r.ID).Error
+	c.JSON(http.StatusOK, gin.H{"message": "แก้ไขรีวิวสำเร็จ", "data": r})
+}
+
+// ---------- DELETE /api/reviews/:id ----------
+// ลบจริง (ไม่ soft delete) เพื่อให้รีวิวสินค้าเดิมใหม่ได้
+// รีวิวที่ admin ซ่อนไว้ลบเองไม่ได้ ไม่งั้นลบแล้วโพสต์ใหม่ก็กลับมาแสดงโดยไม่ผ่านการตรวจ
+
+func DeleteReview(c *gin.Context) {
+	r, ok := findReview(c)
+	if !ok {
+		return
+	}
+	if r.MemberID != *contextMemberID(c) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบรีวิวนี้"})
+		return
+	}
+	if r.HiddenAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "รีวิวนี้ถูกซ่อนโดยผู้ดูแล ลบเองไม่ได้ (แก้ไขได้ หรือติดต่อผู้ดูแล)"})
+		return
+	}
+	db := config.DB()
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("review_id = ?", r.ID).Delete(&entity.ReviewPhoto{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&entity.Review{}, r.ID).Error; err != nil {
+			return err
+		}
+		return recomputeRating(tx, r.ProductID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ลบรีวิวไม่สำเร็จ"})
+		return
+	}
+	for _, ph := range r.Photos {
+		removeUnusedUnder(db, reviewUploadDir, ph.ImagePath)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบรีวิวสำเร็จ"})
+}
+
+// ---------- GET /api/products/:id/reviews?rating=&with_photos=1&page=&page_size= ----------
+// เฉพาะสินค้าที่เปิดขายอยู่ (โพสต์ร่าง/ปิดขาย/ลบแล้ว = 404)
+
+func ListProductReviews(c *gin.Context) {
+	productID, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "product id ไม่ถูกต้อง"})
+		return
+	}
+	page, size := pageParams(c, 20, 100)
+	db := config.DB()
+
+	if ok, err := productPublished(db, productID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงรีวิวได้"})
+		return
+	} else if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบสินค้า"})
+		return
+	}
+
+	q := db.Model(&entity.Review{}).Where("reviews.product_id = ? AND reviews.hidden_at IS NULL", productID)
+	if v := c.Query("rating"); v != "" {
+		q = q.Where("reviews.rating = ?", v)
+	}
+	if c.Query("with_photos") == "1" {
+		q = q.Where("EXISTS (SELECT 1 FROM review_photos rp WHERE rp.review_id = reviews.id AND rp.deleted_at IS NULL)")
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงรีวิวได้"})
+		return
+	}
+	var rows []entity.Review
+	if err := q.Select("reviews.*, m.user_name AS reviewer_name").
+		Joins("LEFT JOIN members m ON m.id = reviews.member_id").
+		Preload("Photos").
+		Order("reviews.created_at DESC, reviews.id DESC").
+		Offset((page - 1) * size).Limit(size).
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงรีวิวได้"})
+		return
+	}
+	summary, err := loadRatingSummary(db, "product_id", productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงรีวิวได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows, "page": page, "page_size": size, "total": total, "rating": summary})
+}
+
+// ---------- POST /api/reviews/:id/reply ----------
+// ผู้ขายตอบรีวิวสินค้าของร้านตัวเองได้ 1 ครั้ง (แสดงต่อสาธารณะ)
+
+type ReviewReplyReq struct {
+	Reply string `json:"reply" binding:"required,max=2000"`
+}
+
+func ReplyReview(c *gin.Context) {
+	var req ReviewReplyReq
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Reply) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณากรอกข้อความตอบกลับ"})
+		return
+	}
+	s, ok := currentSeller(c)
+	if !ok {
+		return
+	}
+	r, ok := findReview(c)
+	if !ok {
+		return
+	}
+	if r.SellerID != s.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบรีวิวนี้"})
+		return
+	}
+
+	now := time.Now()
+	// เงื่อนไข seller_reply = '' กันตอบซ้อนกันสองครั้งพร้อมกัน
+	res := config.DB().Model(&entity.Review{}).
+		Where("id = ? AND (seller_reply IS NULL OR seller_reply = '')", r.ID).
+		UpdateColumns(map[string]interface{}{"seller_reply": strings.TrimSpace(req.Reply), "seller_reply_at": now})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "บันทึกคำตอบไม่สำเร็จ"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "ตอบรีวิวนี้ไปแล้ว"})
+		return
+	}
+	r.SellerReply = strings.TrimSpace(req.Reply)
+	r.SellerReplyAt = &now
+	recordAudit(c, auditEvent{Action: "review.reply", TargetType: "review", TargetID: r.ID})
+	c.JSON(http.StatusOK, gin.H{"message": "ตอบรีวิวสำเร็จ", "data": r})
+}
+
+// ---------- GET /api/admin/reviews?hidden=1&product_id=&seller_id=&page=&page_size= ----------
+
+func AdminListReviews(c *gin.Context) {
+	page, size := pageParams(c, 50, 200)
+	q := config.DB().Model(&entity.Review{})
+	switch c.Query("hidden") {
+	case "1":
+		q = q.Where("reviews.hidden_at IS NOT NULL")
+	case "0":
+		q = q.Where("reviews.hidden_at IS NULL")
+	}
+	if v := c.Query("product_id"); v != "" {
+		q = q.Where("reviews.product_id = ?", v)
+	}
+	if v := c.Query("seller_id"); v != "" {
+		q = q.Where("reviews.seller_id = ?", v)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงรีวิวได้"})
+		return
+	}
+	var rows []entity.Review
+	if err := q.Select("reviews.*, m.user_name AS reviewer_name").
+		Joins("LEFT JOIN members m ON m.id = reviews.member_id").
+		Preload("Photos").
+		Order("reviews.id DESC").
+		Offset((page - 1) * size).Limit(size).
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงรีวิวได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows, "page": page, "page_size": size, "total": total})
+}
+
+// ---------- PUT /api/admin/reviews/:id/hide ----------
+// ---------- DELETE /api/admin/reviews/:id/hide ----------
+// ซ่อนรีวิวที่ไม่เหมาะสม (ไม่ลบ เผื่อกู้คืน) รีวิวที่ซ่อนไม่นับในคะแนนเฉลี่ย
+
+type HideReviewReq struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+func HideReview(c *gin.Context) {
+	var req HideReviewReq
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุเหตุผลที่ซ่อนรีวิว"})
+		return
+	}
+	setReviewHidden(c, true, strings.TrimSpace(req.Reason))
+}
+
+func UnhideReview(c *gin.Context) {
+	setReviewHidden(c, false, "")
+}
+
+func setReviewHidden(c *gin.Context, hide bool, reason string) {
+	r, ok := findReview(c)
+	if !ok {
+		return
+	}
+	before := *r
+
+	upd := map[string]interface{}{"hidden_at": nil, "hidden_reason": "", "hidden_by": nil}
+	if hide {
+		upd = map[string]interface{}{"hidden_at": time.Now(), "hidden_reason": reason, "hidden_by": *contextMemberID(c)}
+	}
+	db := config.DB()
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Review{}).Where("id = ?", r.ID).UpdateColumns(upd).Error; err != nil {
+			return err
+		}
+		return recomputeRating(tx, r.ProductID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "อัปเดตรีวิวไม่สำเร็จ"})
+		return
+	}
+	_ = db.Preload("Photos").First(r, r.ID).Error
+
+	action, msg := "review.unhide", "แสดงรีวิวอีกครั้งแล้ว"
+	if hide {
+		action, msg = "review.hide", "ซ่อนรีวิวแล้ว"
+	}
+	recordAudit(c, auditEvent{Action: action, Detail: reason, TargetType: "review", TargetID: r.ID,
+		Before: before, After: r})
+	c.JSON(http.StatusOK, gin.H{"message": msg, "data": r})
+}
@@ -0,0 +1,108 @@
+// controller/search.go
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/search"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const searchSnippetWidth = 120
+
+// อัปเดตดัชนีค้นหา ล้มเหลวแค่ log ไว้ (ดัชนีจะถูก rebuild ตอนสตาร์ตถ้าจำนวนไม่ตรง)
+func reindexProducts(db *gorm.DB, ids ...uint) {
+	if err := search.IndexProducts(db, ids...); err != nil {
+		log.Println("search index:", err)
+	}
+}
+
+type searchResult struct {
+	entity.Post_a_New_Product
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// ---------- GET /api/products/search?q=&page=&page_size= ----------
+
+func SearchProducts(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ต้องระบุคำค้น q"})
+		return
+	}
+	if len([]rune(q)) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "คำค้นยาวเกินไป"})
+		return
+	}
+	page, size := pageParams(c, 20, 100)
+
+	db := config.DB()
+	hits, total, err := search.Search(db, q, size, (page-1)*size)
+	if err != nil {
+		log.Println("search:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ค้นหาไม่สำเร็จ"})
+		return
+	}
+
+	data := make([]searchResult, 0, len(hits))
+	if len(hits) > 0 {
+		ids := make([]uint, len(hits))
+		for i, h := range hits {
+			ids[i] = h.ProductID
+		}
+		var posts []entity.Post_a_New_Product
+		if err := db.Preload("Product.ProductImage").Preload("Category").Preload("Seller").Preload("Seller.ShopProfile").
+			Where("product_id IN ? AND status = ?", ids, entity.PostPublished).
+			Find(&posts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลสินค้าได้"})
+			return
+		}
+		fillLowestPrices(db, postProducts(posts)...)
+		byProduct := make(map[uint]entity.Post_a_New_Product, len(posts))
+		for _, p := range posts {
+			if p.Product_ID != nil {
+				byProduct[*p.Product_ID] = p
+			}
+		}
+
+		terms := search.Terms(q)
+		for _, h := range hits {
+			p, ok := byProduct[h.ProductID]
+			if !ok {
+				continue
+			}
+			data = append(data, searchResult{
+				Post_a_New_Product: p,
+				Score:              h.Score,
+				Highlights: map[string]string{
+					"name":        search.Highlight(p.Product.Name, terms),
+					"description": search.Snippet(p.Product.Description, terms, searchSnippetWidth),
+				},
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      data,
+		"page":      page,
+		"page_size": size,
+		"total":     total,
+	})
+}
+
+// ---------- POST /api/admin/search/reindex ----------
+
+func RebuildSearchIndex(c *gin.Context) {
+	if err := search.Rebuild(config.DB()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "reindex failed", "error": err.Error()})
+		return
+	}
+	recordAudit(c, auditEvent{Action: "search.reindex", Detail: search.Engine()})
+	c.JSON(http.StatusOK, gin.H{"message": "search index rebuilt", "engine": search.Engine()})
+}
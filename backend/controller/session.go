@@ -0,0 +1,229 @@
+// controller/session.go
+package controller
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute    // access token อายุสั้น
+	refreshTokenTTL = 30 * 24 * time.Hour // refresh token อายุยาว (หมุนทุกครั้งที่ใช้)
+)
+
+var errSecretMissing = errors.New("SECRET not set")
+
+// ---------- helpers ----------
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// สุ่ม token แบบ opaque คืนทั้งตัวจริง (ส่งให้ client) และ hash (เก็บใน DB)
+func newOpaqueToken() (raw, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	raw = base64.RawURLEncoding.EncodeToString(b)
+	return raw, hashToken(raw), nil
+}
+
+func signAccessToken(m entity.Member, sessionID uint) (string, error) {
+	secret := os.Getenv("SECRET")
+	if secret == "" {
+		return "", errSecretMissing
+	}
+	now := time.Now()
+	claims := AuthClaims{
+		MemberID:  m.ID,
+		Username:  m.UserName,
+		Role:      m.Role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return tok.SignedString([]byte(secret))
+}
+
+type issuedTokens struct {
+	AccessToken  string
+	RefreshToken string
+	SessionID    uint
+}
+
+// สร้าง session ใหม่ + ออก access/refresh token ให้ member
+func issueTokens(c *gin.Context, m entity.Member) (*issuedTokens, error) {
+	if os.Getenv("SECRET") == "" {
+		return nil, errSecretMissing
+	}
+	raw, hash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s := entity.Session{
+		MemberID:         m.ID,
+		RefreshTokenHash: hash,
+		ExpiresAt:        now.Add(refreshTokenTTL),
+		LastUsedAt:       now,
+		UserAgent:        truncate(c.Request.UserAgent(), 255),
+		IP:               c.ClientIP(),
+	}
+	if err := config.DB().Create(&s).Error; err != nil {
+		return nil, err
+	}
+
+	access, err := signAccessToken(m, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &issuedTokens{AccessToken: access, RefreshToken: raw, SessionID: s.ID}, nil
+}
+
+// เพิกถอนทุก session ของ member (ใช้ตอน logout ทุกอุปกรณ์/เปลี่ยนรหัส/แบนบัญชี)
+func revokeAllSessions(tx *gorm.DB, memberID uint) error {
+	return tx.Model(&entity.Session{}).
+		Where("member_id = ? AND revoked_at IS NULL", memberID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// ---------- POST /api/token/refresh ----------
+
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func RefreshToken(c *gin.Context) {
+	var req RefreshTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+	hash := hashToken(req.RefreshToken)
+
+	var s entity.Session
+	if err := db.Where("refresh_token_hash = ?", hash).First(&s).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+			return
+		}
+		// token เก่าที่หมุนไปแล้วถูกนำกลับมาใช้ -> ถือว่าหลุด เพิกถอน session นั้นทิ้ง
+		var stolen entity.Session
+		if db.Where("prev_token_hash = ?", hash).First(&stolen).Error == nil {
+			_ = db.Model(&stolen).Update("revoked_at", time.Now()).Error
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid refresh token"})
+		return
+	}
+
+	now := time.Now()
+	if s.RevokedAt != nil || now.After(s.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "session expired or revoked"})
+		return
+	}
+
+	var m entity.Member
+	if err := db.First(&m, s.MemberID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "user not found"})
+		return
+	}
+
+	// หมุน refresh token (เช็ค hash เดิมใน WHERE กันสอง request หมุนพร้อมกัน)
+	raw, newHash, err := newOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "token error"})
+		return
+	}
+	res := db.Model(&entity.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", s.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": newHash,
+			"prev_token_hash":    hash,
+			"last_used_at":       now,
+		})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid refresh token"})
+		return
+	}
+
+	access, err := signAccessToken(m, s.ID)
+	if err != nil {
+		if errors.Is(err, errSecretMissing) {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "server misconfigured: SECRET not set"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "token error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         access,
+		"refresh_token": raw,
+		"expires_in":    int(accessTokenTTL.Seconds()),
+	})
+}
+
+// ---------- POST /api/logout ----------
+
+func Logout(c *gin.Context) {
+	sid, ok := c.Get("session_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	if err := config.DB().Model(&entity.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sid.(uint)).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "logout failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logout success"})
+}
+
+// ---------- POST /api/logout-all ----------
+
+func LogoutAll(c *gin.Context) {
+	mid, ok := c.Get("member_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	if err := revokeAllSessions(config.DB(), mid.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "logout failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out from all devices"})
+}
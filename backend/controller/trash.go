@@ -0,0 +1,114 @@
+// controller/trash.go
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/trash"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// โพสต์ในถังขยะ + เวลาที่จะถูกลบถาวร
+type trashedPost struct {
+	entity.Post_a_New_Product
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// ---------- GET /api/seller/posts/trash?page=&page_size= ----------
+// โพสต์ที่ลบไปแล้วแต่ยังกู้คืนได้ ล่าสุดก่อน
+
+func ListTrashedPosts(c *gin.Context) {
+	s, ok := currentSeller(c)
+	if !ok {
+		return
+	}
+	page, size := pageParams(c, 20, 100)
+
+	db := config.DB()
+	q := db.Unscoped().Model(&entity.Post_a_New_Product{}).
+		Where("seller_id = ? AND deleted_at IS NOT NULL AND deleted_at >= ?", s.ID, time.Now().Add(-trash.Retention))
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ดึงถังขยะไม่สำเร็จ"})
+		return
+	}
+
+	unscoped := func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() }
+	var posts []entity.Post_a_New_Product
+	if err := q.Preload("Product", unscoped).
+		Preload("Product.ProductImage", unscoped).
+		Preload("Category").
+		Order("deleted_at DESC, id DESC").
+		Offset((page - 1) * size).Limit(size).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ดึงถังขยะไม่สำเร็จ"})
+		return
+	}
+
+	rows := make([]trashedPost, 0, len(posts))
+	for _, p := range posts {
+		// แสดงเฉพาะรูปที่ถูกลบพร้อมโพสต์ (รูปที่ถูกแทนที่ไปก่อนหน้านี้จะไม่กลับมาตอนกู้คืน)
+		imgs := p.Product.ProductImage[:0]
+		for _, im := range p.Product.ProductImage {
+			if im.DeletedAt.Valid && trash.SameDelete(im.DeletedAt.Time, p.DeletedAt.Time) {
+				imgs = append(imgs, im)
+			}
+		}
+		p.Product.ProductImage = imgs
+		rows = append(rows, trashedPost{Post_a_New_Product: p, PurgeAt: trash.PurgeAt(p.DeletedAt.Time)})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rows, "page": page, "page_size": size, "total": total,
+		"retention_days": int(trash.Retention / (24 * time.Hour))})
+}
+
+// ---------- POST /api/seller/posts/:id/restore ----------
+// กู้คืนโพสต์ + สินค้า + รูปที่ถูกลบไปพร้อมกัน (สถานะขายกลับเป็นเหมือนก่อนลบ)
+
+func RestorePost(c *gin.Context) {
+	s, ok := currentSeller(c)
+	if !ok {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "post id ไม่ถูกต้อง"})
+		return
+	}
+
+	db := config.DB()
+	var post entity.Post_a_New_Product
+	if err := db.Unscoped().
+		Where("id = ? AND seller_id = ? AND deleted_at IS NOT NULL", id, s.ID).
+		First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": trash.ErrNotFound.Error()})
+		return
+	}
+	deletedAt := post.DeletedAt.Time
+
+	if err := trash.Restore(db, &post, time.Now()); err != nil {
+		switch {
+		case errors.Is(err, trash.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, trash.ErrExpired):
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "กู้คืนโพสต์ไม่สำเร็จ"})
+		}
+		return
+	}
+
+	_ = preloadVariants(db).Preload("Product.ProductImage").
+		Preload("Category").
+		First(&post, post.ID).Error
+
+	recordAudit(c, auditEvent{Action: "post.restore", TargetType: "post", TargetID: post.ID,
+		Before: gin.H{"deleted_at": deletedAt}, After: gin.H{"deleted_at": nil}})
+	c.JSON(http.StatusOK, gin.H{"message": "กู้คืนโพสต์สำเร็จ", "data": post})
+}
@@ -0,0 +1,354 @@
+// controller/twofactor.go
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"example.com/GROUB/totp"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+func totpIssuer() string {
+	if v := os.Getenv("APP_NAME"); v != "" {
+		return v
+	}
+	return "GROUB"
+}
+
+// รหัสสำรองเทียบแบบไม่สนตัวพิมพ์/ขีด/ช่องว่าง
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// สุ่มรหัสสำรองชุดใหม่ (ลบชุดเก่าทิ้ง) คืนตัวจริงให้ผู้ใช้เก็บ — แสดงได้ครั้งเดียว
+func regenerateRecoveryCodes(tx *gorm.DB, memberID uint) ([]string, error) {
+	if err := tx.Where("member_id = ?", memberID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]entity.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		rows = append(rows, entity.RecoveryCode{MemberID: memberID, CodeHash: hashToken(raw)})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ตรวจปัจจัยที่ 2: รหัส 6 หลักจากแอป หรือรหัสสำรอง (ใช้แล้วทิ้ง)
+func verifySecondFactor(tx *gorm.DB, m *entity.Member, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if code == "" || m.TOTPSecret == "" {
+		return false, nil
+	}
+
+	if _, err := strconv.Atoi(code); err == nil && len(code) == totp.Digits {
+		step, ok := totp.Validate(m.TOTPSecret, code, time.Now(), m.TOTPLastStep)
+		if !ok {
+			return false, nil
+		}
+		// จอง step นี้แบบมีเงื่อนไข กันรหัสเดียวกันถูกใช้ซ้ำพร้อมกัน
+		res := tx.Model(&entity.Member{}).
+			Where("id = ? AND totp_last_step < ?", m.ID, step).
+			Update("totp_last_step", step)
+		if res.Error != nil {
+			return false, res.Error
+		}
+		m.TOTPLastStep = step
+		return res.RowsAffected == 1, nil
+	}
+
+	res := tx.Model(&entity.RecoveryCode{}).
+		Where("member_id = ? AND code_hash = ? AND used_at IS NULL", m.ID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func currentMember(c *gin.Context) (*entity.Member, bool) {
+	mid, ok := c.Get("member_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return nil, false
+	}
+	var m entity.Member
+	if err := config.DB().First(&m, mid.(uint)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return nil, false
+	}
+	return &m, true
+}
+
+// ---------- POST /api/login/2fa ----------
+
+type LoginTwoFactorReq struct {
+	Challenge string `json:"challenge" binding:"required"`
+	Code      string `json:"code"      binding:"required"` // รหัส 6 หลัก หรือรหัสสำรอง
+}
+
+func LoginTwoFactor(c *gin.Context) {
+	var req LoginTwoFactorReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+
+	// ดู challenge ก่อน (ยังไม่ใช้) เพื่อให้พิมพ์รหัสผิดแล้วลองใหม่ได้ภายในอายุ challenge
+	var t entity.AuthToken
+	if err := db.Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?",
+		hashToken(req.Challenge), entity.TokenMFAChallenge, time.Now()).
+		First(&t).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "challenge invalid or expired, please log in again"})
+		return
+	}
+
+	var m entity.Member
+	if err := db.Preload("People").
+		Preload("Seller").
+		Preload("Seller.ShopProfile").
+		First(&m, t.MemberID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "user not found"})
+		return
+	}
+
+	// ใช้ตัวล็อกเดียวกับ Login กันเดารหัส 6 หลัก
+	keys := loginKeys(m.UserName, c.ClientIP())
+	if wait := loginLimiter.retryAfter(keys...); wait > 0 {
+		secs := int(wait.Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(secs))
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": "too many failed attempts, try again later", "retry_after": secs})
+		return
+	}
+
+	ok, err := verifySecondFactor(db, &m, req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+		return
+	}
+	if !ok {
+		loginLimiter.fail(keys...)
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid 2FA code"})
+		recordAudit(c, auditEvent{Action: "auth.login_2fa", Status: entity.AuditFailure, Detail: "invalid code",
+			TargetType: "member", TargetID: m.ID, Actor: &m})
+		return
+	}
+	if _, err := consumeAuthToken(db, req.Challenge, entity.TokenMFAChallenge); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "challenge invalid or expired, please log in again"})
+		return
+	}
+	loginLimiter.reset(keys[0])
+	recordAudit(c, auditEvent{Action: "auth.login_2fa", TargetType: "member", TargetID: m.ID, Actor: &m})
+
+	respondLogin(c, m)
+}
+
+// ---------- POST /api/2fa/setup ----------
+
+func SetupTwoFactor(c *gin.Context) {
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if m.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"message": "2FA already enabled"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "cannot generate secret"})
+		return
+	}
+	// เก็บไว้ก่อนแต่ยังไม่เปิดใช้ จนกว่าจะ confirm ด้วยรหัสจากแอป
+	if err := config.DB().Model(m).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": totp.URI(totpIssuer(), m.UserName, secret),
+	})
+}
+
+// ---------- POST /api/2fa/confirm ----------
+
+type TwoFactorCodeReq struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func ConfirmTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if m.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"message": "2FA already enabled"})
+		return
+	}
+	if m.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "call /api/2fa/setup first"})
+		return
+	}
+
+	var codes []string
+	if err := config.DB().Transaction(func(tx *gorm.DB) error {
+		step, valid := totp.Validate(m.TOTPSecret, req.Code, time.Now(), m.TOTPLastStep)
+		if !valid {
+			return errTokenInvalid
+		}
+		if err := tx.Model(m).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = regenerateRecoveryCodes(tx, m.ID)
+		return err
+	}); err != nil {
+		if errors.Is(err, errTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid 2FA code"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "enable 2FA failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "2FA enabled",
+		"recovery_codes": codes, // แสดงครั้งเดียว ให้ผู้ใช้เก็บไว้
+	})
+}
+
+// ---------- POST /api/2fa/disable ----------
+
+type DisableTwoFactorReq struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"     binding:"required"`
+}
+
+func DisableTwoFactor(c *gin.Context) {
+	var req DisableTwoFactorReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !m.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "2FA is not enabled"})
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(m.Password), []byte(req.Password)) != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "password invalid"})
+		return
+	}
+
+	if err := config.DB().Transaction(func(tx *gorm.DB) error {
+		valid, err := verifySecondFactor(tx, m, req.Code)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return errTokenInvalid
+		}
+		if err := tx.Model(m).Updates(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("member_id = ?", m.ID).Delete(&entity.RecoveryCode{}).Error
+	}); err != nil {
+		if errors.Is(err, errTokenInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid 2FA code"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "disable 2FA failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "2FA disabled"})
+}
+
+// ---------- POST /api/2fa/recovery-codes ----------
+
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req TwoFactorCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid body", "error": err.Error()})
+		return
+	}
+	m, ok := currentMember(c)
+	if !ok {
+		return
+	}
+	if !m.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "2FA is not enabled"})
+		return
+	}
+
+	var codes []string
+	if err := config.DB().Transaction(func(tx *gorm.DB) error {
+		valid, err := verifySecondFactor(tx, m, req.Code)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return errTokenInvalid
+		}
+		codes, err = regenerateRecoveryCodes(tx, m.ID)
+		return err
+	}); err != nil {
+		if errors.Is(err, errTokenInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid 2FA code"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "regenerate recovery codes failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
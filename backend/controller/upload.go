@@ -3,10 +3,7 @@ package controller
 
 
 import (
-	"fmt"
-	"path/filepath"
-	"time"
-
+	"example.com/GROUB/imaging"
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,16 +15,18 @@ func UploadLogo(c *gin.Context) {
 		return
 	}
 
-	// ✅ สร้างชื่อใหม่
-	filename := fmt.Sprintf("%d%s", time.Now().UnixNano(), filepath.Ext(file.Filename))
-	dst := "uploads/logo/" + filename
-
-	if err := c.SaveUploadedFile(file, dst); err != nil {
+	// ✅ ตรวจชนิดไฟล์จริง + ล้าง EXIF + ทำรูปย่อ
+	img, err := saveImage(c.Request.Context(), file, "logo", "")
+	if err != nil {
+		if imaging.IsInvalid(err) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"error": "อัปโหลดโลโก้ล้มเหลว"})
 		return
 	}
 
-	c.JSON(200, gin.H{"url": "/" + dst})
+	c.JSON(200, gin.H{"url": img.URL, "sizes": img.Sizes})
 }
 
 // POST /api/upload-product-images
@@ -39,24 +38,20 @@ func UploadProductImages(c *gin.Context) {
 	}
 
 	files := form.File["files"] // 👈 ต้องตรงกับ key ด้านหน้า
-	var urls []string
-
-	for _, file := range files {
-		// ✅ เปลี่ยนชื่อไฟล์
-		filename := fmt.Sprintf("%d%s", time.Now().UnixNano(), filepath.Ext(file.Filename))
-		dst := "uploads/products/" + filename
-
-		if err := c.SaveUploadedFile(file, dst); err != nil {
-			continue // ข้ามไฟล์ที่ error
-		}
-
-		urls = append(urls, "/"+dst)
+	if len(files) == 0 {
+		c.JSON(400, gin.H{"error": "ไม่พบไฟล์รูปภาพ"})
+		return
 	}
 
-	if len(urls) == 0 {
-		c.JSON(500, gin.H{"error": "ไม่สามารถอัปโหลดรูปภาพได้เลย"})
+	// ไฟล์ใดไม่ผ่าน = ไม่รับทั้งชุด (ไฟล์ที่บันทึกไปแล้วถูกลบคืน)
+	imgs, ok := saveImageFiles(c, files, "products")
+	if !ok {
 		return
 	}
 
-	c.JSON(200, gin.H{"urls": urls})
-}
\ No newline at end of file
+	urls := make([]string, 0, len(imgs))
+	for _, img := range imgs {
+		urls = append(urls, img.URL)
+	}
+	c.JSON(200, gin.H{"urls": urls, "images": imgs})
+}
@@ -0,0 +1,55 @@
+// controller/uploadgc.go
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/storage"
+	"example.com/GROUB/uploadgc"
+	"github.com/gin-gonic/gin"
+)
+
+// ---------- POST /api/admin/uploads/gc?dry_run=true&grace_hours=24&purge_after_hours=168 ----------
+// เก็บกวาดไฟล์อัปโหลดที่ไม่มีใครอ้างทันที (ปกติรันเองเป็นรอบอยู่แล้ว)
+func RunUploadGC(c *gin.Context) {
+	opt := uploadgc.DefaultOptions()
+	opt.DryRun, _ = strconv.ParseBool(c.Query("dry_run"))
+	for _, p := range []struct {
+		name string
+		dst  *time.Duration
+	}{{"grace_hours", &opt.Grace}, {"purge_after_hours", &opt.PurgeAfter}} {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		// ต่ำสุด 1 ชม. กันไฟล์ของฟอร์มที่กำลังกรอกอยู่โดนย้าย
+		h, err := strconv.Atoi(v)
+		if err != nil || h < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": p.name + " ต้องเป็นจำนวนชั่วโมงตั้งแต่ 1 ขึ้นไป"})
+			return
+		}
+		*p.dst = time.Duration(h) * time.Hour
+	}
+
+	rep, err := uploadgc.Sweep(c.Request.Context(), config.DB(), storage.Default(), opt)
+	if err != nil {
+		if errors.Is(err, uploadgc.ErrRunning) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "เก็บกวาดไฟล์ไม่สำเร็จ", "detail": err.Error()})
+		return
+	}
+
+	if !rep.DryRun {
+		recordAudit(c, auditEvent{Action: "uploads.gc", Detail: fmt.Sprintf(
+			"quarantined %d (%d bytes), restored %d, purged %d, exports deleted %d (reclaimed %d bytes)",
+			rep.Quarantined, rep.QuarantinedBytes, rep.Restored, rep.Purged, rep.ExportsDeleted, rep.ReclaimedBytes)})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rep})
+}
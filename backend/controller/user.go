@@ -27,6 +27,15 @@ func CurrentUser(c *gin.Context) {
 		return
 	}
 
+	payload := userPayload(m)
+	c.JSON(http.StatusOK, gin.H{
+		"user":     payload,
+		"has_shop": payload["hasShop"],
+	})
+}
+
+// payload ผู้ใช้แบบเดียวกับที่ Login/CurrentUser ตอบ (m ต้อง preload People, Seller, Seller.ShopProfile)
+func userPayload(m entity.Member) gin.H {
 	var sellerID *uint
 	hasShop := false
 	if m.Seller.ID != 0 {
@@ -41,24 +50,24 @@ func CurrentUser(c *gin.Context) {
 		birthday = m.People.BirthDay.Format("2006-01-02")
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user": gin.H{
-			"id":       m.ID,
-			"username": m.UserName,
-			"people": gin.H{
-				"id":        m.People.ID,
-				"firstName": m.People.FirstName,
-				"lastName":  m.People.LastName,
-				"email":     m.People.Email,
-				"age":       m.People.Age,
-				"phone":     m.People.Phone,
-				"birthday":  birthday,
-				"address":   m.People.Address,
-				"genderID":  m.People.GenderID,
-			},
-			"sellerID": sellerID,
-			"hasShop":  hasShop,
+	return gin.H{
+		"id":       m.ID,
+		"username": m.UserName,
+		"role":     m.Role,
+		"people": gin.H{
+			"id":            m.People.ID,
+			"firstName":     m.People.FirstName,
+			"lastName":      m.People.LastName,
+			"email":         m.People.Email,
+			"emailVerified": m.People.EmailVerifiedAt != nil,
+			"age":           m.People.Age,
+			"phone":         m.People.Phone,
+			"birthday":      birthday,
+			"address":       m.People.Address,
+			"genderID":      m.People.GenderID,
 		},
-		"has_shop": hasShop,
-	})
+		"sellerID":    sellerID, // null ถ้ายังไม่เป็นผู้ขาย
+		"hasShop":     hasShop,  // true เมื่อมี ShopProfile
+		"totpEnabled": m.TOTPEnabled,
+	}
 }
@@ -0,0 +1,368 @@
+// controller/variant.go
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"example.com/GROUB/entity"
+	"example.com/GROUB/inventory"
+	"gorm.io/gorm"
+)
+
+const (
+	maxProductOptions = 3
+	maxOptionValues   = 50
+	maxVariants       = 100
+)
+
+var errSKUTaken = errors.New("รหัส SKU นี้ถูกใช้กับสินค้าอื่นของร้านแล้ว")
+
+// optionInput = ตัวเลือกที่ส่งมาตอนสร้าง/แก้สินค้า เช่น {"name":"ไซซ์","values":["S","M","L"]}
+type optionInput struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// variantInput = SKU 1 แบบ; options ระบุค่าของทุกตัวเลือก เช่น {"ไซซ์":"M","สี":"แดง"}
+// id = variant เดิม (ตอนแก้ไข) ไม่ส่ง = สร้างใหม่
+type variantInput struct {
+	ID       uint              `json:"id"`
+	SKU      string            `json:"sku"`
+	Price    int               `json:"price"`
+	Quantity int               `json:"quantity"`
+	Image    string            `json:"image"`
+	Options  map[string]string `json:"options"`
+}
+
+func normKey(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
+
+// validateVariants ตรวจรูปแบบ (ไม่แตะ DB) และ trim ค่าใน slice ที่ส่งมา
+func validateVariants(opts []optionInput, vars []variantInput) error {
+	if len(opts) > maxProductOptions {
+		return fmt.Errorf("ตัวเลือกได้ไม่เกิน %d ประเภท", maxProductOptions)
+	}
+	if len(vars) > maxVariants {
+		return fmt.Errorf("variant ได้ไม่เกิน %d แบบ", maxVariants)
+	}
+	if len(opts) > 0 && len(vars) == 0 {
+		return errors.New("มีตัวเลือกแล้วต้องมี variant อย่างน้อย 1 แบบ")
+	}
+	if len(opts) == 0 && len(vars) > 1 {
+		return errors.New("มีหลาย variant ต้องกำหนดตัวเลือก (options)")
+	}
+
+	// option name -> set ของ value ที่อนุญาต
+	allowed := make(map[string]map[string]bool, len(opts))
+	for i := range opts {
+		o := &opts[i]
+		o.Name = strings.TrimSpace(o.Name)
+		if o.Name == "" || len([]rune(o.Name)) > 50 {
+			return errors.New("ชื่อตัวเลือกต้องไม่ว่างและยาวไม่เกิน 50 ตัวอักษร")
+		}
+		if _, dup := allowed[normKey(o.Name)]; dup {
+			return fmt.Errorf("ตัวเลือก %q ซ้ำ", o.Name)
+		}
+		if len(o.Values) == 0 || len(o.Values) > maxOptionValues {
+			return fmt.Errorf("ตัวเลือก %q ต้องมีค่า 1-%d ค่า", o.Name, maxOptionValues)
+		}
+		set := make(map[string]bool, len(o.Values))
+		for j, v := range o.Values {
+			v = strings.TrimSpace(v)
+			if v == "" || len([]rune(v)) > 50 {
+				return fmt.Errorf("ค่าของตัวเลือก %q ต้องไม่ว่างและยาวไม่เกิน 50 ตัวอักษร", o.Name)
+			}
+			if set[normKey(v)] {
+				return fmt.Errorf("ค่า %q ของตัวเลือก %q ซ้ำ", v, o.Name)
+			}
+			set[normKey(v)] = true
+			o.Values[j] = v
+		}
+		allowed[normKey(o.Name)] = set
+	}
+
+	combos := make(map[string]bool, len(vars))
+	skus := make(map[string]bool, len(vars))
+	ids := make(map[uint]bool, len(vars))
+	for i := range vars {
+		v := &vars[i]
+		v.SKU = strings.TrimSpace(v.SKU)
+		v.Image = strings.TrimSpace(v.Image)
+		if v.Price < 0 || v.Quantity < 0 {
+			return errors.New("ราคาและจำนวนของ variant ต้องไม่ติดลบ")
+		}
+		if len(v.SKU) > 64 {
+			return errors.New("SKU ยาวไม่เกิน 64 ตัวอักษร")
+		}
+		if v.SKU != "" {
+			if skus[normKey(v.SKU)] {
+				return fmt.Errorf("SKU %q ซ้ำ", v.SKU)
+			}
+			skus[normKey(v.SKU)] = true
+		}
+		if v.ID != 0 {
+			if ids[v.ID] {
+				return fmt.Errorf("variant id %d ซ้ำ", v.ID)
+			}
+			ids[v.ID] = true
+		}
+
+		if len(v.Options) != len(opts) {
+			return errors.New("variant ต้องระบุค่าให้ครบทุกตัวเลือก")
+		}
+		parts := make([]string, 0, len(opts))
+		for _, o := range opts {
+			val, ok := lookupOption(v.Options, o.Name)
+			if !ok || !allowed[normKey(o.Name)][normKey(val)] {
+				return fmt.Errorf("variant ต้องเลือกค่าของ %q จาก %v", o.Name, o.Values)
+			}
+			parts = append(parts, normKey(val))
+		}
+		key := strings.Join(parts, "\x00")
+		if combos[key] {
+			return errors.New("มี variant ที่เลือกค่าตัวเลือกซ้ำกัน")
+		}
+		combos[key] = true
+	}
+	return nil
+}
+
+// หา value ของตัวเลือกจาก map แบบไม่สนตัวพิมพ์
+func lookupOption(m map[string]string, name string) (string, bool) {
+	for k, v := range m {
+		if normKey(k) == normKey(name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// checkVariantRefs ตรวจกับ DB ก่อนบันทึก: id ต้องเป็นของสินค้านี้, SKU ไม่ซ้ำกับสินค้าอื่นของร้าน
+// และไม่ลบ variant ที่มีคนจองอยู่
+// productID = 0 ตอนสร้างสินค้าใหม่
+func checkVariantRefs(db *gorm.DB, productID, sellerID uint, vars []variantInput) error {
+	var skus []string
+	var wantIDs []uint
+	for _, v := range vars {
+		if v.SKU != "" {
+			skus = append(skus, normKey(v.SKU))
+		}
+		if v.ID != 0 {
+			wantIDs = append(wantIDs, v.ID)
+		}
+	}
+
+	// variant ที่จะถูกลบ / สินค้าที่จะเปลี่ยนไปใช้ variant ต้องไม่มีของถูกจองค้างอยู่
+	if productID != 0 {
+		var reserved int64
+		q := db.Model(&entity.ProductVariant{}).Where("product_id = ? AND reserved > 0", productID)
+		if len(wantIDs) > 0 {
+			q = q.Where("id NOT IN ?", wantIDs)
+		}
+		if err := q.Count(&reserved).Error; err != nil {
+			return err
+		}
+		var p entity.Product
+		if err := db.Select("id", "reserved").First(&p, productID).Error; err != nil {
+			return err
+		}
+		if reserved > 0 || (p.Reserved > 0 && len(vars) > 0) {
+			return inventory.ErrBelowReserved
+		}
+	}
+
+	if len(wantIDs) > 0 {
+		var n int64
+		if err := db.Model(&entity.ProductVariant{}).
+			Where("id IN ? AND product_id = ?", wantIDs, productID).
+			Count(&n).Error; err != nil {
+			return err
+		}
+		if int(n) != len(wantIDs) {
+			return errors.New("มี variant id ที่ไม่ใช่ของสินค้านี้")
+		}
+	}
+
+	if len(skus) > 0 {
+		var n int64
+		if err := db.Table("product_variants AS v").
+			Joins("JOIN products p ON p.id = v.product_id AND p.deleted_at IS NULL").
+			Where("v.deleted_at IS NULL AND p.seller_id = ? AND v.product_id <> ? AND LOWER(v.sku) IN ?", sellerID, productID, skus).
+			Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return errSKUTaken
+		}
+	}
+	return nil
+}
+
+// saveVariants เขียนตัวเลือก/variant ทั้งชุดของสินค้า (เรียกใน transaction หลัง validate แล้ว)
+// ตัวเลือกถูกสร้างใหม่ทั้งหมด ส่วน variant ที่มี id เดิมจะถูกแก้ไขในแถวเดิม (id ไม่เปลี่ยน)
+// variant เดิมที่ไม่ได้ส่งมาจะถูก soft delete; จำนวนสต็อกเปลี่ยนผ่าน inventory (ลงสมุดบัญชี)
+func saveVariants(tx *gorm.DB, productID uint, opts []optionInput, vars []variantInput, actorID *uint) error {
+	var existing []entity.ProductVariant
+	if err := tx.Select("id").Where("product_id = ?", productID).Find(&existing).Error; err != nil {
+		return err
+	}
+	// เปลี่ยนจากสินค้าธรรมดาเป็นมี variant: ปิดยอดระดับสินค้าก่อน แล้วสต็อกไปอยู่ที่ variant
+	if len(existing) == 0 && len(vars) > 0 {
+		if _, err := inventory.SetQuantity(tx, inventory.Line{ProductID: productID}, actorID, "moved to variants"); err != nil {
+			return err
+		}
+	}
+
+	// 1) ล้างตัวเลือกเดิม + ความสัมพันธ์ variant <-> ค่า
+	if err := tx.Exec(`DELETE FROM product_variant_values WHERE product_variant_id IN
+		(SELECT id FROM product_variants WHERE product_id = ?)`, productID).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().
+		Where("option_id IN (?)", tx.Model(&entity.ProductOption{}).Unscoped().Select("id").Where("product_id = ?", productID)).
+		Delete(&entity.ProductOptionValue{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("product_id = ?", productID).Delete(&entity.ProductOption{}).Error; err != nil {
+		return err
+	}
+
+	// 2) สร้างตัวเลือกใหม่ แล้วจำ id ของแต่ละค่า
+	valueIDs := map[string]map[string]uint{}
+	for i, o := range opts {
+		opt := entity.ProductOption{ProductID: productID, Name: o.Name, Position: i}
+		for j, v := range o.Values {
+			opt.Values = append(opt.Values, entity.ProductOptionValue{Value: v, Position: j})
+		}
+		if err := tx.Create(&opt).Error; err != nil {
+			return err
+		}
+		m := make(map[string]uint, len(opt.Values))
+		for _, v := range opt.Values {
+			m[normKey(v.Value)] = v.ID
+		}
+		valueIDs[normKey(o.Name)] = m
+	}
+
+	// 3) variant: แก้แถวเดิม / สร้างใหม่ แล้วผูกกับค่าตัวเลือก
+	keep := make([]uint, 0, len(vars))
+	for i, in := range vars {
+		v := entity.ProductVariant{
+			ProductID: productID,
+			SKU:       in.SKU,
+			Price:     in.Price,
+			ImagePath: in.Image,
+			Position:  i,
+		}
+		if in.ID != 0 {
+			v.ID = in.ID
+			if err := tx.Model(&entity.ProductVariant{}).Where("id = ?", in.ID).Updates(map[string]interface{}{
+				"sku": v.SKU, "price": v.Price, "image_path": v.ImagePath, "position": v.Position,
+			}).Error; err != nil {
+				return err
+			}
+			if _, err := inventory.SetQuantity(tx, inventory.Line{ProductID: productID, VariantID: &v.ID, Quantity: in.Quantity}, actorID, ""); err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Omit("OptionValues").Create(&v).Error; err != nil {
+				return err
+			}
+			if in.Quantity > 0 {
+				if _, err := inventory.Restock(tx, inventory.Line{ProductID: productID, VariantID: &v.ID, Quantity: in.Quantity}, actorID, "initial stock"); err != nil {
+					return err
+				}
+			}
+		}
+		keep = append(keep, v.ID)
+
+		for _, o := range opts {
+			val, _ := lookupOption(in.Options, o.Name)
+			if err := tx.Exec("INSERT INTO product_variant_values (product_variant_id, product_option_value_id) VALUES (?, ?)",
+				v.ID, valueIDs[normKey(o.Name)][normKey(val)]).Error; err != nil {
+				return err
+			}
+		}
+	}
+	for _, old := range existing {
+		if containsUint(keep, old.ID) {
+			continue
+		}
+		id := old.ID
+		if _, err := inventory.SetQuantity(tx, inventory.Line{ProductID: productID, VariantID: &id}, actorID, "variant removed"); err != nil {
+			return err
+		}
+		if err := tx.Delete(&entity.ProductVariant{}, id).Error; err != nil {
+			return err
+		}
+	}
+
+	return syncVariantTotals(tx, productID)
+}
+
+func containsUint(xs []uint, x uint) bool {
+	for _, v := range xs {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+// syncVariantTotals อัปเดตราคา/สต็อกระดับสินค้าจาก variant
+// มี variant: price = ต่ำสุด, price_max = สูงสุด, quantity/reserved = ผลรวม / ไม่มี: price_max = price
+func syncVariantTotals(tx *gorm.DB, productID uint) error {
+	var agg struct {
+		N        int64
+		MinPrice int
+		MaxPrice int
+		Qty      int
+		Reserved int
+	}
+	if err := tx.Model(&entity.ProductVariant{}).
+		Select("COUNT(*) AS n, COALESCE(MIN(price), 0) AS min_price, COALESCE(MAX(price), 0) AS max_price, "+
+			"COALESCE(SUM(quantity), 0) AS qty, COALESCE(SUM(reserved), 0) AS reserved").
+		Where("product_id = ?", productID).
+		Scan(&agg).Error; err != nil {
+		return err
+	}
+	if agg.N == 0 {
+		return tx.Model(&entity.Product{}).Where("id = ?", productID).
+			Update("price_max", gorm.Expr("price")).Error
+	}
+	return tx.Model(&entity.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"price":     agg.MinPrice,
+		"price_max": agg.MaxPrice,
+		"quantity":  agg.Qty,
+		"reserved":  agg.Reserved,
+	}).Error
+}
+
+// ตัวเลือก/variant ของสินค้า เป็น input ชุดเดิม (ใช้ตอนแก้แค่ variant โดยไม่ส่ง options มา)
+func loadOptionInputs(db *gorm.DB, productID uint) ([]optionInput, error) {
+	var opts []entity.ProductOption
+	if err := db.Where("product_id = ?", productID).
+		Preload("Values", func(tx *gorm.DB) *gorm.DB { return tx.Order("position, id") }).
+		Order("position, id").
+		Find(&opts).Error; err != nil {
+		return nil, err
+	}
+	out := make([]optionInput, len(opts))
+	for i, o := range opts {
+		out[i].Name = o.Name
+		for _, v := range o.Values {
+			out[i].Values = append(out[i].Values, v.Value)
+		}
+	}
+	return out, nil
+}
+
+// preload ตัวเลือก/variant ของ Post_a_New_Product ตามลำดับที่ผู้ขายกำหนด
+func preloadVariants(db *gorm.DB) *gorm.DB {
+	byPos := func(tx *gorm.DB) *gorm.DB { return tx.Order("position, id") }
+	return db.Preload("Product.Options", byPos).
+		Preload("Product.Options.Values", byPos).
+		Preload("Product.Variants", byPos).
+		Preload("Product.Variants.OptionValues")
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ผลของการกระทำที่บันทึก
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+var ErrAuditAppendOnly = errors.New("audit events are append-only")
+
+// AuditEvent = บันทึกว่าใครทำอะไรกับอะไร จากที่ไหน (เขียนเพิ่มได้อย่างเดียว ห้ามแก้/ลบ)
+// ไม่ใช้ gorm.Model เพราะไม่มี UpdatedAt / soft delete
+type AuditEvent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	// ผู้กระทำ (ว่าง = ไม่ระบุตัวตน เช่น ล็อกอินด้วย username ที่ไม่มีอยู่จริง)
+	ActorID       *uint  `gorm:"index" json:"actor_id"`
+	ActorUsername string `gorm:"size:100" json:"actor_username"`
+	ActorRole     string `gorm:"size:20" json:"actor_role"`
+	APIKeyID      *uint  `json:"api_key_id"` // ทำผ่าน API key ของผู้ขาย
+
+	Action string `gorm:"size:64;index;not null" json:"action"` // เช่น auth.login, category.delete
+	Status string `gorm:"size:10;index;not null" json:"status"`
+	Detail string `gorm:"size:255" json:"detail"`
+
+	TargetType string `gorm:"size:64;index:idx_audit_target" json:"target_type"`
+	TargetID   string `gorm:"size:64;index:idx_audit_target" json:"target_id"`
+	// {"field": {"from": .., "to": ..}}
+	Changes json.RawMessage `gorm:"type:text" json:"changes"`
+
+	IP        string `gorm:"size:64;index" json:"ip"`
+	UserAgent string `gorm:"size:255" json:"user_agent"`
+}
+
+func (*AuditEvent) BeforeUpdate(*gorm.DB) error { return ErrAuditAppendOnly }
+func (*AuditEvent) BeforeDelete(*gorm.DB) error { return ErrAuditAppendOnly }
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// จุดประสงค์ของ token ใช้ครั้งเดียว
+const (
+	TokenPasswordReset = "password_reset"
+	TokenEmailVerify   = "email_verify"
+	TokenMFAChallenge  = "mfa_challenge" // ผ่านรหัสผ่านแล้ว รอรหัส 2FA
+)
+
+// AuthToken = token ใช้ครั้งเดียวมีวันหมดอายุ (เก็บเฉพาะ hash)
+type AuthToken struct {
+	gorm.Model
+	MemberID uint   `gorm:"index;not null" json:"member_id"`
+	Member   Member `gorm:"foreignKey:MemberID" json:"-"`
+
+	Purpose   string `gorm:"size:32;index;not null" json:"purpose"`
+	TokenHash string `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	// อีเมลที่ส่ง token ไป (verify ต้องตรงกับอีเมลปัจจุบันตอนยืนยัน)
+	Email string `gorm:"size:255" json:"email"`
+
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
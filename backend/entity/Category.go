@@ -1,11 +1,47 @@
 // entity/category.go
 package entity
 
-import "gorm.io/gorm"
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
 
 type Category struct {
 	gorm.Model
 	Name string `gorm:"type:varchar(100);not null" json:"name"`
 
+	// ต้นไม้หมวดหมู่: ParentID = nil คือหมวดบนสุด
+	// Path เก็บ id ของบรรพบุรุษจนถึงตัวเอง เช่น "/1/5/12/" ใช้หาลูกหลานด้วย LIKE '/1/5/%'
+	ParentID *uint  `gorm:"index" json:"parent_id"`
+	Path     string `gorm:"type:varchar(255);index" json:"path"`
+	Depth    int    `gorm:"not null;default:0" json:"depth"`
+
 	Post_a_New_Product []Post_a_New_Product `gorm:"foreignKey:Category_ID"`
 }
+
+// CategoryPath คืน path ของหมวดที่อยู่ใต้ parentPath (parentPath ว่าง = หมวดบนสุด)
+func CategoryPath(parentPath string, id uint) string {
+	if parentPath == "" {
+		parentPath = "/"
+	}
+	return fmt.Sprintf("%s%d/", parentPath, id)
+}
+
+// AfterCreate เติม Path/Depth หลังได้ id (ทั้งตอน seed และตอนสร้างผ่าน API)
+func (c *Category) AfterCreate(tx *gorm.DB) error {
+	parentPath := ""
+	depth := 0
+	if c.ParentID != nil {
+		var parent Category
+		if err := tx.Select("id", "path", "depth").First(&parent, *c.ParentID).Error; err != nil {
+			return err
+		}
+		parentPath = parent.Path
+		depth = parent.Depth + 1
+	}
+	c.Path = CategoryPath(parentPath, c.ID)
+	c.Depth = depth
+	return tx.Model(&Category{}).Where("id = ?", c.ID).
+		UpdateColumns(map[string]interface{}{"path": c.Path, "depth": c.Depth}).Error
+}
@@ -0,0 +1,51 @@
+// entity/category_attribute.go
+package entity
+
+import "gorm.io/gorm"
+
+// ชนิดของข้อมูลจำเพาะสินค้า
+const (
+	AttrEnum   = "enum"    // เลือกจาก Options เช่น ยี่ห้อ, สี
+	AttrNumber = "number"  // ตัวเลข + หน่วย เช่น ความจุ 128 GB
+	AttrText   = "text"    // ข้อความอิสระ (filter ไม่ได้)
+	AttrBool   = "boolean" // มี/ไม่มี เช่น รองรับ 5G
+)
+
+// CategoryAttribute = ช่องข้อมูลจำเพาะของหมวด ใช้กับสินค้าในหมวดนี้และหมวดลูกหลานทั้งหมด
+// Key ไม่ซ้ำกันตลอดสายบรรพบุรุษ/ลูกหลาน (หมวดที่ไม่เกี่ยวกันใช้ key เดียวกันได้ เช่น "brand")
+type CategoryAttribute struct {
+	gorm.Model
+	CategoryID uint     `gorm:"index;not null" json:"category_id"`
+	Key        string   `gorm:"type:varchar(50);index;not null" json:"key"` // ใช้ใน query string เช่น attr[brand]=apple
+	Name       string   `gorm:"type:varchar(100);not null" json:"name"`
+	Type       string   `gorm:"type:varchar(10);not null" json:"type"`
+	Unit       string   `gorm:"type:varchar(20)" json:"unit"` // เฉพาะ number
+	Min        *float64 `json:"min"`                          // เฉพาะ number (null = ไม่จำกัด)
+	Max        *float64 `json:"max"`
+	Required   bool     `json:"required"`
+	Filterable bool     `json:"filterable"` // แสดงเป็น facet + filter ในหน้ารายการสินค้า
+	Position   int      `json:"position"`
+
+	Options []CategoryAttributeOption `gorm:"foreignKey:AttributeID;constraint:OnDelete:CASCADE;" json:"options"`
+}
+
+// CategoryAttributeOption = ค่าที่เลือกได้ของ attribute แบบ enum
+type CategoryAttributeOption struct {
+	gorm.Model
+	AttributeID uint   `gorm:"index;not null" json:"attribute_id"`
+	Value       string `gorm:"type:varchar(50);not null" json:"value"`
+	Position    int    `json:"position"`
+}
+
+// ProductAttributeValue = ค่าข้อมูลจำเพาะ 1 ช่องของสินค้า เก็บตามชนิด (ช่องอื่นว่าง)
+// enum/text อยู่ที่ Value, number อยู่ที่ Number, boolean อยู่ที่ Bool
+type ProductAttributeValue struct {
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	ProductID   uint     `gorm:"uniqueIndex:ux_product_attribute;not null" json:"product_id"`
+	AttributeID uint     `gorm:"uniqueIndex:ux_product_attribute;index;not null" json:"attribute_id"`
+	Value       string   `gorm:"type:varchar(255);index" json:"value,omitempty"`
+	Number      *float64 `gorm:"index" json:"number,omitempty"`
+	Bool        *bool    `json:"bool,omitempty"`
+
+	Attribute CategoryAttribute `gorm:"foreignKey:AttributeID" json:"attribute"`
+}
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// สถานะงาน export ข้อมูลส่วนตัว
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// DataExport = งานสร้างไฟล์ zip ข้อมูลทั้งหมดของสมาชิก (ทำเบื้องหลัง)
+type DataExport struct {
+	gorm.Model
+	MemberID uint   `gorm:"index;not null" json:"member_id"`
+	Member   Member `gorm:"foreignKey:MemberID" json:"-"`
+
+	Status      string     `gorm:"size:20;not null;default:pending" json:"status"`
+	FilePath    string     `json:"-"` // key ใน storage (ว่าง = ยังไม่เสร็จ หรือไฟล์ถูกลบไปแล้ว)
+	SizeBytes   int64      `json:"size_bytes"`
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"` // หลังจากนี้ดาวน์โหลดไม่ได้ ต้องขอใหม่
+}
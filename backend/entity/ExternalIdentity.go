@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExternalIdentity = บัญชีผู้ให้บริการภายนอก (Google ฯลฯ) ที่ผูกกับ member
+type ExternalIdentity struct {
+	gorm.Model
+	MemberID uint   `gorm:"index;not null" json:"member_id"`
+	Member   Member `gorm:"foreignKey:MemberID" json:"-"`
+
+	Provider string `gorm:"size:32;not null;uniqueIndex:idx_ext_provider_subject" json:"provider"`
+	// claim "sub" ของผู้ให้บริการ (ไม่เปลี่ยนตลอดอายุบัญชี ต่างจากอีเมล)
+	Subject string `gorm:"size:255;not null;uniqueIndex:idx_ext_provider_subject" json:"-"`
+	Email   string `gorm:"size:255" json:"email"`
+
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// OAuthState = สถานะระหว่างพาผู้ใช้ไปหน้า login ของผู้ให้บริการ (ใช้ครั้งเดียว)
+type OAuthState struct {
+	gorm.Model
+	StateHash string `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Provider  string `gorm:"size:32;not null" json:"provider"`
+
+	CodeVerifier string `gorm:"size:128;not null" json:"-"` // PKCE
+	Nonce        string `gorm:"size:64;not null" json:"-"`
+
+	// ไม่ว่าง = กำลังผูกบัญชีภายนอกเข้ากับ member ที่ล็อกอินอยู่ ไม่ใช่ล็อกอิน
+	LinkMemberID *uint `gorm:"index" json:"link_member_id"`
+
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
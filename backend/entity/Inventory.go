@@ -0,0 +1,57 @@
+package entity
+
+import "time"
+
+// ประเภทการเคลื่อนไหวของสต็อก
+const (
+	StockRestock = "restock" // รับของเข้า
+	StockSale    = "sale"    // ขายออก (ตัดจากการจอง)
+	StockReserve = "reserve" // จองไว้ระหว่าง checkout
+	StockRelease = "release" // ปล่อยการจอง (ยกเลิก / หมดเวลา)
+	StockAdjust  = "adjust"  // ผู้ขายแก้จำนวนเอง
+	StockReturn  = "return"  // ลูกค้าคืนของ
+)
+
+// สถานะการจอง
+const (
+	ReservationActive    = "active"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+// StockMovement = สมุดบัญชีสต็อก เพิ่มได้อย่างเดียว ทุกการเปลี่ยนแปลงต้องมี 1 แถว
+// Delta = จำนวนในคลังที่เปลี่ยน, ReservedDelta = จำนวนที่ถูกจองที่เปลี่ยน
+// QuantityAfter / ReservedAfter = ยอดหลังรายการนี้ (ของ variant ถ้ามี ไม่งั้นของสินค้า)
+type StockMovement struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ProductID     uint   `gorm:"index;not null" json:"product_id"`
+	VariantID     *uint  `gorm:"index" json:"variant_id"`
+	Type          string `gorm:"type:varchar(16);index;not null" json:"type"`
+	Delta         int    `json:"delta"`
+	ReservedDelta int    `json:"reserved_delta"`
+	QuantityAfter int    `json:"quantity_after"`
+	ReservedAfter int    `json:"reserved_after"`
+
+	ReservationID *uint  `gorm:"index" json:"reservation_id,omitempty"`
+	ActorID       *uint  `json:"actor_id,omitempty"`
+	Note          string `gorm:"type:varchar(255)" json:"note,omitempty"`
+}
+
+// StockReservation = การจองสินค้าระหว่าง checkout หมดเวลาแล้วถูกปล่อยอัตโนมัติ
+// รายการที่จองพร้อมกันใช้ Ref เดียวกัน (ยืนยัน/ยกเลิกทั้งชุด)
+type StockReservation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Ref       string    `gorm:"type:varchar(32);index;not null" json:"ref"`
+	MemberID  uint      `gorm:"index;not null" json:"member_id"`
+	ProductID uint      `gorm:"index;not null" json:"product_id"`
+	VariantID *uint     `json:"variant_id"`
+	Quantity  int       `json:"quantity"`
+	Status    string    `gorm:"type:varchar(16);index;not null" json:"status"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+}
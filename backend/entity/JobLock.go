@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// JobLock = lease ของงานเบื้องหลังที่ห้ามรันซ้อนกัน (ข้าม process ได้ เช่น server กับ CLI ที่ใช้ DB เดียวกัน)
+// แถวที่ ExpiresAt ผ่านไปแล้ว = ไม่มีใครถือ (process ที่ถือตายกลางทางก็ปล่อยเองเมื่อหมดเวลา)
+type JobLock struct {
+	Name      string    `gorm:"primaryKey;size:64" json:"name"`
+	Holder    string    `gorm:"size:128;not null" json:"holder"` // host:pid:random ของผู้ถือ
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+}
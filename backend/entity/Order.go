@@ -8,10 +8,11 @@ type Order struct {
     gorm.Model
 
     MemberID uint   `json:"member_id"`
-    Member   Member `gorm:"foreignKey:MemberID"`
+    Member   Member `gorm:"foreignKey:MemberID" json:"-"`
 
     TotalPrice int `json:"total_price"`
 
     // ความสัมพันธ์
     DiscountUsages []DiscountUsage `json:"discount_usages"`
+    Items          []OrderItem     `json:"items"`
 }
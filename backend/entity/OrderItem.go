@@ -0,0 +1,16 @@
+package entity
+
+import "gorm.io/gorm"
+
+// OrderItem = สินค้า 1 รายการใน order (เก็บชื่อ/ราคา ณ ตอนซื้อไว้ เผื่อสินค้าถูกแก้ภายหลัง)
+// ใช้ตรวจสิทธิ์รีวิวด้วย: รีวิวได้เฉพาะสินค้าที่เคยซื้อจริง
+type OrderItem struct {
+	gorm.Model
+	OrderID   uint   `gorm:"index;not null" json:"order_id"`
+	ProductID uint   `gorm:"index;not null" json:"product_id"`
+	VariantID *uint  `json:"variant_id"`
+	SellerID  uint   `gorm:"index" json:"seller_id"`
+	Name      string `json:"name"`
+	UnitPrice int    `json:"unit_price"`
+	Quantity  int    `json:"quantity"`
+}
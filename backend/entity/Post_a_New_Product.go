@@ -1,7 +1,20 @@
 // entity/post.go
 package entity
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// สถานะโพสต์ขายสินค้า (หน้าสาธารณะแสดงเฉพาะ published)
+const (
+	PostDraft     = "draft"     // ร่าง ยังไม่เปิดขาย
+	PostScheduled = "scheduled" // ตั้งเวลาเปิดขายไว้ที่ PublishAt
+	PostPublished = "published" // เปิดขายอยู่
+	PostPaused    = "paused"    // พักการขายชั่วคราว
+	PostArchived  = "archived"  // เลิกขายแล้ว (ยังเก็บไว้ดูย้อนหลัง)
+)
 
 type Post_a_New_Product struct {
 	gorm.Model
@@ -14,4 +27,9 @@ type Post_a_New_Product struct {
 
 	SellerID *uint  `json:"seller_id"`
 	Seller   Seller `gorm:"foreignKey:SellerID;references:ID" json:"Seller"`
+
+	Status      string     `gorm:"size:20;not null;default:published;index" json:"status"`
+	PublishAt   *time.Time `gorm:"index" json:"publish_at"`   // เวลาเปิดขายอัตโนมัติ (สถานะ scheduled)
+	UnpublishAt *time.Time `gorm:"index" json:"unpublish_at"` // เวลาพักการขายอัตโนมัติ (สถานะ published)
+	PublishedAt *time.Time `json:"published_at"`              // เปิดขายครั้งล่าสุดเมื่อไร
 }
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// ที่มาของการเปลี่ยนราคา
+const (
+	PriceSourceCreate   = "create"   // ราคาตอนสร้างสินค้า
+	PriceSourceUpdate   = "update"   // ผู้ขายแก้สินค้า / variant
+	PriceSourceImport   = "import"   // นำเข้าแคตตาล็อก
+	PriceSourceBaseline = "baseline" // ราคาตั้งต้นของสินค้าที่มีอยู่ก่อนเริ่มเก็บประวัติ
+)
+
+// PriceChange = ประวัติราคา เพิ่มได้อย่างเดียว 1 แถวต่อการเปลี่ยนราคา 1 ครั้ง
+// VariantID = nil คือราคาของสินค้า (ที่แสดงในหน้ารายการ มี variant = ราคาต่ำสุดของ variant)
+// OldPrice = nil คือแถวแรกของสินค้า/variant นั้น
+type PriceChange struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ProductID uint   `gorm:"index;not null" json:"product_id"`
+	VariantID *uint  `gorm:"index" json:"variant_id"`
+	OldPrice  *int   `json:"old_price"`
+	NewPrice  int    `json:"new_price"`
+	Source    string `gorm:"type:varchar(16);not null" json:"source"`
+	ActorID   *uint  `json:"actor_id,omitempty"`
+}
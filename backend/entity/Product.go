@@ -8,10 +8,26 @@ type Product struct {
 
 	Name        string `json:"name"`
 	Description string `json:"description"`
-	Price       int    `json:"price"`
-	Quantity    int    `json:"quantity"`
+	Price       int    `gorm:"index" json:"price"` // มี variant = ราคาต่ำสุดของ variant
+	PriceMax    int    `json:"price_max"`          // มี variant = ราคาสูงสุด, ไม่มี = เท่ากับ Price
+	Quantity    int    `json:"quantity"`           // มี variant = ผลรวมสต็อกของทุก variant
 	SellerID    uint   `json:"seller_id"`
+	// ถูกจองระหว่าง checkout (ขายได้ = Quantity - Reserved) แก้ผ่าน package inventory เท่านั้น
+	Reserved int `gorm:"not null;default:0" json:"reserved"`
+	// จำนวนชิ้นที่ขายไปแล้ว ใช้เรียงตามความนิยม
+	SoldCount int `gorm:"not null;default:0;index" json:"sold_count"`
+	// คะแนนรีวิว (คำนวณใหม่ทุกครั้งที่รีวิวเปลี่ยน ไม่นับรีวิวที่ถูกซ่อน)
+	RatingAvg   float64 `gorm:"not null;default:0" json:"rating_avg"`
+	RatingCount int     `gorm:"not null;default:0" json:"rating_count"`
+	// ราคาต่ำสุดในช่วง 30 วันที่ผ่านมา (รวมราคาปัจจุบัน) คำนวณจาก PriceChange ตอนแสดงผล ไม่มีคอลัมน์จริง
+	LowestPrice30d *int `gorm:"-" json:"lowest_price_30d"`
 
 	// 👉 ให้ React เข้าถึง product.ProductImage[0].image_path ได้
 	 ProductImage []ProductImage `gorm:"foreignKey:Product_ID;constraint:OnDelete:CASCADE;" json:"ProductImage"`
+
+	Options  []ProductOption  `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;" json:"options"`
+	Variants []ProductVariant `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;" json:"variants"`
+
+	// ข้อมูลจำเพาะตาม attribute ของหมวด (รวมที่สืบทอดจากหมวดแม่)
+	Attributes []ProductAttributeValue `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;" json:"attributes"`
 }
@@ -0,0 +1,34 @@
+package entity
+
+import "gorm.io/gorm"
+
+// ProductOption = ประเภทตัวเลือกของสินค้า เช่น "ไซซ์", "สี"
+type ProductOption struct {
+	gorm.Model
+	ProductID uint                 `gorm:"index;not null" json:"product_id"`
+	Name      string               `gorm:"type:varchar(50);not null" json:"name"`
+	Position  int                  `json:"position"`
+	Values    []ProductOptionValue `gorm:"foreignKey:OptionID;constraint:OnDelete:CASCADE;" json:"values"`
+}
+
+// ProductOptionValue = ค่าของตัวเลือก เช่น "S", "M", "แดง"
+type ProductOptionValue struct {
+	gorm.Model
+	OptionID uint   `gorm:"index;not null" json:"option_id"`
+	Value    string `gorm:"type:varchar(50);not null" json:"value"`
+	Position int    `json:"position"`
+}
+
+// ProductVariant = SKU ที่ขายจริง 1 แบบ (1 ค่าต่อ 1 ตัวเลือก) มีราคา/สต็อก/รูปของตัวเอง
+type ProductVariant struct {
+	gorm.Model
+	ProductID uint   `gorm:"index;not null" json:"product_id"`
+	SKU       string `gorm:"type:varchar(64);index" json:"sku"`
+	Price     int    `json:"price"`
+	Quantity  int    `json:"quantity"`
+	Reserved  int    `gorm:"not null;default:0" json:"reserved"`
+	ImagePath string `json:"image_path"`
+	Position  int    `json:"position"`
+
+	OptionValues []ProductOptionValue `gorm:"many2many:product_variant_values;" json:"option_values"`
+}
@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode = รหัสสำรองใช้ครั้งเดียว สำหรับตอนไม่มีแอป Authenticator
+type RecoveryCode struct {
+	gorm.Model
+	MemberID uint       `gorm:"index;not null" json:"member_id"`
+	CodeHash string     `gorm:"size:64;not null" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
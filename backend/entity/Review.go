@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Review = รีวิวสินค้าจากผู้ซื้อ (1 คนรีวิวสินค้าหนึ่งได้ครั้งเดียว แก้ไขได้)
+// ผู้ขายตอบได้ 1 ครั้งต่อรีวิว, admin ซ่อนรีวิวได้ (ไม่นับในคะแนนเฉลี่ย)
+type Review struct {
+	gorm.Model
+	ProductID uint   `gorm:"uniqueIndex:ux_review_member_product;not null" json:"product_id"`
+	MemberID  uint   `gorm:"uniqueIndex:ux_review_member_product;not null" json:"member_id"`
+	SellerID  uint   `gorm:"index;not null" json:"seller_id"`
+	Rating    int    `gorm:"not null" json:"rating"` // 1-5
+	Text      string `gorm:"type:varchar(2000)" json:"text"`
+
+	Photos []ReviewPhoto `gorm:"foreignKey:ReviewID;constraint:OnDelete:CASCADE;" json:"photos"`
+
+	SellerReply   string     `gorm:"type:varchar(2000)" json:"seller_reply"`
+	SellerReplyAt *time.Time `json:"seller_reply_at"`
+
+	HiddenAt     *time.Time `gorm:"index" json:"hidden_at,omitempty"`
+	HiddenReason string     `json:"hidden_reason,omitempty"`
+	HiddenBy     *uint      `json:"hidden_by,omitempty"`
+
+	// ชื่อผู้รีวิว (อ่านจาก join ตอนแสดงผล ไม่มีคอลัมน์จริง)
+	ReviewerName string `gorm:"->;-:migration" json:"reviewer_name,omitempty"`
+}
+
+type ReviewPhoto struct {
+	gorm.Model
+	ReviewID  uint   `gorm:"index;not null" json:"review_id"`
+	ImagePath string `json:"image_path"`
+}
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// สิทธิ์ของ API key (write ทำได้ทุกอย่างที่ read ทำได้)
+const (
+	APIScopeRead  = "read"
+	APIScopeWrite = "write"
+)
+
+// SellerAPIKey = กุญแจให้ระบบของผู้ขายเรียก API แทนการล็อกอินผ่านเบราว์เซอร์ (เก็บเฉพาะ hash)
+type SellerAPIKey struct {
+	gorm.Model
+	SellerID uint   `gorm:"index;not null" json:"seller_id"`
+	Seller   Seller `gorm:"foreignKey:SellerID" json:"-"`
+
+	Name  string `gorm:"size:100;not null" json:"name"`
+	Scope string `gorm:"size:10;not null;default:read" json:"scope"`
+	// ต้นของ key ให้ผู้ขายจำได้ว่าเป็นดอกไหน เช่น gsk_AbC123xy
+	Prefix  string `gorm:"size:16;not null" json:"prefix"`
+	KeyHash string `gorm:"size:64;uniqueIndex;not null" json:"-"`
+
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `gorm:"size:64" json:"last_used_ip"`
+	RevokedAt  *time.Time `gorm:"index" json:"revoked_at"`
+}
@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session = 1 อุปกรณ์ที่ล็อกอินอยู่ (ผูกกับ refresh token ตัวล่าสุด)
+type Session struct {
+	gorm.Model
+	MemberID uint   `gorm:"index;not null" json:"member_id"`
+	Member   Member `gorm:"foreignKey:MemberID" json:"-"`
+
+	// เก็บเฉพาะ sha256 ของ refresh token ไม่เก็บตัวจริง
+	RefreshTokenHash string `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	// hash ของ token ก่อนหมุน ใช้จับการนำ token เก่ากลับมาใช้ซ้ำ
+	PrevTokenHash string `gorm:"size:64;index" json:"-"`
+
+	ExpiresAt  time.Time  `gorm:"index;not null" json:"expires_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `gorm:"index" json:"revoked_at"`
+
+	UserAgent string `gorm:"size:255" json:"user_agent"`
+	IP        string `gorm:"size:64" json:"ip"`
+}
@@ -1,13 +1,31 @@
 package entity
 
 import (
-    
+    "time"
+
     "gorm.io/gorm")
 
+// บทบาทของสมาชิก (ใส่ไว้ใน JWT ด้วย)
+const (
+    RoleBuyer  = "buyer"
+    RoleSeller = "seller"
+    RoleAdmin  = "admin"
+)
+
 type Member struct {
     gorm.Model
     UserName string `json:"username"`
-    Password string
+    Password string `json:"-"`
+    Role     string `gorm:"size:20;not null;default:buyer;index" json:"role"`
+
+    // 2FA (TOTP): secret ถูกเซ็ตตอน setup แต่ยังไม่ใช้จนกว่าจะ confirm (TOTPEnabled)
+    TOTPSecret   string `json:"-"`
+    TOTPEnabled  bool   `gorm:"not null;default:false" json:"totp_enabled"`
+    TOTPLastStep int64  `json:"-"` // step ล่าสุดที่ใช้ไปแล้ว กันใช้รหัสเดิมซ้ำ
+
+    // ลบบัญชีแล้ว (ข้อมูลส่วนตัวถูกล้าง แต่แถวยังอยู่ให้ order/DM อ้างถึงได้)
+    DeactivatedAt *time.Time `json:"deactivated_at"`
+
     PeopleID uint   // FK -> People.ID
     People   People // Relation
     Seller   Seller `gorm:"foreignKey:MemberID;references:ID"`
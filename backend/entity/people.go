@@ -9,6 +9,7 @@ type People struct {
     FirstName string
     LastName  string
     Email     string
+    EmailVerifiedAt *time.Time
     Age       int
     Phone     string
     BirthDay  time.Time
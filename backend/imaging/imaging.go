@@ -0,0 +1,181 @@
+// Package imaging ตรวจและแปลงรูปที่อัปโหลด ก่อนเก็บลงดิสก์
+//
+//   - ดูชนิดไฟล์จากเนื้อไฟล์จริง (ไม่เชื่อนามสกุล) รับเฉพาะ JPEG / PNG / GIF
+//   - จำกัดขนาดไฟล์และขนาดภาพ อ่าน header ก่อน decode จริง (กัน decompression bomb)
+//   - decode แล้ว encode ใหม่ เมตาดาต้าทั้งหมด (EXIF / GPS / ความเห็น) จึงหลุดไป
+//     แต่หมุนภาพตาม EXIF orientation ให้ก่อน รูปจากมือถือจะได้ไม่ตะแคง
+//   - ย่อรูปเพิ่มตาม Sizes (ไม่ขยายรูปที่เล็กกว่าอยู่แล้ว)
+//
+// ใช้แค่ standard library (GIF เก็บเฉพาะเฟรมแรก แปลงเป็น PNG)
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	MaxFileSize  = 10 << 20   // 10MB
+	MaxDimension = 8000       // ด้านยาวสุด (px)
+	MaxPixels    = 40_000_000 // กว้าง x สูง
+	MinDimension = 16         // เล็กกว่านี้ไม่น่าใช่รูปสินค้า
+
+	jpegQuality = 85
+)
+
+var (
+	ErrUnsupported = errors.New("รองรับเฉพาะไฟล์รูป JPEG, PNG หรือ GIF")
+	ErrTooLarge    = fmt.Errorf("ไฟล์รูปต้องมีขนาดไม่เกิน %dMB", MaxFileSize>>20)
+	ErrDimensions  = fmt.Errorf("ขนาดรูปต้องอยู่ระหว่าง %d ถึง %d px และไม่เกิน %d ล้านพิกเซล", MinDimension, MaxDimension, MaxPixels/1_000_000)
+	ErrCorrupt     = errors.New("ไฟล์รูปเสียหรืออ่านไม่ได้")
+)
+
+// IsInvalid = error ที่เกิดจากไฟล์ของผู้ใช้ (ตอบ 400) ไม่ใช่ปัญหาฝั่ง server
+func IsInvalid(err error) bool {
+	return errors.Is(err, ErrUnsupported) || errors.Is(err, ErrTooLarge) ||
+		errors.Is(err, ErrDimensions) || errors.Is(err, ErrCorrupt)
+}
+
+// Size = รูปย่อ 1 ขนาด (ย่อให้ด้านยาวสุดไม่เกิน Max)
+type Size struct {
+	Name string
+	Max  int
+}
+
+// Sizes = รูปย่อที่สร้างให้ทุกรูป (ตัวเต็มเก็บเป็นชื่อ "original")
+var Sizes = []Size{
+	{Name: "thumb", Max: 150},
+	{Name: "small", Max: 400},
+	{Name: "medium", Max: 800},
+}
+
+const Original = "original"
+
+// Variant = รูป 1 ขนาดที่ encode แล้ว
+type Variant struct {
+	Name   string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Result = ผลจาก Process: ตัวเต็ม (ล้างเมตาดาต้าแล้ว) + รูปย่อทุกขนาด
+type Result struct {
+	ContentType string // ชนิดไฟล์ที่ encode ออกมา
+	Ext         string // ".jpg" / ".png"
+	Variants    []Variant
+}
+
+var allowed = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".png", // เก็บเป็น PNG (เฟรมแรก)
+}
+
+// Sniff ดูชนิดไฟล์จาก 512 ไบต์แรก คืน ErrUnsupported ถ้าไม่อยู่ใน allowlist
+func Sniff(data []byte) (string, error) {
+	ct := http.DetectContentType(data)
+	if _, ok := allowed[ct]; !ok {
+		return ct, ErrUnsupported
+	}
+	return ct, nil
+}
+
+// Process ตรวจ decode แล้ว encode รูปใหม่ทุกขนาด
+func Process(data []byte) (*Result, error) {
+	if len(data) > MaxFileSize {
+		return nil, ErrTooLarge
+	}
+	ct, err := Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	if !dimensionsOK(cfg.Width, cfg.Height) {
+		return nil, ErrDimensions
+	}
+
+	var img image.Image
+	switch ct {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	if ct == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	res := &Result{Ext: allowed[ct], ContentType: "image/png"}
+	if res.Ext == ".jpg" {
+		res.ContentType = "image/jpeg"
+	}
+	encode := func(name string, m image.Image) error {
+		var buf bytes.Buffer
+		var err error
+		if res.Ext == ".jpg" {
+			err = jpeg.Encode(&buf, m, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, m)
+		}
+		if err != nil {
+			return err
+		}
+		b := m.Bounds()
+		res.Variants = append(res.Variants, Variant{Name: name, Width: b.Dx(), Height: b.Dy(), Data: buf.Bytes()})
+		return nil
+	}
+
+	if err := encode(Original, img); err != nil {
+		return nil, err
+	}
+	for _, s := range Sizes {
+		if err := encode(s.Name, Fit(img, s.Max)); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func dimensionsOK(w, h int) bool {
+	return w >= MinDimension && h >= MinDimension &&
+		w <= MaxDimension && h <= MaxDimension &&
+		int64(w)*int64(h) <= MaxPixels
+}
+
+// VariantPath คืน path ของรูปย่อจาก path ตัวเต็ม
+// "/uploads/products/123.jpg" + "thumb" -> "/uploads/products/123_thumb.jpg"
+func VariantPath(p, size string) string {
+	if size == Original {
+		return p
+	}
+	ext := path.Ext(p)
+	return strings.TrimSuffix(p, ext) + "_" + size + ext
+}
+
+// VariantPaths คืน path ของทุกขนาดรวมตัวเต็ม (name -> path)
+func VariantPaths(p string) map[string]string {
+	out := make(map[string]string, len(Sizes)+1)
+	out[Original] = p
+	for _, s := range Sizes {
+		out[s.Name] = VariantPath(p, s.Name)
+	}
+	return out
+}
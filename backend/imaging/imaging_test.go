@@ -0,0 +1,400 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// รูปทดสอบสร้างในเทสต์ทั้งหมด (เล็ก ๆ) ไม่ต้องเก็บไฟล์ fixture
+
+// w x h สีขาว มุมซ้ายบน 16x16 เป็นสีแดง ใช้ดูว่าหมุนไปทางไหน
+func marked(w, h int) *image.NRGBA {
+	m := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{255, 255, 255, 255}
+			if x < 16 && y < 16 {
+				c = color.NRGBA{255, 0, 0, 255}
+			}
+			m.SetNRGBA(x, y, c)
+		}
+	}
+	return m
+}
+
+func encodeJPEG(t *testing.T, m image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, m, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, m image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// exifSegment สร้าง APP1 (Exif, little-endian) ที่มี Orientation, ImageDescription และ GPS IFD (ละติจูด)
+func exifSegment(orientation uint16, description string) []byte {
+	le := binary.LittleEndian
+	desc := append([]byte(description), 0)
+	const ifd0 = 8
+	const ifd0Entries = 3
+	gpsIFD := ifd0 + 2 + ifd0Entries*12 + 4
+	const gpsEntries = 2
+	dataOff := gpsIFD + 2 + gpsEntries*12 + 4
+
+	t := make([]byte, dataOff)
+	copy(t, "II")
+	le.PutUint16(t[2:], 42)
+	le.PutUint32(t[4:], ifd0)
+	entry := func(at int, tag, typ uint16, count, value uint32) {
+		le.PutUint16(t[at:], tag)
+		le.PutUint16(t[at+2:], typ)
+		le.PutUint32(t[at+4:], count)
+		le.PutUint32(t[at+8:], value)
+	}
+	le.PutUint16(t[ifd0:], ifd0Entries)
+	entry(ifd0+2, 0x010E, 2, uint32(len(desc)), uint32(dataOff)) // ImageDescription -> ข้อความท้าย
+	entry(ifd0+14, 0x0112, 3, 1, uint32(orientation))            // Orientation
+	entry(ifd0+26, 0x8825, 4, 1, uint32(gpsIFD))                 // GPSInfo IFD
+	le.PutUint16(t[gpsIFD:], gpsEntries)
+	entry(gpsIFD+2, 0x0001, 2, 2, 'N')                        // GPSLatitudeRef = "N"
+	entry(gpsIFD+14, 0x0002, 5, 3, uint32(dataOff+len(desc))) // GPSLatitude (RATIONAL x3)
+	t = append(t, desc...)
+	for _, v := range []uint32{13, 1, 45, 1, 1234, 100} { // 13° 45' 12.34"
+		t = binary.LittleEndian.AppendUint32(t, v)
+	}
+
+	seg := append([]byte("Exif\x00\x00"), t...)
+	out := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(out[2:], uint16(len(seg)+2))
+	return append(out, seg...)
+}
+
+// withEXIF แทรก APP1 ต่อจาก SOI ของ JPEG
+func withEXIF(jpg []byte, orientation uint16, description string) []byte {
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, exifSegment(orientation, description)...)
+	return append(out, jpg[2:]...)
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	out = append(out, typ...)
+	out = append(out, data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+}
+
+// pngHeader = PNG ที่มีแค่ IHDR (ไม่มีข้อมูลภาพ) ใช้ทดสอบว่าปฏิเสธจาก header ก่อน decode
+func pngHeader(w, h uint32) []byte {
+	ihdr := binary.BigEndian.AppendUint32(nil, w)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, h)
+	ihdr = append(ihdr, 8, 6, 0, 0, 0) // 8-bit RGBA
+	out := []byte("\x89PNG\r\n\x1a\n")
+	out = append(out, pngChunk("IHDR", ihdr)...)
+	return append(out, pngChunk("IEND", nil)...)
+}
+
+func TestSniff(t *testing.T) {
+	small := marked(20, 20)
+	var gifBuf bytes.Buffer
+	if err := gif.Encode(&gifBuf, small, nil); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		data []byte
+		ct   string
+		ok   bool
+	}{
+		{"jpeg", encodeJPEG(t, small), "image/jpeg", true},
+		{"png", encodePNG(t, small), "image/png", true},
+		{"gif", gifBuf.Bytes(), "image/gif", true},
+		{"bmp", []byte("BM\x36\x00\x00\x00\x00\x00\x00\x00\x36\x00\x00\x00"), "image/bmp", false},
+		{"webp", []byte("RIFF\x1a\x00\x00\x00WEBPVP8 "), "image/webp", false},
+		{"svg/html", []byte(`<html><svg onload="alert(1)"></svg></html>`), "text/html; charset=utf-8", false},
+		{"text", []byte("just text, named .png"), "text/plain; charset=utf-8", false},
+		{"empty", nil, "text/plain; charset=utf-8", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ct, err := Sniff(tc.data)
+			if ct != tc.ct || (err == nil) != tc.ok {
+				t.Fatalf("Sniff = %q, %v; want %q ok=%t", ct, err, tc.ct, tc.ok)
+			}
+			if !tc.ok && !errors.Is(err, ErrUnsupported) {
+				t.Fatalf("err = %v, want ErrUnsupported", err)
+			}
+		})
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	truncated := encodeJPEG(t, marked(64, 64))
+	truncated = truncated[:len(truncated)/2]
+
+	cases := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"too large file", append(encodePNG(t, marked(20, 20)), make([]byte, MaxFileSize)...), ErrTooLarge},
+		{"not an image", []byte("hello"), ErrUnsupported},
+		{"too small", encodePNG(t, image.NewNRGBA(image.Rect(0, 0, MinDimension-1, 100))), ErrDimensions},
+		{"too wide", pngHeader(MaxDimension+1, 100), ErrDimensions},
+		{"too tall", pngHeader(100, MaxDimension+1), ErrDimensions},
+		{"too many pixels", pngHeader(MaxDimension, MaxPixels/MaxDimension+1), ErrDimensions},
+		{"huge header", pngHeader(100_000, 100_000), ErrDimensions},
+		{"bad header", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDRgarbage"), ErrCorrupt},
+		{"truncated body", truncated, ErrCorrupt},
+		{"header only", pngHeader(100, 100), ErrCorrupt},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Process(tc.data)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v (res=%v), want %v", err, res != nil, tc.want)
+			}
+			if !IsInvalid(err) {
+				t.Fatalf("IsInvalid(%v) = false", err)
+			}
+		})
+	}
+	if IsInvalid(errors.New("disk full")) {
+		t.Fatal("server error treated as invalid input")
+	}
+}
+
+func TestProcessSizes(t *testing.T) {
+	cases := []struct {
+		name string
+		w, h int
+		want map[string][2]int
+	}{
+		{"landscape", 1000, 500, map[string][2]int{Original: {1000, 500}, "thumb": {150, 75}, "small": {400, 200}, "medium": {800, 400}}},
+		{"portrait", 300, 900, map[string][2]int{Original: {300, 900}, "thumb": {50, 150}, "small": {133, 400}, "medium": {266, 800}}},
+		{"no upscale", 100, 60, map[string][2]int{Original: {100, 60}, "thumb": {100, 60}, "small": {100, 60}, "medium": {100, 60}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Process(encodePNG(t, marked(tc.w, tc.h)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Ext != ".png" || res.ContentType != "image/png" || len(res.Variants) != len(Sizes)+1 {
+				t.Fatalf("result = %s %s %d variants", res.Ext, res.ContentType, len(res.Variants))
+			}
+			for _, v := range res.Variants {
+				want, ok := tc.want[v.Name]
+				if !ok || v.Width != want[0] || v.Height != want[1] {
+					t.Errorf("%s = %dx%d, want %v", v.Name, v.Width, v.Height, want)
+				}
+				// ขนาดที่บอกต้องตรงกับไฟล์ที่ encode จริง
+				cfg, err := png.DecodeConfig(bytes.NewReader(v.Data))
+				if err != nil || cfg.Width != v.Width || cfg.Height != v.Height {
+					t.Errorf("%s data = %dx%d %v", v.Name, cfg.Width, cfg.Height, err)
+				}
+			}
+		})
+	}
+
+	t.Run("gif becomes png", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := gif.Encode(&buf, marked(40, 30), nil); err != nil {
+			t.Fatal(err)
+		}
+		res, err := Process(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := png.Decode(bytes.NewReader(res.Variants[0].Data)); err != nil || res.Ext != ".png" {
+			t.Fatalf("ext %s, decode %v", res.Ext, err)
+		}
+	})
+}
+
+// มุมที่สีแดงไปอยู่หลังแก้ orientation (รูปต้นฉบับ 48x32 แดงที่มุมซ้ายบน)
+var orientationCases = []struct {
+	o      uint16
+	w, h   int
+	corner string
+}{
+	{1, 48, 32, "top-left"},
+	{2, 48, 32, "top-right"},
+	{3, 48, 32, "bottom-right"},
+	{4, 48, 32, "bottom-left"},
+	{5, 32, 48, "top-left"},
+	{6, 32, 48, "top-right"},
+	{7, 32, 48, "bottom-right"},
+	{8, 32, 48, "bottom-left"},
+}
+
+// redCorner หาว่ามุมไหนเป็นสีแดง (ดูกลางกล่อง 16x16 ของแต่ละมุม เผื่อ JPEG เพี้ยนสีเล็กน้อย)
+func redCorner(m image.Image) string {
+	b := m.Bounds()
+	corners := map[string]image.Point{
+		"top-left":     {b.Min.X + 8, b.Min.Y + 8},
+		"top-right":    {b.Max.X - 9, b.Min.Y + 8},
+		"bottom-left":  {b.Min.X + 8, b.Max.Y - 9},
+		"bottom-right": {b.Max.X - 9, b.Max.Y - 9},
+	}
+	found := ""
+	for name, p := range corners {
+		r, g, bl, _ := m.At(p.X, p.Y).RGBA()
+		if r>>8 > 200 && g>>8 < 80 && bl>>8 < 80 {
+			if found != "" {
+				return "many"
+			}
+			found = name
+		}
+	}
+	return found
+}
+
+func TestApplyOrientation(t *testing.T) {
+	for _, tc := range orientationCases {
+		got := applyOrientation(marked(48, 32), int(tc.o))
+		if b := got.Bounds(); b.Dx() != tc.w || b.Dy() != tc.h {
+			t.Errorf("orientation %d: %dx%d, want %dx%d", tc.o, b.Dx(), b.Dy(), tc.w, tc.h)
+		}
+		if c := redCorner(got); c != tc.corner {
+			t.Errorf("orientation %d: red at %q, want %q", tc.o, c, tc.corner)
+		}
+	}
+	// ค่านอกช่วง = ไม่หมุน
+	for _, o := range []int{0, 9, -1} {
+		if got := applyOrientation(marked(48, 32), o); redCorner(got) != "top-left" || got.Bounds().Dx() != 48 {
+			t.Errorf("orientation %d changed the image", o)
+		}
+	}
+}
+
+func TestProcessJPEGOrientation(t *testing.T) {
+	src := encodeJPEG(t, marked(48, 32))
+	for _, tc := range orientationCases {
+		data := withEXIF(src, tc.o, "x")
+		if got := jpegOrientation(data); got != int(tc.o) {
+			t.Fatalf("fixture orientation = %d, want %d", got, tc.o)
+		}
+		res, err := Process(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orig := res.Variants[0]
+		m, err := jpeg.Decode(bytes.NewReader(orig.Data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if orig.Width != tc.w || orig.Height != tc.h || redCorner(m) != tc.corner {
+			t.Errorf("orientation %d: %dx%d red at %q, want %dx%d %q", tc.o, orig.Width, orig.Height, redCorner(m), tc.w, tc.h, tc.corner)
+		}
+		// หมุนแล้วต้องไม่เหลือ orientation ให้ viewer หมุนซ้ำ
+		if got := jpegOrientation(orig.Data); got != 1 {
+			t.Errorf("orientation %d: output orientation = %d", tc.o, got)
+		}
+	}
+}
+
+func TestJPEGOrientationMalformed(t *testing.T) {
+	src := encodeJPEG(t, marked(20, 20))
+	good := withEXIF(src, 6, "x")
+	cases := map[string][]byte{
+		"no exif":        src,
+		"not jpeg":       encodePNG(t, marked(20, 20)),
+		"truncated exif": good[:30],
+		"bad byte order": bytes.Replace(good, []byte("Exif\x00\x00II"), []byte("Exif\x00\x00XX"), 1),
+		"empty":          nil,
+	}
+	for name, data := range cases {
+		if got := jpegOrientation(data); got != 1 {
+			t.Errorf("%s: orientation = %d, want 1", name, got)
+		}
+	}
+}
+
+func TestProcessStripsMetadata(t *testing.T) {
+	const secret = "GPS-SECRET-HOME-ADDRESS"
+
+	t.Run("jpeg exif with gps", func(t *testing.T) {
+		data := withEXIF(encodeJPEG(t, marked(48, 32)), 1, secret)
+		if !bytes.Contains(data, []byte(secret)) || !bytes.Contains(data, []byte("Exif\x00\x00")) {
+			t.Fatal("fixture has no EXIF")
+		}
+		res, err := Process(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Ext != ".jpg" || res.ContentType != "image/jpeg" {
+			t.Fatalf("result = %s %s", res.Ext, res.ContentType)
+		}
+		for _, v := range res.Variants {
+			if bytes.Contains(v.Data, []byte("Exif")) || bytes.Contains(v.Data, []byte(secret)) {
+				t.Errorf("%s still has EXIF/GPS", v.Name)
+			}
+			if hasAPP1(v.Data) {
+				t.Errorf("%s has an APP1 segment", v.Name)
+			}
+		}
+	})
+
+	t.Run("png text chunk", func(t *testing.T) {
+		p := encodePNG(t, marked(48, 32))
+		// แทรก tEXt ต่อจาก IHDR (signature 8 + IHDR 25 ไบต์)
+		data := append(append(append([]byte{}, p[:33]...), pngChunk("tEXt", []byte("Comment\x00"+secret))...), p[33:]...)
+		if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+			t.Fatalf("fixture: %v", err)
+		}
+		res, err := Process(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range res.Variants {
+			if bytes.Contains(v.Data, []byte(secret)) {
+				t.Errorf("%s still has the text chunk", v.Name)
+			}
+		}
+	})
+}
+
+// hasAPP1 ไล่ marker ของ JPEG จนถึง SOS
+func hasAPP1(data []byte) bool {
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		if marker == 0xDA {
+			return false
+		}
+		if marker == 0xE1 {
+			return true
+		}
+		i += 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+	}
+	return false
+}
+
+func TestVariantPath(t *testing.T) {
+	if got := VariantPath("/uploads/products/123.jpg", "thumb"); got != "/uploads/products/123_thumb.jpg" {
+		t.Fatalf("got %s", got)
+	}
+	if got := VariantPath("/uploads/products/123.jpg", Original); got != "/uploads/products/123.jpg" {
+		t.Fatalf("got %s", got)
+	}
+	paths := VariantPaths("/uploads/a/b.png")
+	if len(paths) != len(Sizes)+1 || paths["medium"] != "/uploads/a/b_medium.png" {
+		t.Fatalf("paths = %v", paths)
+	}
+}
@@ -0,0 +1,80 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Fit ย่อรูปให้ด้านยาวสุดไม่เกิน max (รักษาสัดส่วน) รูปที่เล็กกว่าอยู่แล้วคืนตัวเดิม
+func Fit(src image.Image, max int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= max && h <= max {
+		return src
+	}
+	dw, dh := max, max
+	if w >= h {
+		dh = h * max / w
+	} else {
+		dw = w * max / h
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+	return resizeBox(toNRGBA(src), dw, dh)
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	if m, ok := src.(*image.NRGBA); ok && m.Rect.Min == (image.Point{}) {
+		return m
+	}
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, src, b.Min, draw.Src)
+	return dst
+}
+
+// ย่อแบบเฉลี่ยพื้นที่ (box filter): แต่ละพิกเซลปลายทาง = ค่าเฉลี่ยของกล่องพิกเซลต้นทางที่มันครอบ
+// สีถ่วงน้ำหนักด้วย alpha ขอบโปร่งใสจะได้ไม่เป็นเงาดำ
+func resizeBox(src *image.NRGBA, dw, dh int) *image.NRGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := y * sh / dh
+		y1 := (y + 1) * sh / dh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < dw; x++ {
+			x0 := x * sw / dw
+			x1 := (x + 1) * sw / dw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				i := sy*src.Stride + x0*4
+				for sx := x0; sx < x1; sx++ {
+					pa := uint64(src.Pix[i+3])
+					r += uint64(src.Pix[i]) * pa
+					g += uint64(src.Pix[i+1]) * pa
+					bl += uint64(src.Pix[i+2]) * pa
+					a += pa
+					n++
+					i += 4
+				}
+			}
+			o := y*dst.Stride + x*4
+			if a > 0 {
+				dst.Pix[o] = uint8(r / a)
+				dst.Pix[o+1] = uint8(g / a)
+				dst.Pix[o+2] = uint8(bl / a)
+			}
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
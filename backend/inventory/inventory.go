@@ -0,0 +1,237 @@
+// Package inventory = สต็อกสินค้าแบบมีสมุดบัญชี (StockMovement) และการจองระหว่าง checkout
+//
+// ทุกการเปลี่ยนจำนวนต้องผ่าน package นี้ ซึ่งจะ
+//   - แก้ quantity/reserved ด้วย UPDATE แบบมีเงื่อนไขคำสั่งเดียว (ไม่อ่านแล้วค่อยเขียน)
+//     เงื่อนไข quantity >= reserved >= 0 จึงไม่มีทางขายเกินแม้ checkout พร้อมกัน
+//   - บันทึก StockMovement 1 แถวใน transaction เดียวกัน
+//
+// สินค้าที่มี variant เก็บสต็อกที่ variant แล้วรวมยอดขึ้นไปที่ Product ให้อัตโนมัติ
+package inventory
+
+import (
+	"errors"
+	"fmt"
+
+	"example.com/GROUB/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInsufficientStock = errors.New("สินค้าในคลังไม่พอ")
+	ErrBelowReserved     = errors.New("จำนวนต้องไม่น้อยกว่าที่ถูกจองอยู่")
+	ErrInvalidQuantity   = errors.New("จำนวนไม่ถูกต้อง")
+	ErrNotFound          = errors.New("ไม่พบสินค้า")
+	ErrVariantRequired   = errors.New("สินค้านี้มีตัวเลือก ต้องระบุ variant_id")
+	ErrConcurrentUpdate  = errors.New("สต็อกถูกแก้พร้อมกัน ลองใหม่อีกครั้ง")
+)
+
+// Line = สินค้า 1 รายการ (VariantID = nil คือสินค้าที่ไม่มีตัวเลือก)
+type Line struct {
+	ProductID uint  `json:"product_id"`
+	VariantID *uint `json:"variant_id"`
+	Quantity  int   `json:"quantity"`
+}
+
+// change = การเปลี่ยนแปลง 1 ครั้ง
+type change struct {
+	Line
+	Type          string
+	Delta         int  // quantity +/- เท่านี้
+	ReservedDelta int  // reserved +/- เท่านี้
+	Expect        *int // ต้องมี quantity เท่านี้อยู่ก่อน (SetQuantity) ไม่งั้นถือว่าชนกัน
+	ReservationID *uint
+	ActorID       *uint
+	Note          string
+}
+
+// ตรวจว่า line ชี้ไปที่แถวที่ถูกต้อง: มี variant ต้องระบุ variant, variant ต้องเป็นของสินค้านั้น
+func resolve(tx *gorm.DB, l Line) error {
+	var p entity.Product
+	if err := tx.Select("id").First(&p, l.ProductID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if l.VariantID == nil {
+		var n int64
+		if err := tx.Model(&entity.ProductVariant{}).Where("product_id = ?", l.ProductID).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrVariantRequired
+		}
+		return nil
+	}
+	var n int64
+	if err := tx.Model(&entity.ProductVariant{}).Where("id = ? AND product_id = ?", *l.VariantID, l.ProductID).Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// apply แก้ยอดแบบ atomic แล้วลงสมุดบัญชี (ต้องเรียกใน transaction)
+func apply(tx *gorm.DB, ch change) (*entity.StockMovement, error) {
+	if err := resolve(tx, ch.Line); err != nil {
+		return nil, err
+	}
+
+	table, id := "products", ch.ProductID
+	if ch.VariantID != nil {
+		table, id = "product_variants", *ch.VariantID
+	}
+
+	// เงื่อนไขเดียวกันทั้งหมดอยู่ใน WHERE: ถ้าไม่ผ่าน RowsAffected = 0 และไม่มีอะไรถูกแก้
+	q := tx.Table(table).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Where("quantity + ? >= 0 AND reserved + ? >= 0 AND quantity + ? >= reserved + ?",
+			ch.Delta, ch.ReservedDelta, ch.Delta, ch.ReservedDelta)
+	if ch.Expect != nil {
+		q = q.Where("quantity = ?", *ch.Expect)
+	}
+	res := q.Updates(map[string]interface{}{
+		"quantity": gorm.Expr("quantity + ?", ch.Delta),
+		"reserved": gorm.Expr("reserved + ?", ch.ReservedDelta),
+	})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	var after struct{ Quantity, Reserved int }
+	if err := tx.Table(table).Select("quantity, reserved").Where("id = ?", id).Scan(&after).Error; err != nil {
+		return nil, err
+	}
+	if res.RowsAffected == 0 {
+		switch {
+		case ch.Expect != nil && after.Quantity != *ch.Expect:
+			return nil, ErrConcurrentUpdate
+		case ch.ReservedDelta > 0 || ch.Type == entity.StockSale:
+			return nil, ErrInsufficientStock
+		case after.Quantity+ch.Delta < 0:
+			return nil, ErrInvalidQuantity
+		default:
+			return nil, ErrBelowReserved
+		}
+	}
+
+	// สินค้าที่มี variant: ยอดของ Product = ผลรวมของ variant
+	if ch.VariantID != nil {
+		if err := tx.Exec(`UPDATE products SET
+			quantity = (SELECT COALESCE(SUM(quantity), 0) FROM product_variants WHERE product_id = ? AND deleted_at IS NULL),
+			reserved = (SELECT COALESCE(SUM(reserved), 0) FROM product_variants WHERE product_id = ? AND deleted_at IS NULL)
+			WHERE id = ?`, ch.ProductID, ch.ProductID, ch.ProductID).Error; err != nil {
+			return nil, err
+		}
+	}
+	switch ch.Type {
+	case entity.StockSale:
+		if err := tx.Exec("UPDATE products SET sold_count = sold_count + ? WHERE id = ?", -ch.Delta, ch.ProductID).Error; err != nil {
+			return nil, err
+		}
+	case entity.StockReturn:
+		if err := tx.Exec("UPDATE products SET sold_count = MAX(sold_count - ?, 0) WHERE id = ?", ch.Delta, ch.ProductID).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	mv := entity.StockMovement{
+		ProductID:     ch.ProductID,
+		VariantID:     ch.VariantID,
+		Type:          ch.Type,
+		Delta:         ch.Delta,
+		ReservedDelta: ch.ReservedDelta,
+		QuantityAfter: after.Quantity,
+		ReservedAfter: after.Reserved,
+		ReservationID: ch.ReservationID,
+		ActorID:       ch.ActorID,
+		Note:          ch.Note,
+	}
+	if err := tx.Create(&mv).Error; err != nil {
+		return nil, err
+	}
+	return &mv, nil
+}
+
+// Restock รับของเข้าคลัง n ชิ้น
+func Restock(db *gorm.DB, l Line, actorID *uint, note string) (*entity.StockMovement, error) {
+	return delta(db, entity.StockRestock, l, actorID, note)
+}
+
+// Return รับของที่ลูกค้าคืนกลับเข้าคลัง n ชิ้น (ลดยอดขายด้วย)
+func Return(db *gorm.DB, l Line, actorID *uint, note string) (*entity.StockMovement, error) {
+	return delta(db, entity.StockReturn, l, actorID, note)
+}
+
+func delta(db *gorm.DB, typ string, l Line, actorID *uint, note string) (*entity.StockMovement, error) {
+	if l.Quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	var mv *entity.StockMovement
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		mv, err = apply(tx, change{Line: l, Type: typ, Delta: l.Quantity, ActorID: actorID, Note: note})
+		return err
+	})
+	return mv, err
+}
+
+// SetQuantity ตั้งจำนวนในคลังเป็น l.Quantity (บันทึกเป็น adjust ตามผลต่าง)
+// ไม่เปลี่ยน = ไม่บันทึกอะไร (คืน nil, nil)
+func SetQuantity(db *gorm.DB, l Line, actorID *uint, note string) (*entity.StockMovement, error) {
+	if l.Quantity < 0 {
+		return nil, ErrInvalidQuantity
+	}
+	var mv *entity.StockMovement
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := resolve(tx, l); err != nil {
+			return err
+		}
+		table, id := "products", l.ProductID
+		if l.VariantID != nil {
+			table, id = "product_variants", *l.VariantID
+		}
+		var cur int
+		if err := tx.Table(table).Select("quantity").Where("id = ?", id).Scan(&cur).Error; err != nil {
+			return err
+		}
+		if cur == l.Quantity {
+			return nil
+		}
+		var err error
+		mv, err = apply(tx, change{Line: l, Type: entity.StockAdjust, Delta: l.Quantity - cur, Expect: &cur, ActorID: actorID, Note: note})
+		return err
+	})
+	return mv, err
+}
+
+// Available = จำนวนที่ยังขาย/จองได้
+func Available(db *gorm.DB, l Line) (int, error) {
+	if err := resolve(db, l); err != nil {
+		return 0, err
+	}
+	table, id := "products", l.ProductID
+	if l.VariantID != nil {
+		table, id = "product_variants", *l.VariantID
+	}
+	var n int
+	err := db.Table(table).Select("quantity - reserved").Where("id = ?", id).Scan(&n).Error
+	return n, err
+}
+
+// ใช้ตอนสร้าง error message ที่บอกว่ารายการไหนไม่พอ
+type LineError struct {
+	Line Line
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	if e.Line.VariantID != nil {
+		return fmt.Sprintf("สินค้า %d (variant %d): %v", e.Line.ProductID, *e.Line.VariantID, e.Err)
+	}
+	return fmt.Sprintf("สินค้า %d: %v", e.Line.ProductID, e.Err)
+}
+
+func (e *LineError) Unwrap() error { return e.Err }
@@ -0,0 +1,278 @@
+package inventory
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/GROUB/entity"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SQLite ในหน่วยความจำ แยกต่อเทสต์ (ชื่อ = ชื่อเทสต์) ใช้ connection เดียวเหมือนเขียนได้ทีละคนของ SQLite
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&entity.Product{}, &entity.ProductVariant{}, &entity.StockMovement{}, &entity.StockReservation{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// สินค้าไม่มี variant พร้อมของในคลัง qty ชิ้น (ผ่าน Restock ให้มีแถวในสมุดบัญชีด้วย)
+func newProduct(t *testing.T, db *gorm.DB, qty int) uint {
+	t.Helper()
+	p := entity.Product{Name: "p", Description: "d", Price: 100}
+	if err := db.Create(&p).Error; err != nil {
+		t.Fatal(err)
+	}
+	if qty > 0 {
+		if _, err := Restock(db, Line{ProductID: p.ID, Quantity: qty}, nil, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p.ID
+}
+
+type stock struct{ Quantity, Reserved, SoldCount int }
+
+// อ่านยอดปัจจุบัน และตรวจว่าสมุดบัญชีรวมแล้วตรงกับยอด (ไม่มีการแก้ที่ไม่ได้ลงบัญชี)
+func checkStock(t *testing.T, db *gorm.DB, productID uint) stock {
+	t.Helper()
+	var s stock
+	if err := db.Table("products").Select("quantity, reserved, sold_count").Where("id = ?", productID).Scan(&s).Error; err != nil {
+		t.Fatal(err)
+	}
+	if s.Quantity < 0 || s.Reserved < 0 || s.Reserved > s.Quantity {
+		t.Fatalf("invalid stock %+v", s)
+	}
+	var led struct{ Q, R int }
+	db.Model(&entity.StockMovement{}).Select("COALESCE(SUM(delta), 0) AS q, COALESCE(SUM(reserved_delta), 0) AS r").
+		Where("product_id = ?", productID).Scan(&led)
+	if led.Q != s.Quantity || led.R != s.Reserved {
+		t.Fatalf("ledger %+v does not match stock %+v", led, s)
+	}
+	return s
+}
+
+func TestReserveParallelNeverOversells(t *testing.T) {
+	db := testDB(t)
+	const stockQty, buyers, each = 10, 30, 3
+	pid := newProduct(t, db, stockQty)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok, short := 0, 0
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func(member uint) {
+			defer wg.Done()
+			_, err := Reserve(db, member, []Line{{ProductID: pid, Quantity: each}}, time.Minute)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				ok++
+			case errors.Is(err, ErrInsufficientStock):
+				short++
+			default:
+				t.Errorf("member %d: %v", member, err)
+			}
+		}(uint(i + 1))
+	}
+	wg.Wait()
+
+	if ok != stockQty/each || ok+short != buyers {
+		t.Fatalf("reserved %d, short %d; want %d reserved", ok, short, stockQty/each)
+	}
+	s := checkStock(t, db, pid)
+	if s.Quantity != stockQty || s.Reserved != ok*each {
+		t.Fatalf("stock %+v, want quantity %d reserved %d", s, stockQty, ok*each)
+	}
+	var active int64
+	db.Model(&entity.StockReservation{}).Where("status = ?", entity.ReservationActive).Count(&active)
+	if active != int64(ok) {
+		t.Fatalf("active reservations = %d, want %d (failed ones must roll back)", active, ok)
+	}
+}
+
+func TestReserveIsAllOrNothing(t *testing.T) {
+	db := testDB(t)
+	a := newProduct(t, db, 5)
+	b := newProduct(t, db, 1)
+
+	_, err := Reserve(db, 1, []Line{{ProductID: a, Quantity: 2}, {ProductID: b, Quantity: 2}}, time.Minute)
+	var le *LineError
+	if !errors.As(err, &le) || le.Line.ProductID != b || !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("err = %v, want LineError for product %d", err, b)
+	}
+	if s := checkStock(t, db, a); s.Reserved != 0 {
+		t.Fatalf("product a still reserved after rollback: %+v", s)
+	}
+}
+
+func TestCommitAfterExpiry(t *testing.T) {
+	db := testDB(t)
+	pid := newProduct(t, db, 5)
+	rs, err := Reserve(db, 1, []Line{{ProductID: pid, Quantity: 2}}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := Commit(db, 1, rs[0].Ref); !errors.Is(err, ErrReservationClosed) {
+		t.Fatalf("commit after expiry: %v, want ErrReservationClosed", err)
+	}
+	if s := checkStock(t, db, pid); s.Quantity != 5 || s.Reserved != 2 || s.SoldCount != 0 {
+		t.Fatalf("after failed commit: %+v", s)
+	}
+
+	if n, err := ExpireDue(db, time.Now()); err != nil || n != 1 {
+		t.Fatalf("ExpireDue = %d, %v; want 1", n, err)
+	}
+	if s := checkStock(t, db, pid); s.Quantity != 5 || s.Reserved != 0 {
+		t.Fatalf("after expire: %+v", s)
+	}
+	if _, err := Commit(db, 1, rs[0].Ref); !errors.Is(err, ErrReservationClosed) {
+		t.Fatalf("commit after release: %v", err)
+	}
+}
+
+func TestExpireDueRacingCommit(t *testing.T) {
+	db := testDB(t)
+	pid := newProduct(t, db, 100)
+	committed, expired := 0, 0
+	for i := 0; i < 20; i++ {
+		rs, err := Reserve(db, uint(i+1), []Line{{ProductID: pid, Quantity: 1}}, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// ExpireDue มองว่าหมดเวลาแล้ว (now ล่วงหน้า) แต่ Commit ยังอยู่ในเวลา: ใครปิดก่อนได้ไป อีกฝั่งต้องไม่แตะยอด
+		var wg sync.WaitGroup
+		var commitErr error
+		var released int
+		wg.Add(2)
+		go func() { defer wg.Done(); _, commitErr = Commit(db, uint(i+1), rs[0].Ref) }()
+		go func() { defer wg.Done(); released, _ = ExpireDue(db, time.Now().Add(2*time.Hour)) }()
+		wg.Wait()
+
+		var r entity.StockReservation
+		db.First(&r, rs[0].ID)
+		switch {
+		case commitErr == nil && released == 0 && r.Status == entity.ReservationCommitted:
+			committed++
+		case errors.Is(commitErr, ErrReservationClosed) && released == 1 && r.Status == entity.ReservationExpired:
+			expired++
+		default:
+			t.Fatalf("round %d: commit=%v released=%d status=%s", i, commitErr, released, r.Status)
+		}
+	}
+	s := checkStock(t, db, pid)
+	if s.Reserved != 0 || s.Quantity != 100-committed || s.SoldCount != committed {
+		t.Fatalf("stock %+v after %d commits / %d expiries", s, committed, expired)
+	}
+	var sales int64
+	db.Model(&entity.StockMovement{}).Where("type = ?", entity.StockSale).Count(&sales)
+	if sales != int64(committed) {
+		t.Fatalf("sale movements = %d, want %d", sales, committed)
+	}
+}
+
+func TestSetQuantityBelowReserved(t *testing.T) {
+	db := testDB(t)
+	pid := newProduct(t, db, 10)
+	if _, err := Reserve(db, 1, []Line{{ProductID: pid, Quantity: 6}}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SetQuantity(db, Line{ProductID: pid, Quantity: 5}, nil, ""); !errors.Is(err, ErrBelowReserved) {
+		t.Fatalf("set 5 with 6 reserved: %v, want ErrBelowReserved", err)
+	}
+	if _, err := SetQuantity(db, Line{ProductID: pid, Quantity: -1}, nil, ""); !errors.Is(err, ErrInvalidQuantity) {
+		t.Fatalf("set -1: %v", err)
+	}
+	if s := checkStock(t, db, pid); s.Quantity != 10 {
+		t.Fatalf("rejected SetQuantity changed stock: %+v", s)
+	}
+
+	mv, err := SetQuantity(db, Line{ProductID: pid, Quantity: 6}, nil, "")
+	if err != nil || mv == nil || mv.Delta != -4 || mv.QuantityAfter != 6 {
+		t.Fatalf("set 6: %+v, %v", mv, err)
+	}
+	if mv, err := SetQuantity(db, Line{ProductID: pid, Quantity: 6}, nil, ""); err != nil || mv != nil {
+		t.Fatalf("unchanged quantity should not write: %+v, %v", mv, err)
+	}
+	if n, _ := Available(db, Line{ProductID: pid}); n != 0 {
+		t.Fatalf("available = %d, want 0", n)
+	}
+	checkStock(t, db, pid)
+}
+
+func TestReserveLimits(t *testing.T) {
+	db := testDB(t)
+	pid := newProduct(t, db, 1000)
+
+	if _, err := Reserve(db, 1, []Line{{ProductID: pid, Quantity: MaxLineQuantity + 1}}, time.Minute); !errors.Is(err, ErrLineLimit) {
+		t.Fatalf("line over cap: %v", err)
+	}
+	for i := 0; i < MaxActiveRefs; i++ {
+		if _, err := Reserve(db, 1, []Line{{ProductID: pid, Quantity: 1}}, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Reserve(db, 1, []Line{{ProductID: pid, Quantity: 1}}, time.Minute); !errors.Is(err, ErrReservationLimit) {
+		t.Fatalf("refs over cap: %v", err)
+	}
+	// สมาชิกคนอื่นไม่โดนนับรวม
+	if _, err := Reserve(db, 2, []Line{{ProductID: pid, Quantity: 1}}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if s := checkStock(t, db, pid); s.Reserved != MaxActiveRefs+1 {
+		t.Fatalf("reserved = %d", s.Reserved)
+	}
+}
+
+func TestVariantStockRollsUp(t *testing.T) {
+	db := testDB(t)
+	p := entity.Product{Name: "p", Description: "d", Price: 100}
+	if err := db.Create(&p).Error; err != nil {
+		t.Fatal(err)
+	}
+	v1 := entity.ProductVariant{ProductID: p.ID, Price: 100}
+	v2 := entity.ProductVariant{ProductID: p.ID, Price: 120}
+	if err := db.Create(&[]*entity.ProductVariant{&v1, &v2}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restock(db, Line{ProductID: p.ID, Quantity: 1}, nil, ""); !errors.Is(err, ErrVariantRequired) {
+		t.Fatalf("restock without variant: %v", err)
+	}
+	if _, err := Restock(db, Line{ProductID: p.ID, VariantID: &v1.ID, Quantity: 3}, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restock(db, Line{ProductID: p.ID, VariantID: &v2.ID, Quantity: 4}, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Reserve(db, 1, []Line{{ProductID: p.ID, VariantID: &v1.ID, Quantity: 4}}, time.Minute); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("reserve over variant stock: %v", err)
+	}
+	if _, err := Reserve(db, 1, []Line{{ProductID: p.ID, VariantID: &v2.ID, Quantity: 4}}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	var s stock
+	db.Table("products").Select("quantity, reserved").Where("id = ?", p.ID).Scan(&s)
+	if s.Quantity != 7 || s.Reserved != 4 {
+		t.Fatalf("product totals %+v, want 7/4", s)
+	}
+}
@@ -0,0 +1,276 @@
+package inventory
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"example.com/GROUB/entity"
+	"gorm.io/gorm"
+)
+
+// DefaultTTL = เวลาที่จองไว้ได้ก่อนถูกปล่อยคืนอัตโนมัติ
+const DefaultTTL = 15 * time.Minute
+
+const maxLines = 50
+
+// เพดานการจอง กันสมาชิกคนเดียวกักสต็อกไว้จนคนอื่นซื้อไม่ได้
+const (
+	MaxLineQuantity   = 20  // ชิ้นต่อรายการ
+	MaxActiveQuantity = 100 // ชิ้นที่จองค้างอยู่รวมทุกการจองของสมาชิก 1 คน
+	MaxActiveRefs     = 5   // การจองที่ยังเปิดอยู่พร้อมกันต่อสมาชิก
+)
+
+var (
+	ErrReservationNotFound = errors.New("ไม่พบการจองนี้")
+	ErrReservationClosed   = errors.New("การจองนี้หมดเวลาหรือถูกปิดไปแล้ว")
+	ErrNoLines             = errors.New("ต้องมีสินค้าอย่างน้อย 1 รายการ")
+	ErrLineLimit           = fmt.Errorf("จองได้ไม่เกิน %d ชิ้นต่อรายการ", MaxLineQuantity)
+	ErrReservationLimit    = fmt.Errorf("จองค้างไว้ได้ไม่เกิน %d ชิ้น / %d การจอง ชำระหรือยกเลิกการจองเดิมก่อน", MaxActiveQuantity, MaxActiveRefs)
+)
+
+func newRef() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Reserve จองสินค้าทุกรายการพร้อมกัน (ได้ทั้งหมดหรือไม่ได้เลย)
+// ไม่พอแม้รายการเดียว = rollback ทั้งชุดแล้วคืน *LineError ที่ห่อ ErrInsufficientStock
+func Reserve(db *gorm.DB, memberID uint, lines []Line, ttl time.Duration) ([]entity.StockReservation, error) {
+	if len(lines) == 0 {
+		return nil, ErrNoLines
+	}
+	if len(lines) > maxLines {
+		return nil, ErrInvalidQuantity
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	ref := newRef()
+	expires := time.Now().Add(ttl)
+
+	var out []entity.StockReservation
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, l := range lines {
+			if l.Quantity <= 0 {
+				return &LineError{Line: l, Err: ErrInvalidQuantity}
+			}
+			if l.Quantity > MaxLineQuantity {
+				return &LineError{Line: l, Err: ErrLineLimit}
+			}
+			r := entity.StockReservation{
+				Ref:       ref,
+				MemberID:  memberID,
+				ProductID: l.ProductID,
+				VariantID: l.VariantID,
+				Quantity:  l.Quantity,
+				Status:    entity.ReservationActive,
+				ExpiresAt: expires,
+			}
+			if err := tx.Create(&r).Error; err != nil {
+				return err
+			}
+			if _, err := apply(tx, change{
+				Line: l, Type: entity.StockReserve, ReservedDelta: l.Quantity,
+				ReservationID: &r.ID, ActorID: &memberID,
+			}); err != nil {
+				return &LineError{Line: l, Err: err}
+			}
+			out = append(out, r)
+		}
+		return checkLimits(tx, memberID, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// checkLimits นับหลังใส่แถวของการจองใหม่แล้วใน transaction เดียวกัน เกินเพดาน = rollback ทั้งชุด
+// (นับก่อนใส่จะพลาดกรณีจองพร้อมกันหลายคำขอ)
+func checkLimits(tx *gorm.DB, memberID uint, now time.Time) error {
+	var agg struct {
+		Qty  int64
+		Refs int64
+	}
+	if err := tx.Model(&entity.StockReservation{}).
+		Select("COALESCE(SUM(quantity), 0) AS qty, COUNT(DISTINCT ref) AS refs").
+		Where("member_id = ? AND status = ? AND expires_at > ?", memberID, entity.ReservationActive, now).
+		Scan(&agg).Error; err != nil {
+		return err
+	}
+	if agg.Qty > MaxActiveQuantity || agg.Refs > MaxActiveRefs {
+		return ErrReservationLimit
+	}
+	return nil
+}
+
+// โหลดการจองทั้งชุดของสมาชิกคนนี้
+func loadRef(tx *gorm.DB, memberID uint, ref string) ([]entity.StockReservation, error) {
+	var rs []entity.StockReservation
+	if err := tx.Where("ref = ? AND member_id = ?", ref, memberID).Order("id").Find(&rs).Error; err != nil {
+		return nil, err
+	}
+	if len(rs) == 0 {
+		return nil, ErrReservationNotFound
+	}
+	return rs, nil
+}
+
+// Get คืนการจองทั้งชุด (ไว้ดูสถานะ/เวลาหมดอายุ)
+func Get(db *gorm.DB, memberID uint, ref string) ([]entity.StockReservation, error) {
+	return loadRef(db, memberID, ref)
+}
+
+// closeReservation เปลี่ยนสถานะจาก active แบบมีเงื่อนไข แล้วปล่อย reserved (+ ตัดของออกถ้าเป็นการขาย)
+// คืน false ถ้ารายการนี้ถูกปิดไปแล้ว (เช่น ตัวตั้งเวลาเพิ่งปล่อยไป)
+func closeReservation(tx *gorm.DB, r *entity.StockReservation, status string, actorID *uint) (bool, error) {
+	res := tx.Model(&entity.StockReservation{}).
+		Where("id = ? AND status = ?", r.ID, entity.ReservationActive).
+		Update("status", status)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	ch := change{
+		Line:          Line{ProductID: r.ProductID, VariantID: r.VariantID, Quantity: r.Quantity},
+		Type:          entity.StockRelease,
+		ReservedDelta: -r.Quantity,
+		ReservationID: &r.ID,
+		ActorID:       actorID,
+	}
+	switch status {
+	case entity.ReservationCommitted:
+		ch.Type = entity.StockSale
+		ch.Delta = -r.Quantity
+	case entity.ReservationExpired:
+		ch.Note = "expired"
+	}
+	// ปล่อยการจองของสินค้า/variant ที่ถูกลบไปแล้ว: ไม่มียอดให้คืน ปิดการจองอย่างเดียว
+	if _, err := apply(tx, ch); err != nil && (status == entity.ReservationCommitted || !errors.Is(err, ErrNotFound)) {
+		return false, err
+	}
+	r.Status = status
+	return true, nil
+}
+
+// Commit ยืนยันการซื้อ: ตัดของออกจากคลังตามที่จองไว้ (ต้องยังไม่หมดเวลา)
+func Commit(db *gorm.DB, memberID uint, ref string) ([]entity.StockReservation, error) {
+	var rs []entity.StockReservation
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if rs, err = loadRef(tx, memberID, ref); err != nil {
+			return err
+		}
+		now := time.Now()
+		for i := range rs {
+			if rs[i].Status != entity.ReservationActive || !rs[i].ExpiresAt.After(now) {
+				return ErrReservationClosed
+			}
+			ok, err := closeReservation(tx, &rs[i], entity.ReservationCommitted, &memberID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrReservationClosed
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// Release ยกเลิกการจอง คืนของให้คนอื่นซื้อได้ (รายการที่ปิดไปแล้วข้ามไป)
+func Release(db *gorm.DB, memberID uint, ref string) ([]entity.StockReservation, error) {
+	var rs []entity.StockReservation
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if rs, err = loadRef(tx, memberID, ref); err != nil {
+			return err
+		}
+		for i := range rs {
+			if rs[i].Status != entity.ReservationActive {
+				continue
+			}
+			if _, err := closeReservation(tx, &rs[i], entity.ReservationReleased, &memberID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// ReleaseAll ปล่อยการจองที่ยังค้างทั้งหมดของสมาชิก (ตอนลบบัญชี)
+func ReleaseAll(db *gorm.DB, memberID uint) error {
+	var refs []string
+	if err := db.Model(&entity.StockReservation{}).
+		Where("member_id = ? AND status = ?", memberID, entity.ReservationActive).
+		Distinct().Pluck("ref", &refs).Error; err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if _, err := Release(db, memberID, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpireDue ปล่อยการจองที่หมดเวลาแล้ว คืนจำนวนรายการที่ปล่อย
+func ExpireDue(db *gorm.DB, now time.Time) (int, error) {
+	var due []entity.StockReservation
+	if err := db.Where("status = ? AND expires_at <= ?", entity.ReservationActive, now).
+		Order("id").Limit(500).Find(&due).Error; err != nil {
+		return 0, err
+	}
+	n := 0
+	for i := range due {
+		var released bool
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			released, err = closeReservation(tx, &due[i], entity.ReservationExpired, nil)
+			return err
+		})
+		switch {
+		case err != nil:
+			log.Printf("inventory: expire reservation %d: %v", due[i].ID, err)
+		case released:
+			n++
+		}
+	}
+	return n, nil
+}
+
+// StartExpirer รันตัวปล่อยการจองที่หมดเวลาทุก interval (เรียกครั้งเดียวตอนสตาร์ต)
+func StartExpirer(db *gorm.DB, interval time.Duration) {
+	run := func() {
+		if n, err := ExpireDue(db, time.Now()); err != nil {
+			log.Println("inventory: expire reservations:", err)
+		} else if n > 0 {
+			log.Printf("inventory: released %d expired reservations", n)
+		}
+	}
+	run() // ที่ค้างจากตอนปิด server
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for range t.C {
+			run()
+		}
+	}()
+}
@@ -0,0 +1,69 @@
+// Package mailer ส่งอีเมลผ่าน interface เดียว เลือก driver ด้วย MAIL_DRIVER
+//
+//	MAIL_DRIVER=outbox (ค่าเริ่มต้น) เขียนไฟล์ .eml ลง MAIL_OUTBOX_DIR (default: ./outbox)
+//	MAIL_DRIVER=smtp   ส่งเข้า MAIL_SMTP_ADDR (default: localhost:1025 เช่น MailHog/Mailpit)
+package mailer
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string // text/plain
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+var (
+	mu      sync.Mutex
+	current Mailer
+)
+
+// Default คืน mailer ตาม env (สร้างครั้งแรกที่เรียก)
+func Default() Mailer {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		current = FromEnv()
+	}
+	return current
+}
+
+// SetDefault ใช้สลับ mailer เอง เช่นตอนเทสต์
+func SetDefault(m Mailer) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = m
+}
+
+func FromEnv() Mailer {
+	from := getenv("MAIL_FROM", "no-reply@groub.local")
+
+	switch strings.ToLower(os.Getenv("MAIL_DRIVER")) {
+	case "smtp":
+		return &SMTPMailer{
+			Addr:     getenv("MAIL_SMTP_ADDR", "localhost:1025"),
+			Username: os.Getenv("MAIL_SMTP_USER"),
+			Password: os.Getenv("MAIL_SMTP_PASS"),
+			From:     from,
+		}
+	default:
+		return &OutboxMailer{
+			Dir:  getenv("MAIL_OUTBOX_DIR", "outbox"),
+			From: from,
+		}
+	}
+}
+
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,25 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// OutboxMailer ไม่ส่งจริง เขียนแต่ละฉบับเป็นไฟล์ .eml ไว้เปิดดู/ให้เทสต์อ่าน
+type OutboxMailer struct {
+	Dir  string
+	From string
+}
+
+var unsafeName = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func (m *OutboxMailer) Send(msg Message) error {
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), unsafeName.ReplaceAllString(msg.To, "_"))
+	return os.WriteFile(filepath.Join(m.Dir, name), buildMessage(m.From, msg), 0644)
+}
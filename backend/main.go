@@ -2,9 +2,17 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"example.com/GROUB/config"
+	"example.com/GROUB/controller"
+	"example.com/GROUB/inventory"
+	"example.com/GROUB/publishing"
 	"example.com/GROUB/routes"
+	"example.com/GROUB/search"
+	"example.com/GROUB/storage"
+	"example.com/GROUB/trash"
+	"example.com/GROUB/uploadgc"
 	"github.com/joho/godotenv"
 )
 
@@ -15,11 +23,36 @@ func main() {
 		log.Println("No .env file found, using system env")
 	}
 
+	// ที่เก็บไฟล์อัปโหลด (ตั้งค่าผิด = ไม่เปิด server ดีกว่าอัปโหลดแล้วหาย)
+	if err := storage.Setup(); err != nil {
+		log.Fatal(err)
+	}
+
 	// open connection database
 	config.ConnectionDB()
 	
 	// Generate databases
 	config.SetupDatabase()
+
+	// งาน export ที่ค้างจากรอบก่อน
+	controller.RecoverDataExports()
+
+	// ดัชนีค้นหาสินค้า (ใช้ไม่ได้ก็ยังเปิด server ต่อ แค่ค้นหาไม่ได้)
+	if err := search.Setup(config.DB()); err != nil {
+		log.Println("search setup:", err)
+	}
+
+	// ปล่อยการจองสต็อกที่หมดเวลา
+	inventory.StartExpirer(config.DB(), time.Minute)
+
+	// เปิด/พักการขายโพสต์ที่ตั้งเวลาไว้
+	publishing.StartScheduler(config.DB(), time.Minute)
+
+	// เก็บกวาดไฟล์อัปโหลดที่ไม่มีใครอ้าง (ย้ายเข้า quarantine ก่อน ลบจริงทีหลัง)
+	uploadgc.StartSweeper(config.DB(), 6*time.Hour)
+
+	// ลบถาวรโพสต์ที่อยู่ในถังขยะเกินกำหนด
+	trash.StartPurger(config.DB(), time.Hour)
 	r := routes.SetupRouter()
 	
 	r.Run(":8080")
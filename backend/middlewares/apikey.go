@@ -0,0 +1,66 @@
+// middleware/apikey.go
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// บันทึกเวลาใช้งานล่าสุดไม่ถี่กว่านี้ (ไม่ต้องเขียน DB ทุก request)
+const apiKeyTouchEvery = time.Minute
+
+// APIKey ยืนยันตัวตนด้วย header X-API-Key แล้วเซ็ต context ให้เหมือนผู้ขายล็อกอินอยู่
+// (member_id, role, seller_id, api_key_id) handler เดิมที่อ่าน member_id จึงใช้ต่อได้เลย
+// scope = สิทธิ์ขั้นต่ำที่ route นี้ต้องการ (read / write)
+func APIKey(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := strings.TrimSpace(c.GetHeader("X-API-Key"))
+		if raw == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "missing X-API-Key header"})
+			return
+		}
+
+		sum := sha256.Sum256([]byte(raw))
+		db := config.DB()
+
+		var key entity.SellerAPIKey
+		if err := db.Preload("Seller").
+			Where("key_hash = ? AND revoked_at IS NULL", hex.EncodeToString(sum[:])).
+			First(&key).Error; err != nil || key.Seller.ID == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid api key"})
+			return
+		}
+
+		var m entity.Member
+		if err := db.Select("id", "user_name", "deactivated_at").First(&m, key.Seller.MemberID).Error; err != nil || m.DeactivatedAt != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid api key"})
+			return
+		}
+
+		if scope == entity.APIScopeWrite && key.Scope != entity.APIScopeWrite {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "api key is read-only"})
+			return
+		}
+
+		now := time.Now()
+		if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchEvery || key.LastUsedIP != c.ClientIP() {
+			_ = db.Model(&entity.SellerAPIKey{}).Where("id = ?", key.ID).
+				Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": c.ClientIP()}).Error
+		}
+
+		c.Set("member_id", m.ID)
+		c.Set("username", m.UserName)
+		c.Set("role", entity.RoleSeller)
+		c.Set("seller_id", key.SellerID)
+		c.Set("api_key_id", key.ID)
+
+		c.Next()
+	}
+}
@@ -5,16 +5,21 @@ import (
 	"errors"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"example.com/GROUB/config"
+	"example.com/GROUB/entity"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type AuthClaims struct {
-	MemberID uint   `json:"member_id"`
-	Username string `json:"username"`
+	MemberID  uint   `json:"member_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID uint   `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -53,10 +58,60 @@ func Authz() gin.HandlerFunc {
 			return
 		}
 
+		// session ต้องยังไม่ถูกเพิกถอน (logout / เปลี่ยนรหัส / แบน)
+		var n int64
+		if err := config.DB().Model(&entity.Session{}).
+			Where("id = ? AND member_id = ? AND revoked_at IS NULL AND expires_at > ?", claims.SessionID, claims.MemberID, time.Now()).
+			Count(&n).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "db error"})
+			return
+		}
+		if n == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "session revoked"})
+			return
+		}
+
 		// เซ็ตค่าไว้ให้ handler ใช้
 		c.Set("member_id", claims.MemberID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 
 		c.Next()
 	}
 }
+
+// AuthzOrDev = Authz() ปกติ แต่ถ้าเปิด DEV_AUTH=true จะยอมรับ
+// X-User-Id: <id> หรือ Authorization: Bearer uid:<id> ด้วย (ห้ามเปิดบน production)
+func AuthzOrDev() gin.HandlerFunc {
+	authz := Authz()
+
+	return func(c *gin.Context) {
+		if config.DevAuthEnabled() {
+			if uid, ok := devUserID(c); ok {
+				c.Set("member_id", uid)
+				c.Next()
+				return
+			}
+		}
+		authz(c)
+	}
+}
+
+func devUserID(c *gin.Context) (uint, bool) {
+	raw := strings.TrimSpace(c.GetHeader("X-User-Id"))
+	if raw == "" {
+		parts := strings.Fields(c.GetHeader("Authorization"))
+		if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") && strings.HasPrefix(parts[1], "uid:") {
+			raw = strings.TrimPrefix(parts[1], "uid:")
+		}
+	}
+	if raw == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	return uint(n), true
+}
@@ -0,0 +1,100 @@
+// middleware/ratelimit.go
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// token bucket ต่อ client: เต็มได้ limit ใบ เติมคืนเฉลี่ย limit ใบต่อ window
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type limiter struct {
+	mu      sync.Mutex
+	limit   float64
+	rate    float64 // tokens ต่อวินาที
+	window  time.Duration
+	buckets map[string]*bucket
+}
+
+func newLimiter(limit int, window time.Duration) *limiter {
+	l := &limiter{
+		limit:   float64(limit),
+		rate:    float64(limit) / window.Seconds(),
+		window:  window,
+		buckets: map[string]*bucket{},
+	}
+	go l.cleanup()
+	return l
+}
+
+// คืน (ผ่านไหม, ต้องรออีกกี่วินาทีถ้าไม่ผ่าน)
+func (l *limiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.limit, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.limit, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// ทิ้ง bucket ที่เต็มแล้ว (ไม่ได้ใช้นานเกิน window) กัน map โตไม่หยุด
+func (l *limiter) cleanup() {
+	t := time.NewTicker(l.window)
+	defer t.Stop()
+	for range t.C {
+		l.mu.Lock()
+		for k, b := range l.buckets {
+			if time.Since(b.last) > l.window {
+				delete(l.buckets, k)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// RateLimit จำกัด limit request ต่อ window ต่อ client
+// ถ้าวางต่อจาก Authz() จะนับต่อ member_id ไม่งั้นนับต่อ IP
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	l := newLimiter(limit, window)
+
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if mid, ok := c.Get("member_id"); ok {
+			key = fmt.Sprintf("m:%v", mid)
+		}
+
+		ok, wait := l.allow(key)
+		if !ok {
+			secs := int(math.Ceil(wait.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(secs))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"message":     "too many requests, please slow down",
+				"retry_after": secs,
+			})
+			return
+		}
+		c.Next()
+	}
+}
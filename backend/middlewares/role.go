@@ -0,0 +1,25 @@
+// middleware/role.go
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole ต้องวางต่อจาก Authz() เพราะอ่าน role ที่ Authz เซ็ตไว้ใน context
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		allowed[r] = true
+	}
+
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if !allowed[role] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "forbidden: insufficient role"})
+			return
+		}
+		c.Next()
+	}
+}
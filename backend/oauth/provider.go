@@ -0,0 +1,110 @@
+// Package oauth = ล็อกอินผ่านผู้ให้บริการภายนอก (OAuth2 authorization code + PKCE / OpenID Connect)
+//
+// ตั้งค่าผ่าน env:
+//
+//	OAUTH_PROVIDERS=google,mock
+//	OAUTH_GOOGLE_ISSUER=https://accounts.google.com
+//	OAUTH_GOOGLE_CLIENT_ID=...
+//	OAUTH_GOOGLE_CLIENT_SECRET=...
+//	OAUTH_GOOGLE_REDIRECT_URL=http://localhost:5173/oauth/google/callback
+//	OAUTH_GOOGLE_SCOPES=openid email profile   (ไม่ใส่ = ค่านี้)
+package oauth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownProvider = errors.New("unknown oauth provider")
+
+// Identity = ข้อมูลผู้ใช้ที่ได้จากผู้ให้บริการหลังยืนยันตัวตนสำเร็จ
+type Identity struct {
+	Subject       string // id ถาวรของผู้ใช้ฝั่งผู้ให้บริการ (claim "sub")
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+	Username      string // preferred_username ถ้ามี
+}
+
+type Provider interface {
+	Name() string
+	// AuthCodeURL คืนลิงก์หน้า login ของผู้ให้บริการ (PKCE S256)
+	AuthCodeURL(ctx context.Context, state, codeChallenge, nonce string) (string, error)
+	// Exchange แลก code เป็น token แล้วตรวจ id_token (ลายเซ็น / iss / aud / exp / nonce)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
+
+var (
+	mu        sync.Mutex
+	providers map[string]Provider
+)
+
+// Get คืน provider ตามชื่อ (โหลดจาก env ครั้งแรก)
+func Get(name string) (Provider, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if providers == nil {
+		providers = fromEnv()
+	}
+	p, ok := providers[strings.ToLower(name)]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// Names รายชื่อ provider ที่เปิดใช้
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	if providers == nil {
+		providers = fromEnv()
+	}
+	out := make([]string, 0, len(providers))
+	for n := range providers {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Register ใส่ provider เอง (เช่นตอนเทสต์)
+func Register(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	if providers == nil {
+		providers = fromEnv()
+	}
+	providers[strings.ToLower(p.Name())] = p
+}
+
+func fromEnv() map[string]Provider {
+	out := map[string]Provider{}
+	for _, name := range strings.Split(os.Getenv("OAUTH_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		issuer := os.Getenv(prefix + "ISSUER")
+		clientID := os.Getenv(prefix + "CLIENT_ID")
+		if issuer == "" || clientID == "" {
+			continue
+		}
+		scopes := strings.Fields(os.Getenv(prefix + "SCOPES"))
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "email", "profile"}
+		}
+		out[name] = NewOIDC(name, issuer, clientID,
+			os.Getenv(prefix+"CLIENT_SECRET"),
+			os.Getenv(prefix+"REDIRECT_URL"),
+			scopes)
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+// Package pricing = ประวัติราคาสินค้า (PriceChange) และราคาต่ำสุดย้อนหลัง
+//
+// ราคาถูกแก้ได้หลายทาง (หน้าแก้สินค้า, variant, นำเข้าแคตตาล็อก) แทนที่ทุกที่จะต้องคำนวณ old/new เอง
+// ผู้แก้เรียก Record หลังแก้เสร็จใน transaction เดียวกัน Record เทียบราคาปัจจุบันกับแถวล่าสุดของประวัติ
+// แล้วบันทึกเฉพาะราคาที่เปลี่ยนจริง
+package pricing
+
+import (
+	"time"
+
+	"example.com/GROUB/entity"
+	"gorm.io/gorm"
+)
+
+// Window = ช่วงที่ใช้หาราคาต่ำสุด (lowest_price_30d)
+const Window = 30 * 24 * time.Hour
+
+// Record บันทึกราคาของสินค้า (และทุก variant) ที่ต่างจากแถวล่าสุดในประวัติ
+func Record(tx *gorm.DB, productID uint, actorID *uint, source string) error {
+	var p entity.Product
+	if err := tx.Select("id", "price").First(&p, productID).Error; err != nil {
+		return err
+	}
+	var vars []entity.ProductVariant
+	if err := tx.Select("id", "price").Where("product_id = ?", productID).Order("id").Find(&vars).Error; err != nil {
+		return err
+	}
+
+	// แถวล่าสุดของแต่ละ variant (variant_id NULL = ราคาสินค้า)
+	var last []entity.PriceChange
+	if err := tx.Where("id IN (?)", tx.Model(&entity.PriceChange{}).
+		Select("MAX(id)").Where("product_id = ?", productID).Group("variant_id")).
+		Find(&last).Error; err != nil {
+		return err
+	}
+	prev := make(map[uint]int, len(last)) // key 0 = ราคาสินค้า (variant id ไม่มีทางเป็น 0)
+	for _, r := range last {
+		var k uint
+		if r.VariantID != nil {
+			k = *r.VariantID
+		}
+		prev[k] = r.NewPrice
+	}
+
+	var rows []entity.PriceChange
+	add := func(variantID *uint, price int) {
+		var k uint
+		if variantID != nil {
+			k = *variantID
+		}
+		old, ok := prev[k]
+		if ok && old == price {
+			return
+		}
+		row := entity.PriceChange{ProductID: productID, VariantID: variantID, NewPrice: price, Source: source, ActorID: actorID}
+		if ok {
+			row.OldPrice = &old
+		}
+		rows = append(rows, row)
+	}
+	add(nil, p.Price)
+	for i := range vars {
+		add(&vars[i].ID, vars[i].Price)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+// Lowest = ราคาต่ำสุดของสินค้าในช่วง Window ก่อน now (รวมราคาปัจจุบัน)
+// = min(ราคาที่มีผลตอนต้นช่วง, ทุกราคาที่ตั้งระหว่างช่วง); สินค้าที่ไม่มีประวัติจะไม่อยู่ใน map
+func Lowest(db *gorm.DB, productIDs []uint, now time.Time) (map[uint]int, error) {
+	out := make(map[uint]int, len(productIDs))
+	if len(productIDs) == 0 {
+		return out, nil
+	}
+	start := now.Add(-Window)
+
+	var rows []struct {
+		ProductID uint
+		Price     int
+	}
+	// ราคาที่ตั้งระหว่างช่วง
+	if err := db.Model(&entity.PriceChange{}).
+		Select("product_id, MIN(new_price) AS price").
+		Where("variant_id IS NULL AND product_id IN ? AND created_at >= ? AND created_at <= ?", productIDs, start, now).
+		Group("product_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.ProductID] = r.Price
+	}
+
+	// ราคาที่มีผลอยู่ตอนต้นช่วง = แถวล่าสุดก่อน start
+	rows = rows[:0]
+	if err := db.Model(&entity.PriceChange{}).
+		Select("product_id, new_price AS price").
+		Where("id IN (?)", db.Model(&entity.PriceChange{}).
+			Select("MAX(id)").
+			Where("variant_id IS NULL AND product_id IN ? AND created_at < ?", productIDs, start).
+			Group("product_id")).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		if cur, ok := out[r.ProductID]; !ok || r.Price < cur {
+			out[r.ProductID] = r.Price
+		}
+	}
+	return out, nil
+}
+
+// Backfill ใส่ราคาตั้งต้น (source = baseline) ให้สินค้า/variant ที่ยังไม่มีประวัติเลย
+// เรียกตอนเริ่มระบบ กันการแก้ราคาครั้งแรกหลังอัปเกรดไม่รู้ราคาเดิม
+func Backfill(db *gorm.DB, now time.Time) error {
+	if err := db.Exec(`INSERT INTO price_changes (created_at, product_id, variant_id, new_price, source)
+		SELECT ?, p.id, NULL, p.price, ? FROM products p
+		WHERE p.deleted_at IS NULL AND NOT EXISTS
+			(SELECT 1 FROM price_changes c WHERE c.product_id = p.id AND c.variant_id IS NULL)`,
+		now, entity.PriceSourceBaseline).Error; err != nil {
+		return err
+	}
+	return db.Exec(`INSERT INTO price_changes (created_at, product_id, variant_id, new_price, source)
+		SELECT ?, v.product_id, v.id, v.price, ? FROM product_variants v
+		WHERE v.deleted_at IS NULL AND NOT EXISTS
+			(SELECT 1 FROM price_changes c WHERE c.variant_id = v.id)`,
+		now, entity.PriceSourceBaseline).Error
+}
@@ -0,0 +1,205 @@
+// Package publishing = วงจรชีวิตของโพสต์ขายสินค้า
+// (draft -> scheduled -> published -> paused / archived) และตัวตั้งเวลาเปิด/พักการขาย
+//
+// เปลี่ยนสถานะด้วย UPDATE ที่มีเงื่อนไขสถานะเดิม ถ้ามีคนเปลี่ยนไปก่อน (เช่น scheduler)
+// จะได้ ErrConcurrentUpdate แทนการเขียนทับ
+package publishing
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"example.com/GROUB/entity"
+	"example.com/GROUB/search"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidStatus     = errors.New("สถานะต้องเป็น draft, scheduled, published, paused หรือ archived")
+	ErrInvalidTransition = errors.New("เปลี่ยนสถานะแบบนี้ไม่ได้")
+	ErrPublishAtRequired = errors.New("สถานะ scheduled ต้องระบุ publish_at ที่เป็นเวลาในอนาคต")
+	ErrUnpublishAt       = errors.New("unpublish_at ต้องอยู่หลังเวลาเปิดขาย")
+	ErrConcurrentUpdate  = errors.New("สถานะโพสต์ถูกเปลี่ยนพร้อมกัน ลองใหม่อีกครั้ง")
+)
+
+// สถานะถัดไปที่ไปได้จากแต่ละสถานะ
+var transitions = map[string][]string{
+	entity.PostDraft:     {entity.PostScheduled, entity.PostPublished, entity.PostArchived},
+	entity.PostScheduled: {entity.PostDraft, entity.PostPublished, entity.PostArchived},
+	entity.PostPublished: {entity.PostPaused, entity.PostArchived},
+	entity.PostPaused:    {entity.PostPublished, entity.PostScheduled, entity.PostDraft, entity.PostArchived},
+	entity.PostArchived:  {entity.PostDraft},
+}
+
+// Valid บอกว่าเป็นชื่อสถานะที่รู้จักหรือไม่
+func Valid(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// CanTransition บอกว่าเปลี่ยนจาก from ไป to ได้หรือไม่ (สถานะเดิม = แก้แค่เวลา ได้เสมอ)
+func CanTransition(from, to string) bool {
+	if from == to {
+		return Valid(to)
+	}
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed คืนสถานะถัดไปที่ไปได้ (ให้หน้าเว็บใช้แสดงปุ่ม)
+func Allowed(from string) []string {
+	return append([]string(nil), transitions[from]...)
+}
+
+// Schedule = สถานะที่ต้องการพร้อมเวลาเปิด/พักการขาย
+type Schedule struct {
+	Status      string
+	PublishAt   *time.Time
+	UnpublishAt *time.Time
+}
+
+// Normalize ตรวจค่าแล้วล้างเวลาที่ไม่เกี่ยวกับสถานะนั้นทิ้ง
+func (s Schedule) Normalize(now time.Time) (Schedule, error) {
+	if !Valid(s.Status) {
+		return s, ErrInvalidStatus
+	}
+	switch s.Status {
+	case entity.PostScheduled:
+		if s.PublishAt == nil || !s.PublishAt.After(now) {
+			return s, ErrPublishAtRequired
+		}
+	default:
+		s.PublishAt = nil
+	}
+	if s.Status != entity.PostScheduled && s.Status != entity.PostPublished {
+		s.UnpublishAt = nil
+	}
+	if s.UnpublishAt != nil {
+		start := now
+		if s.PublishAt != nil {
+			start = *s.PublishAt
+		}
+		if !s.UnpublishAt.After(start) {
+			return s, ErrUnpublishAt
+		}
+	}
+	return s, nil
+}
+
+// Fields คือค่าที่ต้องเขียนลงโพสต์สำหรับ schedule นี้
+func (s Schedule) Fields(now time.Time) map[string]interface{} {
+	f := map[string]interface{}{
+		"status":       s.Status,
+		"publish_at":   s.PublishAt,
+		"unpublish_at": s.UnpublishAt,
+	}
+	if s.Status == entity.PostPublished {
+		f["published_at"] = now
+	}
+	return f
+}
+
+// Transition เปลี่ยนสถานะโพสต์ตาม s; post.Status ที่ส่งมาใช้เป็นเงื่อนไขใน UPDATE (ต้องอ่านมาล่าสุด)
+func Transition(db *gorm.DB, post *entity.Post_a_New_Product, s Schedule) error {
+	now := time.Now()
+	s, err := s.Normalize(now)
+	if err != nil {
+		return err
+	}
+	if !CanTransition(post.Status, s.Status) {
+		return ErrInvalidTransition
+	}
+	fields := s.Fields(now)
+	if s.Status == post.Status && post.PublishedAt != nil {
+		// แก้แค่เวลา ไม่นับเป็นการเปิดขายใหม่
+		delete(fields, "published_at")
+	}
+	res := db.Model(&entity.Post_a_New_Product{}).
+		Where("id = ? AND status = ?", post.ID, post.Status).
+		Updates(fields)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConcurrentUpdate
+	}
+	if post.Product_ID != nil {
+		if err := search.IndexProducts(db, *post.Product_ID); err != nil {
+			log.Println("search index:", err)
+		}
+	}
+	return db.First(post, post.ID).Error
+}
+
+// ApplyDue เปิดขายโพสต์ที่ถึงเวลา publish_at และพักโพสต์ที่ถึง unpublish_at
+func ApplyDue(db *gorm.DB, now time.Time) (published, paused int, err error) {
+	var ids []uint
+	step := func(where string, from string, fields map[string]interface{}) (int, error) {
+		var due []entity.Post_a_New_Product
+		if err := db.Select("id", "product_id").
+			Where("status = ? AND "+where+" <= ?", from, now).
+			Find(&due).Error; err != nil {
+			return 0, err
+		}
+		n := 0
+		for _, p := range due {
+			// เงื่อนไขซ้ำใน UPDATE กันชนกับผู้ขายที่เปลี่ยนสถานะพร้อมกัน
+			res := db.Model(&entity.Post_a_New_Product{}).
+				Where("id = ? AND status = ? AND "+where+" <= ?", p.ID, from, now).
+				Updates(fields)
+			if res.Error != nil {
+				return n, res.Error
+			}
+			if res.RowsAffected > 0 {
+				n++
+				if p.Product_ID != nil {
+					ids = append(ids, *p.Product_ID)
+				}
+			}
+		}
+		return n, nil
+	}
+
+	if published, err = step("publish_at", entity.PostScheduled, map[string]interface{}{
+		"status": entity.PostPublished, "publish_at": nil, "published_at": now,
+	}); err != nil {
+		return
+	}
+	// ค้างมานานจนถึงเวลาพักด้วย (เช่น server ปิดอยู่) ก็พักต่อในรอบเดียวกัน
+	if paused, err = step("unpublish_at", entity.PostPublished, map[string]interface{}{
+		"status": entity.PostPaused, "unpublish_at": nil,
+	}); err != nil {
+		return
+	}
+	if len(ids) > 0 {
+		if e := search.IndexProducts(db, ids...); e != nil {
+			log.Println("search index:", e)
+		}
+	}
+	return
+}
+
+// StartScheduler เรียก ApplyDue ทันที 1 ครั้ง แล้วทุก interval ใน goroutine
+func StartScheduler(db *gorm.DB, interval time.Duration) {
+	run := func() {
+		p, u, err := ApplyDue(db, time.Now())
+		if err != nil {
+			log.Println("publishing: apply schedule:", err)
+		} else if p+u > 0 {
+			log.Printf("publishing: published %d, paused %d posts", p, u)
+		}
+	}
+	run() // ที่ถึงเวลาระหว่าง server ปิดอยู่
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for range t.C {
+			run()
+		}
+	}()
+}
@@ -2,9 +2,12 @@
 package routes
 
 import (
+	"log"
 	"time"
 
+	"example.com/GROUB/config"
 	"example.com/GROUB/controller"
+	"example.com/GROUB/entity"
 	mw "example.com/GROUB/middlewares"
 
 	"github.com/gin-contrib/cors"
@@ -14,6 +17,11 @@ import (
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
 
+	// c.ClientIP() ใช้กับ rate limit และ login lockout: เชื่อ X-Forwarded-For เฉพาะจาก proxy ที่ตั้งไว้
+	if err := r.SetTrustedProxies(config.TrustedProxies()); err != nil {
+		log.Fatal("TRUSTED_PROXIES ไม่ถูกต้อง: ", err)
+	}
+
 	// CORS: อนุญาตให้ frontend ส่ง Authorization และ X-User-Id มาได้
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000", "http://localhost:8081"},
@@ -24,63 +32,186 @@ func SetupRouter() *gin.Engine {
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// เสิร์ฟไฟล์อัปโหลด (ใช้ใน DM upload)
-	r.Static("/uploads", "./uploads")
+	// เสิร์ฟไฟล์อัปโหลด (local = จากดิสก์, s3 = redirect ไปลิงก์ presign)
+	r.GET("/uploads/*key", controller.ServeUpload)
+	r.HEAD("/uploads/*key", controller.ServeUpload)
 
 	api := r.Group("/api")
 	{
 		// ----------------- ตัวอย่างเส้นทางของระบบเดิม -----------------
-		api.POST("/register", controller.Register)
-		api.POST("/login", controller.Login)
-		api.POST("/upload-logo", controller.UploadLogo)
-		api.POST("/upload-Product", controller.UploadProductImages)
-		api.POST("/post-Product", controller.CreateProduct)
+		// จำกัดความถี่ต่อ IP (login มีตัวล็อกต่อ username/IP แยกใน controller อีกชั้น)
+		authLimit := mw.RateLimit(10, time.Minute)
+		uploadLimit := mw.RateLimit(30, time.Minute)
+
+		api.POST("/register", mw.RateLimit(20, time.Hour), controller.Register)
+		api.POST("/login", authLimit, controller.Login)
+		api.POST("/login/2fa", authLimit, controller.LoginTwoFactor)
+		api.POST("/token/refresh", controller.RefreshToken)
+		api.POST("/logout", mw.Authz(), controller.Logout)
+		api.POST("/logout-all", mw.Authz(), controller.LogoutAll)
+		api.POST("/password/forgot", authLimit, controller.ForgotPassword)
+		api.POST("/password/reset", controller.ResetPassword)
+		api.POST("/email/verify/request", mw.Authz(), controller.RequestEmailVerification)
+		api.POST("/email/verify", controller.VerifyEmail)
+
+		// ----------------- 2FA (TOTP) -----------------
+		tfa := api.Group("/2fa", mw.Authz())
+		{
+			tfa.POST("/setup", controller.SetupTwoFactor)
+			tfa.POST("/confirm", controller.ConfirmTwoFactor)
+			tfa.POST("/disable", controller.DisableTwoFactor)
+			tfa.POST("/recovery-codes", controller.RegenerateRecoveryCodes)
+		}
+
+		// ----------------- ล็อกอินผ่านผู้ให้บริการภายนอก (OIDC) -----------------
+		api.GET("/oauth/providers", controller.ListOAuthProviders)
+		oauthLimit := mw.RateLimit(30, time.Minute)
+		api.GET("/oauth/:provider/start", oauthLimit, controller.StartOAuth)
+		api.GET("/oauth/:provider/callback", oauthLimit, controller.OAuthCallback)
+		api.POST("/oauth/:provider/link", mw.Authz(), controller.StartOAuthLink)
+		api.DELETE("/oauth/:provider/link", mw.Authz(), controller.UnlinkOAuth)
+		api.GET("/me/identities", mw.Authz(), controller.ListMyIdentities)
+		api.POST("/upload-logo", uploadLimit, controller.UploadLogo)
+		api.POST("/upload-Product", uploadLimit, controller.UploadProductImages)
+		api.POST("/post-Product", mw.Authz(), controller.CreateProduct)
 
 		api.POST("/seller-shop", mw.Authz(), controller.CreateSellerAndShop)
 		api.GET("/current-user", mw.Authz(), controller.CurrentUser)
+		api.PUT("/me", mw.Authz(), controller.UpdateMe)
+		api.PUT("/me/password", mw.Authz(), controller.ChangePassword)
+		api.DELETE("/me", mw.Authz(), controller.DeleteMe)
+		api.POST("/me/export", mw.Authz(), mw.RateLimit(5, time.Hour), controller.RequestDataExport)
+		api.GET("/me/export/:id", mw.Authz(), controller.GetDataExport)
+		api.GET("/me/export/:id/download", mw.Authz(), controller.DownloadDataExport)
 		api.GET("/ListMyProfile", mw.Authz(), controller.ListMyProfile)
 		api.GET("/ListMyPostProducts", mw.Authz(), controller.ListMyPostProducts)
 
 		api.GET("/listAllProducts", controller.ListAllProducts)
+		api.GET("/products/search", mw.RateLimit(60, time.Minute), controller.SearchProducts)
 		api.GET("/listCategory", controller.ListCategoies)
+		api.GET("/categories/tree", controller.GetCategoryTree)
+		api.GET("/categories/:id/path", controller.GetCategoryPath)
+		api.GET("/categories/:id/attributes", controller.ListCategoryAttributes)
 		api.GET("/post-products/:id", mw.Authz(), controller.GetPostProductByID)
 		api.PUT("/UpdateShopProfile", mw.Authz(), controller.UpdateShopProfile)
 		api.PUT("/UpdateProduct", mw.Authz(), controller.UpdateProduct)
 
-		api.POST("/CreateCategory", controller.CreateCategory)
+		admin := []gin.HandlerFunc{mw.Authz(), mw.RequireRole(entity.RoleAdmin)}
+
+		api.POST("/CreateCategory", append(admin, controller.CreateCategory)...)
 		api.GET("/shops/:sellerId/posts", controller.ListPostsBySeller)
 		api.GET("/shops/:sellerId/profile", controller.GetShopProfileBySellerID)
 
 		api.GET("/ListCShopCategory", controller.ListCShopCategory)
-		api.POST("/CreateCShopCategory", controller.CreateCShopCategory)
-		api.PUT("/categories/:id", controller.UpdateCategory)
-		api.DELETE("/categories/:id", controller.DeleteCategory)
-		api.PUT("/shopcategories/:id", controller.UpdateShopCategory)
-		api.DELETE("/shopcategories/:id", controller.DeleteShopCategory)
+		api.POST("/CreateCShopCategory", append(admin, controller.CreateCShopCategory)...)
+		api.PUT("/categories/:id", append(admin, controller.UpdateCategory)...)
+		api.DELETE("/categories/:id", append(admin, controller.DeleteCategory)...)
+		api.PUT("/categories/:id/move", append(admin, controller.MoveCategory)...)
+		api.POST("/categories/:id/attributes", append(admin, controller.CreateCategoryAttribute)...)
+		api.PUT("/categories/:id/attributes/:attrId", append(admin, controller.UpdateCategoryAttribute)...)
+		api.DELETE("/categories/:id/attributes/:attrId", append(admin, controller.DeleteCategoryAttribute)...)
+		api.PUT("/shopcategories/:id", append(admin, controller.UpdateShopCategory)...)
+		api.DELETE("/shopcategories/:id", append(admin, controller.DeleteShopCategory)...)
 
 		api.DELETE("/DeletePost/:id", mw.Authz(), controller.SoftDeletePostWithProductAndImages)
 
 		// ----------------- DiscountCode (ตามที่ขอเพิ่ม) -----------------
-		api.GET("/discountcodes", controller.ListDiscountCodes)
-		api.POST("/discountcodes", controller.CreateDiscountCode)
-		api.PUT("/discountcodes/:id", controller.UpdateDiscountCode)
-		api.DELETE("/discountcodes/:id", controller.DeleteDiscountCode)
+		dc := api.Group("/discountcodes", admin...)
+		{
+			dc.GET("", controller.ListDiscountCodes)
+			dc.POST("", uploadLimit, controller.CreateDiscountCode)
+			dc.PUT("/:id", uploadLimit, controller.UpdateDiscountCode)
+			dc.DELETE("/:id", controller.DeleteDiscountCode)
+		}
+
+		// ----------------- API key ของผู้ขาย -----------------
+		keys := api.Group("/seller/api-keys", mw.Authz())
+		{
+			keys.GET("", controller.ListAPIKeys)
+			keys.POST("", controller.CreateAPIKey)
+			keys.PATCH("/:id", controller.UpdateAPIKey)
+			keys.DELETE("/:id", controller.RevokeAPIKey)
+		}
+
+		// ----------------- นำเข้า/ส่งออกสินค้าทั้งร้าน -----------------
+		api.POST("/seller/products/import", mw.Authz(), uploadLimit, controller.ImportProducts)
+		api.GET("/seller/products/export", mw.Authz(), controller.ExportProducts)
+
+		// ----------------- สถานะโพสต์ (draft / scheduled / published / paused / archived) -----------------
+		api.PUT("/seller/posts/:id/status", mw.Authz(), controller.UpdatePostStatus)
+		api.GET("/seller/posts/trash", mw.Authz(), controller.ListTrashedPosts)
+		api.POST("/seller/posts/:id/restore", mw.Authz(), controller.RestorePost)
+
+		// ----------------- สต็อกสินค้า -----------------
+		stock := api.Group("/seller/products/:id/stock", mw.Authz())
+		{
+			stock.GET("", controller.GetProductStock)
+			stock.POST("", controller.ChangeProductStock)
+			stock.GET("/movements", controller.ListStockMovements)
+		}
+		resv := api.Group("/reservations", mw.Authz())
+		{
+			resv.POST("", mw.RateLimit(30, time.Minute), controller.CreateReservation)
+			resv.GET("/:ref", controller.GetReservation)
+			resv.POST("/:ref/commit", controller.CommitReservation)
+			resv.DELETE("/:ref", controller.ReleaseReservation)
+		}
+		api.GET("/me/orders", mw.Authz(), controller.ListMyOrders)
+
+		// ----------------- ประวัติราคา -----------------
+		api.GET("/products/:id/price-history", controller.GetPriceHistory)
+
+		// ----------------- รีวิวสินค้า -----------------
+		api.GET("/products/:id/reviews", controller.ListProductReviews)
+		api.POST("/products/:id/reviews", mw.Authz(), mw.RateLimit(30, time.Minute), controller.CreateReview)
+		api.POST("/reviews/photos", mw.Authz(), uploadLimit, controller.UploadReviewPhotos)
+		api.PUT("/reviews/:id", mw.Authz(), controller.UpdateReview)
+		api.DELETE("/reviews/:id", mw.Authz(), controller.DeleteReview)
+		api.POST("/reviews/:id/reply", mw.Authz(), controller.ReplyReview)
+
+		// เรียกจากระบบของผู้ขายด้วย X-API-Key (ใช้ handler ชุดเดียวกับหน้าเว็บ)
+		// rate limit วางหลัง APIKey เพื่อให้นับต่อผู้ขาย ไม่ใช่ต่อ IP
+		apiLimit := mw.RateLimit(120, time.Minute)
+		integ := api.Group("/integration")
+		{
+			integ.GET("/products", mw.APIKey(entity.APIScopeRead), apiLimit, controller.ListMyPostProducts)
+			integ.GET("/products/:id", mw.APIKey(entity.APIScopeRead), apiLimit, controller.GetPostProductByID)
+			integ.POST("/products", mw.APIKey(entity.APIScopeWrite), apiLimit, controller.CreateProduct)
+			integ.PUT("/products", mw.APIKey(entity.APIScopeWrite), apiLimit, controller.UpdateProduct)
+			integ.POST("/products/import", mw.APIKey(entity.APIScopeWrite), apiLimit, controller.ImportProducts)
+			integ.GET("/products/export", mw.APIKey(entity.APIScopeRead), apiLimit, controller.ExportProducts)
+			integ.PUT("/posts/:id/status", mw.APIKey(entity.APIScopeWrite), apiLimit, controller.UpdatePostStatus)
+			integ.GET("/products/:id/stock", mw.APIKey(entity.APIScopeRead), apiLimit, controller.GetProductStock)
+			integ.POST("/products/:id/stock", mw.APIKey(entity.APIScopeWrite), apiLimit, controller.ChangeProductStock)
+		}
+
+		// ----------------- Admin -----------------
+		adm := api.Group("/admin", admin...)
+		{
+			adm.PUT("/members/:id/role", controller.SetMemberRole)
+			adm.GET("/audit-events", controller.ListAuditEvents)
+			adm.POST("/search/reindex", controller.RebuildSearchIndex)
+			adm.POST("/uploads/gc", controller.RunUploadGC)
+			adm.GET("/reviews", controller.AdminListReviews)
+			adm.PUT("/reviews/:id/hide", controller.HideReview)
+			adm.DELETE("/reviews/:id/hide", controller.UnhideReview)
+		}
 
 		// ----------------- Messenger (DM) -----------------
-		dm := api.Group("/dm")
+		// ต้องล็อกอิน (dev auth แบบ Bearer uid:<id> ใช้ได้เฉพาะตอนตั้ง DEV_AUTH=true)
+		dm := api.Group("/dm", mw.AuthzOrDev())
 		{
-			// ไม่ครอบ Authz() เพื่อให้ dev auth แบบ Bearer uid:<id> ใช้งานได้
 			dm.POST("/threads/open", controller.OpenThread)
 			dm.GET("/threads", controller.ListThreads)
 			dm.DELETE("/threads/:id", controller.DeleteThread)
 
 			dm.GET("/threads/:id/posts", controller.ListPosts)
-			dm.POST("/threads/:id/posts", controller.CreatePost)
+			dm.POST("/threads/:id/posts", mw.RateLimit(60, time.Minute), controller.CreatePost)
 			dm.PATCH("/posts/:id", controller.EditPost)
 			dm.DELETE("/posts/:id", controller.DeletePost)
 
 			dm.PATCH("/threads/:id/read", controller.MarkRead)
-			dm.POST("/upload", controller.UploadFile)
+			dm.POST("/upload", uploadLimit, controller.UploadFile)
 		}
 	}
 
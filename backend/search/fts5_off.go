@@ -0,0 +1,6 @@
+//go:build !(sqlite_fts5 || fts5)
+
+package search
+
+// build แบบไม่มี FTS5 (ใช้ FTS4 แทน ดู Setup)
+const fts5Built = false
@@ -0,0 +1,6 @@
+//go:build sqlite_fts5 || fts5
+
+package search
+
+// build ด้วย tag ของ go-sqlite3 ที่เปิด FTS5 แล้ว (ดู Setup)
+const fts5Built = true
@@ -0,0 +1,300 @@
+// Package search = ดัชนีค้นหาสินค้าแบบ full-text บน SQLite
+//
+// ใช้ FTS5 (จัดอันดับด้วย bm25) ซึ่ง go-sqlite3 มีให้เฉพาะตอน build ด้วย -tags sqlite_fts5
+// (Makefile ใส่ให้แล้ว: make run / make build / make test)
+// build แบบไม่ใส่ tag จะใช้ FTS4 แล้วคำนวณ BM25 เองจาก matchinfo แทน พร้อม log เตือนทุกครั้งที่เปิด server
+// ใส่ tag แล้วแต่สร้าง FTS5 ไม่ได้ = Setup คืน error (ไม่ถอยไป FTS4 เงียบ ๆ)
+// ข้อความถูกตัด token ฝั่ง Go ก่อนเก็บ (ดู Tokenize) ทั้งสองแบบจึงให้ผลเหมือนกัน
+package search
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"example.com/GROUB/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const table = "product_search"
+
+// น้ำหนักของแต่ละคอลัมน์ในการจัดอันดับ (ชื่อสินค้าสำคัญกว่าคำอธิบาย)
+const (
+	weightName        = 5.0
+	weightDescription = 1.0
+)
+
+var engine string // "fts5" | "fts4" | "" (ยังไม่ setup / ใช้ไม่ได้)
+
+// Engine บอกว่าตอนนี้ใช้ดัชนีแบบไหน
+func Engine() string { return engine }
+
+// Setup สร้างตารางดัชนี (ถ้ายังไม่มี) และ rebuild ถ้าจำนวนแถวไม่ตรงกับสินค้า
+func Setup(db *gorm.DB) error {
+	fts5 := fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(name, description, tokenize="unicode61 remove_diacritics 2 tokenchars '%s'")`,
+		table, ThaiMarks())
+	fts4 := fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts4(name, description, tokenize=simple)`, table)
+
+	// ตารางเดิมสร้างไว้ด้วย engine ไหน ใช้ตามนั้น (สลับ build tag แล้วต้อง rebuild)
+	var sqlText string
+	db.Raw("SELECT sql FROM sqlite_master WHERE name = ?", table).Scan(&sqlText)
+	switch {
+	case strings.Contains(strings.ToLower(sqlText), "fts5"):
+		if err := db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)}).
+			Exec("SELECT * FROM " + table + " LIMIT 0").Error; err != nil {
+			log.Println("search: FTS5 table exists but FTS5 is not compiled in, recreating as FTS4")
+			if err := db.Exec("DROP TABLE " + table).Error; err != nil {
+				return err
+			}
+			sqlText = ""
+		}
+	case sqlText != "" && !strings.Contains(strings.ToLower(sqlText), "fts4"):
+		return fmt.Errorf("search: unexpected table %s", table)
+	}
+
+	// ลอง FTS5 ก่อน build ไม่มี tag = ไม่มี module เป็นเรื่องที่รู้อยู่แล้ว ไม่ต้อง log error ของ SQL
+	quiet := db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+	if err := quiet.Exec(fts5).Error; err == nil {
+		engine = "fts5"
+	} else if fts5Built {
+		return fmt.Errorf("search: built with sqlite_fts5 but cannot create FTS5 index: %w", err)
+	} else if err := db.Exec(fts4).Error; err == nil {
+		engine = "fts4"
+	} else {
+		return fmt.Errorf("search: cannot create index: %w", err)
+	}
+	// ถ้าตารางเดิมเป็นอีกแบบ CREATE IF NOT EXISTS จะไม่ทำอะไร ต้องอ่านจากของจริง
+	db.Raw("SELECT sql FROM sqlite_master WHERE name = ?", table).Scan(&sqlText)
+	if strings.Contains(strings.ToLower(sqlText), "fts4") {
+		engine = "fts4"
+	}
+
+	if engine == "fts4" {
+		log.Println("search: WARNING: FTS5 not available, using FTS4 fallback ranking; build with `make build` or `go build -tags sqlite_fts5`")
+	}
+
+	var indexed, live int64
+	db.Raw("SELECT COUNT(*) FROM " + table).Scan(&indexed)
+	liveProducts(db).Count(&live)
+	if indexed != live {
+		return Rebuild(db)
+	}
+	return nil
+}
+
+// สินค้าที่ควรค้นเจอ: ตัวสินค้ายังไม่ถูกลบ และมีโพสต์ที่เปิดขายอยู่ (published)
+func liveProducts(db *gorm.DB) *gorm.DB {
+	return db.Model(&entity.Product{}).
+		Where("EXISTS (SELECT 1 FROM post_a_new_products p WHERE p.product_id = products.id AND p.deleted_at IS NULL AND p.status = ?)",
+			entity.PostPublished)
+}
+
+// Rebuild ล้างแล้วสร้างดัชนีใหม่ทั้งหมด
+func Rebuild(db *gorm.DB) error {
+	if engine == "" {
+		return nil
+	}
+	var products []entity.Product
+	if err := liveProducts(db).Select("id", "name", "description").Find(&products).Error; err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+			return err
+		}
+		for _, p := range products {
+			if err := insert(tx, p); err != nil {
+				return err
+			}
+		}
+		log.Printf("search: indexed %d products (%s)", len(products), engine)
+		return nil
+	})
+}
+
+func insert(tx *gorm.DB, p entity.Product) error {
+	return tx.Exec("INSERT INTO "+table+" (rowid, name, description) VALUES (?, ?, ?)",
+		p.ID, Tokenize(p.Name), Tokenize(p.Description)).Error
+}
+
+// IndexProducts อ่านสินค้าจาก DB แล้วอัปเดตดัชนี (ถูกลบ / ไม่มีโพสต์ที่เปิดขายแล้ว = เอาออก)
+// เรียกหลังสร้าง / แก้ / ลบสินค้าหรือโพสต์ ส่ง tx มาได้เพื่อให้อยู่ใน transaction เดียวกัน
+func IndexProducts(db *gorm.DB, ids ...uint) error {
+	if engine == "" || len(ids) == 0 {
+		return nil
+	}
+	var products []entity.Product
+	if err := liveProducts(db).Select("id", "name", "description").Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return err
+	}
+	if err := db.Exec("DELETE FROM "+table+" WHERE rowid IN ?", ids).Error; err != nil {
+		return err
+	}
+	for _, p := range products {
+		if err := insert(db, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hit = ผลค้นหา 1 รายการ (Score มากกว่า = เกี่ยวข้องกว่า)
+type Hit struct {
+	ProductID uint
+	Score     float64
+}
+
+// Search คืนผลที่ตรงกับ q เรียงตามความเกี่ยวข้อง พร้อมจำนวนทั้งหมด
+func Search(db *gorm.DB, q string, limit, offset int) ([]Hit, int64, error) {
+	match := matchQuery(q)
+	if match == "" || engine == "" {
+		return nil, 0, nil
+	}
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM "+table+" WHERE "+table+" MATCH ?", match).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	if engine == "fts5" {
+		var rows []struct {
+			ID    uint
+			Score float64
+		}
+		err := db.Raw(fmt.Sprintf(`SELECT rowid AS id, -bm25(%s, %g, %g) AS score FROM %s
+			WHERE %s MATCH ? ORDER BY score DESC, rowid DESC LIMIT ? OFFSET ?`,
+			table, weightName, weightDescription, table, table), match, limit, offset).Scan(&rows).Error
+		if err != nil {
+			return nil, 0, err
+		}
+		hits := make([]Hit, len(rows))
+		for i, r := range rows {
+			hits[i] = Hit{ProductID: r.ID, Score: r.Score}
+		}
+		return hits, total, nil
+	}
+
+	// FTS4: ไม่มี bm25 ในตัว ดึงสถิติจาก matchinfo มาคิดเอง แล้วแบ่งหน้าใน Go
+	// idf ของ FTS5 นับแถวที่มี phrase ในคอลัมน์ไหนก็ได้ แต่ matchinfo นับแยกคอลัมน์ จึงนับเองทีละ phrase
+	phrases := matchPhrases(q)
+	docs := make([]float64, len(phrases))
+	for i, ph := range phrases {
+		var n int64
+		if err := db.Raw("SELECT COUNT(*) FROM "+table+" WHERE "+table+" MATCH ?", ph).Scan(&n).Error; err != nil {
+			return nil, 0, err
+		}
+		docs[i] = float64(n)
+	}
+	var rows []struct {
+		ID   uint
+		Info []byte
+	}
+	if err := db.Raw("SELECT docid AS id, matchinfo("+table+", 'pcnalx') AS info FROM "+table+" WHERE "+table+" MATCH ?", match).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	hits := make([]Hit, 0, len(rows))
+	for _, r := range rows {
+		hits = append(hits, Hit{ProductID: r.ID, Score: bm25(r.Info, []float64{weightName, weightDescription}, docs)})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ProductID > hits[j].ProductID
+	})
+	if offset >= len(hits) {
+		return nil, total, nil
+	}
+	hits = hits[offset:]
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, total, nil
+}
+
+// bm25 จาก matchinfo 'pcnalx' ของ FTS4 (สูตรเดียวกับ bm25() ของ FTS5, k1=1.2 b=0.75)
+// docs[i] = จำนวนแถวที่มี phrase i (ไม่ส่ง = ใช้จำนวนแยกคอลัมน์จาก matchinfo)
+func bm25(info []byte, weights []float64, docs []float64) float64 {
+	const k1, b = 1.2, 0.75
+	if len(info) < 12 {
+		return 0
+	}
+	u := make([]uint32, len(info)/4)
+	for i := range u {
+		u[i] = binary.NativeEndian.Uint32(info[i*4:])
+	}
+	p, c := int(u[0]), int(u[1])
+	n := float64(u[2])
+	avg := u[3 : 3+c]
+	length := u[3+c : 3+2*c]
+	x := u[3+2*c:]
+	if len(x) < 3*p*c {
+		return 0
+	}
+
+	score := 0.0
+	for i := 0; i < p; i++ {
+		for j := 0; j < c; j++ {
+			k := 3 * (i*c + j)
+			tf, nd := float64(x[k]), float64(x[k+2])
+			if tf == 0 {
+				continue
+			}
+			if i < len(docs) {
+				nd = docs[i]
+			}
+			idf := math.Log((n - nd + 0.5) / (nd + 0.5))
+			if idf <= 0 {
+				idf = 1e-6
+			}
+			a := float64(avg[j])
+			if a == 0 {
+				a = 1
+			}
+			w := 1.0
+			if j < len(weights) {
+				w = weights[j]
+			}
+			score += w * idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(length[j])/a))
+		}
+	}
+	return score
+}
+
+// matchQuery แปลงคำค้นเป็น MATCH expression: ทุกคำต้องเจอ (AND)
+// คำไทยเป็น phrase ของ bigram ที่ต่อกัน คำภาษาอื่น/คำไทยตัวเดียวค้นแบบ prefix
+func matchQuery(q string) string {
+	return strings.Join(matchPhrases(q), " ")
+}
+
+// matchPhrases = แต่ละคำของ matchQuery แยกกัน (ลำดับเดียวกับ phrase ใน matchinfo)
+func matchPhrases(q string) []string {
+	runs := splitRuns(q)
+	parts := make([]string, 0, len(runs))
+	for _, r := range runs {
+		toks := termTokens(r)
+		if len(toks) == 0 {
+			continue
+		}
+		// token ไม่มี " อยู่แล้ว (splitRuns เหลือแต่ตัวอักษร/ตัวเลข) ครอบด้วย " ได้เลย
+		prefix := !r.thai || len(thaiClusters(r.text)) == 1
+		phrase := strings.Join(toks, " ")
+		switch {
+		case prefix && engine == "fts5":
+			parts = append(parts, `"`+phrase+`"*`)
+		case prefix:
+			parts = append(parts, `"`+phrase+`*"`)
+		default:
+			parts = append(parts, `"`+phrase+`"`)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,168 @@
+package search
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"example.com/GROUB/entity"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ทดสอบกับ engine ที่ build มา (ไม่มี tag = fts4, make test = fts5) ผลต้องเหมือนกันทั้งสองแบบ
+func setupIndex(t *testing.T, products map[uint][2]string, status map[uint]string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&entity.Product{}, &entity.Post_a_New_Product{}); err != nil {
+		t.Fatal(err)
+	}
+	for id, nd := range products {
+		if err := db.Create(&entity.Product{Model: gorm.Model{ID: id}, Name: nd[0], Description: nd[1]}).Error; err != nil {
+			t.Fatal(err)
+		}
+		pid := id
+		st := entity.PostPublished
+		if s, ok := status[id]; ok {
+			st = s
+		}
+		if err := db.Create(&entity.Post_a_New_Product{Product_ID: &pid, Status: st}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Setup(db); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("engine = %s", Engine())
+	return db
+}
+
+func hitIDs(hits []Hit) []uint {
+	out := make([]uint, len(hits))
+	for i, h := range hits {
+		out[i] = h.ProductID
+	}
+	return out
+}
+
+func TestSearchRanking(t *testing.T) {
+	db := setupIndex(t, map[uint][2]string{
+		1: {"กระเป๋าผ้า", "ใส่ของได้เยอะ"},
+		2: {"รองเท้าผ้าใบ", "ใส่สบาย เข้ากับกระเป๋าทุกใบ"},
+		3: {"กระเป๋าหนังแท้", "หนังวัว กระเป๋าใบใหญ่ กระเป๋าสตางค์แถม"},
+		4: {"เสื้อยืด", "ผ้าฝ้าย"},
+		5: {"iPhone 15 case", "เคสกันกระแทก"},
+		6: {"กระเป๋าร่าง", "ยังไม่เปิดขาย"},
+	}, map[uint]string{6: entity.PostDraft})
+
+	t.Run("name outranks description", func(t *testing.T) {
+		hits, total, err := Search(db, "กระเป๋า", 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 3 || len(hits) != 3 {
+			t.Fatalf("hits = %v total = %d, want 3", hitIDs(hits), total)
+		}
+		// 1, 3 มีในชื่อ (3 มีในคำอธิบายซ้ำอีก) มาก่อน 2 ที่มีแค่ในคำอธิบาย
+		if got := hitIDs(hits); got[2] != 2 || (got[0] != 3 && got[0] != 1) {
+			t.Fatalf("order = %v, want product 2 last", got)
+		}
+		for i := 1; i < len(hits); i++ {
+			if hits[i].Score > hits[i-1].Score {
+				t.Fatalf("scores not descending: %+v", hits)
+			}
+		}
+	})
+
+	t.Run("thai substring and AND", func(t *testing.T) {
+		hits, _, err := Search(db, "ผ้า", 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) != 3 { // 1, 2 ในชื่อ, 4 ในคำอธิบาย
+			t.Fatalf("ผ้า: %v", hitIDs(hits))
+		}
+		if hits[len(hits)-1].ProductID != 4 {
+			t.Fatalf("ผ้า: %v, want description-only match last", hitIDs(hits))
+		}
+		hits, _, _ = Search(db, "กระเป๋า หนัง", 10, 0)
+		if got := hitIDs(hits); len(got) != 1 || got[0] != 3 {
+			t.Fatalf("กระเป๋า หนัง: %v, want [3]", got)
+		}
+	})
+
+	t.Run("prefix and case", func(t *testing.T) {
+		hits, _, _ := Search(db, "IPH", 10, 0)
+		if got := hitIDs(hits); len(got) != 1 || got[0] != 5 {
+			t.Fatalf("IPH: %v, want [5]", got)
+		}
+	})
+
+	t.Run("unpublished posts are not indexed", func(t *testing.T) {
+		hits, _, _ := Search(db, "ร่าง", 10, 0)
+		if len(hits) != 0 {
+			t.Fatalf("draft found: %v", hitIDs(hits))
+		}
+	})
+
+	t.Run("paging keeps order and total", func(t *testing.T) {
+		all, _, _ := Search(db, "กระเป๋า", 10, 0)
+		page, total, _ := Search(db, "กระเป๋า", 1, 1)
+		if total != 3 || len(page) != 1 || page[0].ProductID != all[1].ProductID {
+			t.Fatalf("page = %v total = %d, all = %v", hitIDs(page), total, hitIDs(all))
+		}
+	})
+
+	t.Run("reindex after edit", func(t *testing.T) {
+		if err := db.Model(&entity.Product{}).Where("id = ?", 4).Update("name", "กระเป๋าเป้").Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := IndexProducts(db, 4); err != nil {
+			t.Fatal(err)
+		}
+		if _, total, _ := Search(db, "กระเป๋า", 10, 0); total != 4 {
+			t.Fatalf("total after edit = %d, want 4", total)
+		}
+		if hits, _, _ := Search(db, "เสื้อยืด", 10, 0); len(hits) != 0 {
+			t.Fatalf("old name still indexed: %v", hitIDs(hits))
+		}
+	})
+}
+
+// bm25 ที่คิดเองจาก matchinfo ต้องให้คะแนนคำที่หายากกว่า / เอกสารสั้นกว่าสูงกว่า
+func TestBM25Matchinfo(t *testing.T) {
+	// p=1 phrase, c=1 column: n, avgLen, docLen, (hits this row, hits all rows, docs with hit)
+	info := func(n, avg, length, tf, docs uint32) []byte {
+		u := []uint32{1, 1, n, avg, length, tf, tf, docs}
+		b := make([]byte, 4*len(u))
+		for i, v := range u {
+			binary.NativeEndian.PutUint32(b[i*4:], v)
+		}
+		return b
+	}
+	w := []float64{1}
+	rare := bm25(info(100, 10, 10, 1, 2), w, nil)
+	common := bm25(info(100, 10, 10, 1, 40), w, nil)
+	short := bm25(info(100, 10, 5, 1, 2), w, nil)
+	long := bm25(info(100, 10, 40, 1, 2), w, nil)
+	more := bm25(info(100, 10, 10, 3, 2), w, nil)
+	if !(rare > common && short > long && more > rare && rare > 0) {
+		t.Fatalf("rare=%g common=%g short=%g long=%g more=%g", rare, common, short, long, more)
+	}
+	if bm25(nil, w, nil) != 0 || bm25(info(100, 10, 10, 0, 2), w, nil) != 0 {
+		t.Fatal("empty matchinfo should score 0")
+	}
+	// จำนวนแถวที่มี phrase (นับทุกคอลัมน์) แทนค่าแยกคอลัมน์ได้
+	if got := bm25(info(100, 10, 10, 1, 2), w, []float64{40}); got != common {
+		t.Fatalf("docs override: %g, want %g", got, common)
+	}
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	markOpen  = "<mark>"
+	markClose = "</mark>"
+)
+
+type span struct{ start, end int } // byte offset ในข้อความเดิม
+
+// หาตำแหน่งที่คำค้นปรากฏ (ไม่สนตัวพิมพ์เล็ก/ใหญ่) รวมช่วงที่ทับกัน
+func findSpans(text string, terms []string) []span {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// ToLower เปลี่ยนความยาว byte (อักษรพิเศษบางตัว) -> เทียบตรง ๆ แทน
+		lower = text
+	}
+	var spans []span
+	for _, t := range terms {
+		if t == "" {
+			continue
+		}
+		for i := 0; ; {
+			j := strings.Index(lower[i:], t)
+			if j < 0 {
+				break
+			}
+			spans = append(spans, span{i + j, i + j + len(t)})
+			i += j + len(t)
+		}
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+	// เรียงแล้วรวมช่วงที่ทับกัน
+	for i := 1; i < len(spans); i++ {
+		for k := i; k > 0 && spans[k].start < spans[k-1].start; k-- {
+			spans[k], spans[k-1] = spans[k-1], spans[k]
+		}
+	}
+	out := spans[:1]
+	for _, s := range spans[1:] {
+		last := &out[len(out)-1]
+		if s.start <= last.end {
+			if s.end > last.end {
+				last.end = s.end
+			}
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func markup(text string, spans []span, from, to int) string {
+	var b strings.Builder
+	pos := from
+	for _, s := range spans {
+		if s.end <= from || s.start >= to {
+			continue
+		}
+		st, en := max(s.start, from), min(s.end, to)
+		b.WriteString(html.EscapeString(text[pos:st]))
+		b.WriteString(markOpen)
+		b.WriteString(html.EscapeString(text[st:en]))
+		b.WriteString(markClose)
+		pos = en
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	return b.String()
+}
+
+// Highlight ครอบคำค้นทุกจุดด้วย <mark> (ข้อความส่วนอื่น escape HTML แล้ว)
+func Highlight(text string, terms []string) string {
+	return markup(text, findSpans(text, terms), 0, len(text))
+}
+
+// Snippet ตัดข้อความรอบคำค้นที่เจอครั้งแรกให้ยาวประมาณ width ตัวอักษร แล้ว highlight
+// ไม่เจอคำค้นเลย = เอาช่วงต้นข้อความ
+func Snippet(text string, terms []string, width int) string {
+	spans := findSpans(text, terms)
+	if utf8.RuneCountInString(text) <= width {
+		return markup(text, spans, 0, len(text))
+	}
+
+	center := 0
+	if len(spans) > 0 {
+		center = spans[0].start
+	}
+	// ถอยไปทางซ้ายราว 1/3 ของ width แล้วนับไปทางขวาจนครบ width (นับเป็น rune ไม่ตัดกลางตัวอักษร)
+	from := center
+	for n := 0; n < width/3 && from > 0; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:from])
+		from -= size
+	}
+	to := from
+	for n := 0; n < width && to < len(text); n++ {
+		_, size := utf8.DecodeRuneInString(text[to:])
+		to += size
+	}
+	// อย่าตัดกลางคำที่ highlight
+	for _, s := range spans {
+		if s.start < to && s.end > to {
+			to = s.end
+		}
+	}
+
+	out := markup(text, spans, from, to)
+	if from > 0 {
+		out = "…" + out
+	}
+	if to < len(text) {
+		out += "…"
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ภาษาไทยไม่มีช่องว่างระหว่างคำ จึงตัดเป็น bigram ของ "กลุ่มตัวอักษร" (พยัญชนะ + สระบน/ล่าง + วรรณยุกต์)
+// เช่น "เสื้อผ้า" -> cluster [เ สื้ อ ผ้ า] -> token "เสื้ สื้อ อผ้ ผ้า"
+// ค้นหาคำใดก็ตามที่เป็น substring ได้ด้วย phrase ของ bigram ที่ต่อกัน
+// ส่วนภาษาอื่นตัดตามตัวอักษร/ตัวเลขตามปกติ และแปลงเป็นตัวพิมพ์เล็ก
+
+func isThai(r rune) bool { return r >= 0x0E00 && r <= 0x0E7F }
+
+// สระบน/ล่าง วรรณยุกต์ และเครื่องหมายที่ต้องเกาะกับตัวหน้า
+func isThaiMark(r rune) bool {
+	return r == 0x0E31 || (r >= 0x0E34 && r <= 0x0E3A) || (r >= 0x0E47 && r <= 0x0E4E)
+}
+
+// ThaiMarks ต้องบอก tokenizer ของ FTS5 ว่าเป็นส่วนหนึ่งของคำ (ค่าเริ่มต้นถือเป็นตัวคั่น)
+func ThaiMarks() string {
+	var b strings.Builder
+	for r := rune(0x0E00); r <= 0x0E7F; r++ {
+		if isThaiMark(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+type run struct {
+	text string
+	thai bool
+}
+
+// แยกข้อความเป็นช่วง ๆ ที่เป็นคำ (ไทย / ไม่ใช่ไทย) ทิ้งตัวคั่นทั้งหมด
+func splitRuns(s string) []run {
+	var out []run
+	var cur strings.Builder
+	curThai := false
+	flush := func() {
+		if cur.Len() > 0 {
+			out = append(out, run{text: cur.String(), thai: curThai})
+			cur.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case isThai(r) && (unicode.IsLetter(r) || unicode.IsDigit(r) || isThaiMark(r)):
+			if !curThai {
+				flush()
+				curThai = true
+			}
+			cur.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			if curThai {
+				flush()
+				curThai = false
+			}
+			cur.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return out
+}
+
+func thaiClusters(s string) []string {
+	var out []string
+	for _, r := range s {
+		if isThaiMark(r) && len(out) > 0 {
+			out[len(out)-1] += string(r)
+			continue
+		}
+		out = append(out, string(r))
+	}
+	return out
+}
+
+func bigrams(cl []string) []string {
+	if len(cl) == 1 {
+		return cl
+	}
+	out := make([]string, 0, len(cl)-1)
+	for i := 0; i+1 < len(cl); i++ {
+		out = append(out, cl[i]+cl[i+1])
+	}
+	return out
+}
+
+// Tokenize แปลงข้อความเป็น token คั่นด้วยช่องว่าง (เก็บลงดัชนี)
+func Tokenize(s string) string {
+	var toks []string
+	for _, r := range splitRuns(s) {
+		if r.thai {
+			toks = append(toks, bigrams(thaiClusters(r.text))...)
+		} else {
+			toks = append(toks, r.text)
+		}
+	}
+	return strings.Join(toks, " ")
+}
+
+// Terms = คำค้นแบบที่ผู้ใช้พิมพ์ (ใช้ทำ highlight)
+func Terms(q string) []string {
+	var out []string
+	for _, r := range splitRuns(q) {
+		out = append(out, r.text)
+	}
+	return out
+}
+
+// term หนึ่งคำ -> token ที่ต้องเจอติดกันตามลำดับ
+func termTokens(t run) []string {
+	if t.thai {
+		return bigrams(thaiClusters(t.text))
+	}
+	return []string{t.text}
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"เสื้อผ้า", "เสื้ สื้อ อผ้ ผ้า"},
+		{"iPhone 15 Pro!", "iphone 15 pro"},
+		{"เสื้อ XL", "เสื้ สื้อ xl"},
+		{"ก", "ก"},     // ไทยตัวเดียวเก็บทั้งตัว
+		{"น้ำ", "น้ำ"}, // [น้ ำ] = bigram เดียว
+		{"ปากกา,ดินสอ", "ปา าก กก กา ดิน นส สอ"}, // ตัวคั่นตัดเป็นคนละคำ ไม่ทำ bigram ข้ามกัน
+		{"Café-au-lait", "café au lait"},
+		{"  ...  ", ""},
+	}
+	for _, tc := range cases {
+		if got := Tokenize(tc.in); got != tc.want {
+			t.Errorf("Tokenize(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestThaiClusters(t *testing.T) {
+	got := thaiClusters("เสื้อผ้า")
+	want := []string{"เ", "สื้", "อ", "ผ้", "า"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := Terms("เสื้อ สีแดง, Size-XL")
+	want := []string{"เสื้อ", "สีแดง", "size", "xl"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestMatchQuery(t *testing.T) {
+	defer func(e string) { engine = e }(engine)
+	cases := []struct{ q, fts5, fts4 string }{
+		// คำไทยหลายตัว = phrase ของ bigram (ไม่ใช่ prefix)
+		{"เสื้อ", `"เสื้ สื้อ"`, `"เสื้ สื้อ"`},
+		// คำอื่น/ไทยตัวเดียว = prefix (ไวยากรณ์ของ FTS5 กับ FTS4 ต่างกัน)
+		{"iph", `"iph"*`, `"iph*"`},
+		{"ก", `"ก"*`, `"ก*"`},
+		// ทุกคำต้องเจอ (AND)
+		{"เสื้อ xl", `"เสื้ สื้อ" "xl"*`, `"เสื้ สื้อ" "xl*"`},
+		// ตัวคั่น/เครื่องหมายคำพูดหายไปหมด ไม่หลุดเข้า MATCH
+		{`"a" OR b`, `"a"* "or"* "b"*`, `"a*" "or*" "b*"`},
+		{"!!", "", ""},
+	}
+	for _, tc := range cases {
+		engine = "fts5"
+		if got := matchQuery(tc.q); got != tc.fts5 {
+			t.Errorf("fts5 %q: got %s, want %s", tc.q, got, tc.fts5)
+		}
+		engine = "fts4"
+		if got := matchQuery(tc.q); got != tc.fts4 {
+			t.Errorf("fts4 %q: got %s, want %s", tc.q, got, tc.fts4)
+		}
+	}
+}
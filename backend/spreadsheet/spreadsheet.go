@@ -0,0 +1,110 @@
+// Package spreadsheet อ่าน/เขียนตารางแบบง่าย (CSV / XLSX) เป็น [][]string
+//
+// XLSX เขียนเองด้วย archive/zip + encoding/xml (ไม่ต้องพึ่ง lib ภายนอก)
+// รองรับเฉพาะ sheet แรก ค่าในเซลล์อ่านเป็นข้อความตามที่เก็บ ไม่สนสูตร/รูปแบบ
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("รองรับเฉพาะไฟล์ .csv และ .xlsx")
+
+// Excel บันทึก CSV เป็น UTF-8 พร้อม BOM; ใส่ไว้ตอนเขียนเพื่อให้เปิดภาษาไทยได้ถูก
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// FormatFromName เดารูปแบบจากนามสกุลไฟล์
+func FormatFromName(name string) (string, error) {
+	n := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(n, ".csv"):
+		return FormatCSV, nil
+	case strings.HasSuffix(n, ".xlsx"):
+		return FormatXLSX, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Limits จำนวนแถว (รวม header) และคอลัมน์สูงสุดที่ยอมอ่าน
+// CSV ใหญ่ได้ไม่เกินขนาดไฟล์อยู่แล้ว แต่ XLSX ระบุเลขแถว/คอลัมน์เองได้ จึงต้องจำกัดตอนอ่าน
+type Limits struct {
+	Rows int
+	Cols int
+}
+
+// Read อ่านไฟล์ทั้งไฟล์ (ตัดแถวว่างท้ายตารางทิ้ง)
+func Read(format string, data []byte, lim Limits) ([][]string, error) {
+	var rows [][]string
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(data)
+	case FormatXLSX:
+		rows, err = readXLSX(data, lim)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	for len(rows) > 0 && isBlank(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	return rows, nil
+}
+
+// Write เขียนตารางเป็นไฟล์ตาม format
+func Write(format string, w io.Writer, rows [][]string) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, rows)
+	case FormatXLSX:
+		return writeXLSX(w, rows)
+	}
+	return ErrUnsupportedFormat
+}
+
+// ContentType ของแต่ละ format (ใช้ตอนส่งไฟล์)
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func isBlank(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1 // จำนวนคอลัมน์ไม่เท่ากันได้ (ให้ผู้เรียกตรวจเอง)
+	r.LazyQuotes = true
+	return r.ReadAll()
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	if _, err := w.Write(utf8BOM); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
@@ -0,0 +1,59 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"sku", "ชื่อ", "ราคา", "จำนวน"},
+		{"A-001", "เสื้อยืด \"ลายไทย\"", "199.50", "10"},
+		{"007", "ขึ้นต้นด้วย 0 ต้องไม่หาย", "0", "12345678901234567890"},
+		{"B,2", "หลายบรรทัด\nบรรทัดสอง", "<b>&amp;</b>", "-3"},
+	}
+	for _, format := range []string{FormatCSV, FormatXLSX} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(format, &buf, rows); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Read(format, buf.Bytes(), testLimits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, rows) {
+				t.Fatalf("got %q\nwant %q", got, rows)
+			}
+		})
+	}
+}
+
+func TestReadTrimsTrailingBlankRows(t *testing.T) {
+	data := []byte("\xEF\xBB\xBFa,b\n1,2\n,\n \n")
+	got, err := Read(FormatCSV, data, testLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"a", "b"}, {"1", "2"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatFromName(t *testing.T) {
+	for name, want := range map[string]string{"a.CSV": FormatCSV, "สินค้า.xlsx": FormatXLSX} {
+		if got, err := FormatFromName(name); err != nil || got != want {
+			t.Errorf("%s: %q %v", name, got, err)
+		}
+	}
+	for _, name := range []string{"a.xls", "a.csv.exe", "xlsx"} {
+		if _, err := FormatFromName(name); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+	if _, err := Read("xls", nil, testLimits); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Read xls: err = %v", err)
+	}
+}
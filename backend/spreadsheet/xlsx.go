@@ -0,0 +1,299 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ขนาดสูงสุดของแต่ละไฟล์ใน zip หลังแตก (กัน zip bomb)
+const maxPartSize = 32 << 20
+
+var errBadXLSX = errors.New("ไฟล์ .xlsx ไม่ถูกต้อง")
+
+func readPart(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(io.LimitReader(rc, maxPartSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > maxPartSize {
+			return nil, errors.New("ไฟล์ .xlsx ใหญ่เกินไป")
+		}
+		return b, nil
+	}
+	return nil, nil
+}
+
+// หา path ของ sheet แรกจาก workbook.xml + relationships
+func firstSheetPath(zr *zip.Reader) (string, error) {
+	wb, err := readPart(zr, "xl/workbook.xml")
+	if err != nil || wb == nil {
+		return "", errBadXLSX
+	}
+	var book struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(wb, &book); err != nil || len(book.Sheets) == 0 {
+		return "", errBadXLSX
+	}
+	rels, err := readPart(zr, "xl/_rels/workbook.xml.rels")
+	if err != nil {
+		return "", err
+	}
+	var rs struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if rels != nil {
+		_ = xml.Unmarshal(rels, &rs)
+	}
+	for _, r := range rs.Rels {
+		if r.ID == book.Sheets[0].RID {
+			if strings.HasPrefix(r.Target, "/") {
+				return strings.TrimPrefix(r.Target, "/"), nil
+			}
+			return path.Join("xl", r.Target), nil
+		}
+	}
+	return "xl/worksheets/sheet1.xml", nil
+}
+
+// ข้อความใน <si> หรือ <is> (rich text = หลาย <r><t>)
+type richText struct {
+	T  string `xml:"t"`
+	Rs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (r richText) String() string {
+	if len(r.Rs) == 0 {
+		return r.T
+	}
+	var b strings.Builder
+	b.WriteString(r.T)
+	for _, x := range r.Rs {
+		b.WriteString(x.T)
+	}
+	return b.String()
+}
+
+// คอลัมน์สุดท้ายที่ Excel รองรับ (XFD)
+const maxXLSXCols = 16384
+
+// "BC12" -> คอลัมน์ที่ 54 (เริ่ม 0), ไม่มีตัวอักษร = -1, เกิน XFD = maxXLSXCols
+func colIndex(ref string) int {
+	n := 0
+	i := 0
+	for ; i < len(ref); i++ {
+		ch := ref[i]
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		if n = n*26 + int(ch-'A'+1); n > maxXLSXCols {
+			return maxXLSXCols
+		}
+	}
+	if i == 0 {
+		return -1
+	}
+	return n - 1
+}
+
+func colName(i int) string {
+	s := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		s = string(rune('A'+(i-1)%26)) + s
+	}
+	return s
+}
+
+// เลขแถว/คอลัมน์มาจากไฟล์ (<row r> / <c r>) ต้องตรวจกับ lim ก่อนเติมช่องว่าง
+// ไม่งั้นไฟล์ไม่กี่ร้อยไบต์ก็สั่งให้จองแถวเป็นล้านได้
+// แถว/เซลล์ที่เกินแต่ไม่มีค่า (Excel ชอบเก็บแถวที่มีแค่ style) ข้ามไป
+func readXLSX(data []byte, lim Limits) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errBadXLSX
+	}
+
+	var shared []string
+	if b, err := readPart(zr, "xl/sharedStrings.xml"); err != nil {
+		return nil, err
+	} else if b != nil {
+		var sst struct {
+			SI []richText `xml:"si"`
+		}
+		if err := xml.Unmarshal(b, &sst); err != nil {
+			return nil, errBadXLSX
+		}
+		shared = make([]string, len(sst.SI))
+		for i, si := range sst.SI {
+			shared[i] = si.String()
+		}
+	}
+
+	sheetPath, err := firstSheetPath(zr)
+	if err != nil {
+		return nil, err
+	}
+	b, err := readPart(zr, sheetPath)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, errBadXLSX
+	}
+	var ws struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R  string    `xml:"r,attr"`
+				T  string    `xml:"t,attr"`
+				V  string    `xml:"v"`
+				IS *richText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(b, &ws); err != nil {
+		return nil, errBadXLSX
+	}
+
+	var rows [][]string
+	for _, r := range ws.Rows {
+		// แถวที่ว่าง (ไม่มีใน xml) ต้องเติมให้เลขแถวตรงกับใน Excel
+		idx := r.R - 1
+		if idx < len(rows) {
+			idx = len(rows)
+		}
+		var row []string
+		for _, c := range r.Cells {
+			var v string
+			switch c.T {
+			case "s":
+				n, err := strconv.Atoi(strings.TrimSpace(c.V))
+				if err != nil || n < 0 || n >= len(shared) {
+					return nil, errBadXLSX
+				}
+				v = shared[n]
+			case "inlineStr":
+				if c.IS != nil {
+					v = c.IS.String()
+				}
+			default: // n, str, b, e
+				v = c.V
+			}
+			ci := colIndex(c.R)
+			if ci < 0 {
+				ci = len(row)
+			}
+			if ci >= lim.Cols {
+				if strings.TrimSpace(v) == "" {
+					continue
+				}
+				return nil, fmt.Errorf("%w: มีข้อมูลเกิน %d คอลัมน์", errBadXLSX, lim.Cols)
+			}
+			for len(row) <= ci {
+				row = append(row, "")
+			}
+			row[ci] = v
+		}
+		if idx >= lim.Rows {
+			if isBlank(row) {
+				continue
+			}
+			return nil, fmt.Errorf("%w: มีข้อมูลเกิน %d แถว", errBadXLSX, lim.Rows)
+		}
+		for len(rows) < idx {
+			rows = append(rows, nil)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func writeXLSX(w io.Writer, rows [][]string) error {
+	zw := zip.NewWriter(w)
+	add := func(name, body string) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, body)
+		return err
+	}
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+	}
+	for _, p := range parts {
+		if err := add(p.name, p.body); err != nil {
+			return err
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&sb, `<row r="%d">`, i+1)
+		for j, v := range row {
+			ref := colName(j) + strconv.Itoa(i+1)
+			// ตัวเลขล้วนเก็บเป็นตัวเลข (Excel จะได้ไม่ขึ้นเตือน) นอกนั้นเป็นข้อความ
+			if _, err := strconv.ParseInt(v, 10, 64); err == nil && len(v) < 16 && (v == "0" || v[0] != '0') {
+				fmt.Fprintf(&sb, `<c r="%s"><v>%s</v></c>`, ref, v)
+			} else if v != "" {
+				fmt.Fprintf(&sb, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(v))
+			}
+		}
+		sb.WriteString(`</row>`)
+	}
+	sb.WriteString(`</sheetData></worksheet>`)
+	if err := add("xl/worksheets/sheet1.xml", sb.String()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
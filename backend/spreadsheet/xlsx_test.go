@@ -0,0 +1,118 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testLimits = Limits{Rows: 1001, Cols: 23}
+
+// buildXLSX ประกอบไฟล์ .xlsx ขั้นต่ำจาก sheetData (และ sharedStrings ถ้ามี)
+func buildXLSX(t *testing.T, sheetData, sharedStrings string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + sheetData + `</sheetData></worksheet>`,
+	}
+	if sharedStrings != "" {
+		parts["xl/sharedStrings.xml"] = `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + sharedStrings + `</sst>`
+	}
+	for name, body := range parts {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadXLSXRejectsHugeIndexes(t *testing.T) {
+	cases := map[string]string{
+		"row index":         `<row r="20000000"><c r="A20000000"><v>1</v></c></row>`,
+		"column index":      `<row r="1"><c r="AAAAA1"><v>1</v></c></row>`,
+		"both":              `<row r="20000000"><c r="AAAAA1"><v>1</v></c></row>`,
+		"column overflow":   `<row r="1"><c r="ZZZZZZZZZZZZZZZZZZZZ1"><v>1</v></c></row>`,
+		"first row too far": `<row r="1002"><c r="A1002"><v>x</v></c></row>`,
+	}
+	for name, sheet := range cases {
+		t.Run(name, func(t *testing.T) {
+			rows, err := Read(FormatXLSX, buildXLSX(t, sheet, ""), testLimits)
+			if !errors.Is(err, errBadXLSX) {
+				t.Fatalf("err = %v (rows=%d), want errBadXLSX", err, len(rows))
+			}
+		})
+	}
+}
+
+func TestReadXLSXSkipsEmptyCellsPastLimits(t *testing.T) {
+	// แถว/เซลล์ที่มีแต่ style (ไม่มีค่า) นอกขอบเขตไม่ถือว่าผิด และไม่ถูกเติมลงตาราง
+	sheet := `<row r="1"><c r="A1" t="inlineStr"><is><t>name</t></is></c><c r="XFD1" s="1"/></row>` +
+		`<row r="2"><c r="A2"><v>5</v></c></row>` +
+		`<row r="1048576"><c r="A1048576" s="1"/></row>`
+	rows, err := Read(FormatXLSX, buildXLSX(t, sheet, ""), testLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || len(rows[0]) != 1 || rows[0][0] != "name" || rows[1][0] != "5" {
+		t.Fatalf("rows = %q", rows)
+	}
+}
+
+func TestReadXLSXSharedAndInlineStrings(t *testing.T) {
+	sst := `<si><t>ชื่อสินค้า</t></si>` +
+		`<si><r><t>เสื้อ</t></r><r><t xml:space="preserve"> สีแดง</t></r></si>` + // rich text ต่อกันเป็นข้อความเดียว
+		`<si><t>ราคา</t></si>`
+	sheet := `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>2</v></c></row>` +
+		`<row r="2"><c r="A2" t="s"><v>1</v></c><c r="B2"><v>199.5</v></c></row>` +
+		// ช่องข้าม (B) และแถวข้าม (3) ต้องเติมว่างให้ตำแหน่งตรงกับใน Excel
+		`<row r="4"><c r="A4" t="inlineStr"><is><t>กางเกง &amp; เข็มขัด</t></is></c><c r="C4" t="str"><v>สูตร</v></c></row>`
+	rows, err := Read(FormatXLSX, buildXLSX(t, sheet, sst), testLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"ชื่อสินค้า", "ราคา"},
+		{"เสื้อ สีแดง", "199.5"},
+		nil,
+		{"กางเกง & เข็มขัด", "", "สูตร"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+
+	// อ้าง shared string ที่ไม่มีจริง = ไฟล์เสีย
+	for _, v := range []string{"3", "-1", "x"} {
+		bad := `<row r="1"><c r="A1" t="s"><v>` + v + `</v></c></row>`
+		if _, err := Read(FormatXLSX, buildXLSX(t, bad, sst), testLimits); !errors.Is(err, errBadXLSX) {
+			t.Errorf("shared index %q: err = %v, want errBadXLSX", v, err)
+		}
+	}
+}
+
+func TestColIndexName(t *testing.T) {
+	cases := map[string]int{"A1": 0, "Z9": 25, "AA10": 26, "BC12": 54, "XFD1": maxXLSXCols - 1, "12": -1}
+	for ref, want := range cases {
+		if got := colIndex(ref); got != want {
+			t.Errorf("colIndex(%q) = %d, want %d", ref, got, want)
+		}
+		if want >= 0 {
+			if got := colName(want) + ref[len(colName(want)):]; got != ref {
+				t.Errorf("colName(%d) = %q, want prefix of %q", want, colName(want), ref)
+			}
+		}
+	}
+}
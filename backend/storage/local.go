@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local เก็บไฟล์บนดิสก์ของเครื่องนี้ (ใช้ได้เมื่อรัน API instance เดียวหรือแชร์ดิสก์กัน)
+type Local struct {
+	Dir string
+}
+
+// Path คืน path บนดิสก์ของ key
+func (l *Local) Path(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Dir, filepath.FromSlash(key)), nil
+}
+
+// เขียนลงไฟล์ชั่วคราวก่อนแล้วค่อย rename กันคนอ่านเจอไฟล์ครึ่ง ๆ
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	p, err := l.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".put-*")
+	if err != nil {
+		return err
+	}
+	_, werr := io.Copy(tmp, r)
+	if cerr := tmp.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		werr = os.Chmod(tmp.Name(), 0644)
+	}
+	if werr == nil {
+		werr = os.Rename(tmp.Name(), p)
+	}
+	if werr != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return werr
+}
+
+// Get คืน *os.File (ใช้ seek ได้ เสิร์ฟแบบ Range ได้)
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := l.Path(key)
+	if err != nil {
+		return nil, err
+	}
+	st, err := os.Stat(p)
+	if err != nil || st.IsDir() {
+		return nil, localErr(err)
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, localErr(err)
+	}
+	return f, nil
+}
+
+func (l *Local) Stat(_ context.Context, key string) (*ObjectInfo, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	p := filepath.Join(l.Dir, filepath.FromSlash(key))
+	st, err := os.Stat(p)
+	if err != nil || st.IsDir() {
+		return nil, localErr(err)
+	}
+	return &ObjectInfo{
+		Key:         key,
+		Size:        st.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(p)),
+		ModTime:     st.ModTime(),
+	}, nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	p, err := l.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Presign ของ local = URL สาธารณะ (ไฟล์ใต้ /uploads เสิร์ฟให้ทุกคนอยู่แล้ว)
+func (l *Local) Presign(_ context.Context, key string, _ time.Duration) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return URL(key), nil
+}
+
+func (l *Local) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	err := filepath.WalkDir(l.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == l.Dir && errors.Is(err, fs.ErrNotExist) {
+				return nil // ยังไม่เคยอัปโหลดอะไร
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".put-") { // ไฟล์ชั่วคราวของ Put ที่ยังเขียนไม่เสร็จ
+			return nil
+		}
+		rel, err := filepath.Rel(l.Dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		st, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // ถูกลบไประหว่างไล่
+			}
+			return err
+		}
+		return fn(ObjectInfo{
+			Key:         key,
+			Size:        st.Size(),
+			ContentType: mime.TypeByExtension(filepath.Ext(p)),
+			ModTime:     st.ModTime(),
+		})
+	})
+	return err
+}
+
+func (l *Local) rename(from, to string) error {
+	src, err := l.Path(from)
+	if err != nil {
+		return err
+	}
+	dst, err := l.Path(to)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return localErr(err)
+	}
+	return nil
+}
+
+// ไม่พบไฟล์/เป็นโฟลเดอร์ = ErrNotFound (err == nil ใช้ตอน Stat แล้วเจอโฟลเดอร์)
+func localErr(err error) error {
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3 เก็บไฟล์ใน bucket ของ AWS S3 หรือเซิร์ฟเวอร์ที่เข้ากันได้ (MinIO ฯลฯ) ให้หลาย API instance ใช้ร่วมกัน
+type S3 struct {
+	Endpoint  string // เช่น http://localhost:9000 (ว่าง = https://s3.<region>.amazonaws.com)
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool // true = <endpoint>/<bucket>/<key>, false = <bucket>.<host>/<key>
+
+	Client *http.Client // nil = http.DefaultClient
+}
+
+func (s *S3) signer() signer {
+	return signer{accessKey: s.AccessKey, secretKey: s.SecretKey, region: s.Region}
+}
+
+func (s *S3) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return http.DefaultClient
+}
+
+func (s *S3) objectURL(key string) (*url.URL, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.bucketURL("/" + key)
+}
+
+// bucketURL = URL ของ p ภายใน bucket (p ว่าง = ตัว bucket, ไม่ว่างต้องขึ้นต้นด้วย /)
+func (s *S3) bucketURL(p string) (*url.URL, error) {
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + s.Region + ".amazonaws.com"
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("storage: STORAGE_S3_ENDPOINT ไม่ถูกต้อง: %w", err)
+	}
+	if s.PathStyle {
+		p = "/" + s.Bucket + p
+	} else {
+		u.Host = s.Bucket + "." + u.Host
+	}
+	u.Path = u.Path + p
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	// ให้ path ที่ส่งจริงกับที่เซ็นตรงกัน (encode แบบ AWS)
+	u.RawPath = awsEscape(u.Path, false)
+	return u, nil
+}
+
+// do ส่ง request ที่เซ็นแล้ว ไม่ใช่ 2xx = error (404 = ErrNotFound)
+func (s *S3) do(ctx context.Context, method, key string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.send(ctx, method, u, key, body, size, contentType)
+}
+
+// send เหมือน do แต่ระบุ URL เอง (name ใช้แค่ในข้อความ error)
+func (s *S3) send(ctx context.Context, method string, u *url.URL, name string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	payload := emptyPayloadHash
+	if body != nil {
+		// ไม่อ่าน body มาทำ hash ก่อน (ไฟล์ใหญ่) ใช้ UNSIGNED-PAYLOAD แทน
+		payload = unsignedPayload
+		req.ContentLength = size
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+	}
+	s.signer().signRequest(req, payload, time.Now())
+
+	res, err := s.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	return nil, s3Error(method, name, res)
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	res, err := s.do(ctx, http.MethodPut, key, r, size, contentType)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	res, err := s.do(ctx, http.MethodGet, key, nil, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	res, err := s.do(ctx, http.MethodHead, key, nil, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+	info := &ObjectInfo{
+		Key:         strings.TrimPrefix(key, "/"),
+		Size:        res.ContentLength,
+		ContentType: res.Header.Get("Content-Type"),
+	}
+	if t, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = t
+	}
+	return info, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	res, err := s.do(ctx, http.MethodDelete, key, nil, 0, "")
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// List ใช้ ListObjectsV2 ทีละหน้า (สูงสุด 1000 ไฟล์ต่อหน้า)
+func (s *S3) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	var token string
+	for {
+		u, err := s.bucketURL("")
+		if err != nil {
+			return err
+		}
+		q := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			q.Set("prefix", prefix)
+		}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		// encode แบบเดียวกับที่เซ็น (url.Values.Encode ใช้ + แทน space)
+		u.RawQuery = canonicalQuery(q)
+
+		res, err := s.send(ctx, http.MethodGet, u, "list "+prefix, nil, 0, "")
+		if err != nil {
+			return err
+		}
+		var page struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return fmt.Errorf("storage: s3 list %q: %w", prefix, err)
+		}
+		for _, o := range page.Contents {
+			if err := fn(ObjectInfo{Key: o.Key, Size: o.Size, ModTime: o.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// Presign คืนลิงก์ GET ที่เซ็นแล้ว (ไม่ตรวจว่ามีไฟล์อยู่จริง)
+func (s *S3) Presign(_ context.Context, key string, ttl time.Duration) (string, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	return s.signer().presign(http.MethodGet, u, ttl, time.Now()), nil
+}
+
+// แปลง error XML ของ S3 (<Error><Code>..</Code><Message>..</Message></Error>) เป็นข้อความอ่านง่าย
+func s3Error(method, name string, res *http.Response) error {
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	if xml.Unmarshal(body, &e) != nil || e.Code == "" {
+		e.Code = res.Status
+	}
+	return fmt.Errorf("storage: s3 %s %s: %s %s", method, name, e.Code, e.Message)
+}
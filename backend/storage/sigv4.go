@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4 สำหรับ S3 (เขียนเองเพื่อไม่ต้องดึง SDK ทั้งชุดมา)
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
+
+const (
+	sigAlgorithm    = "AWS4-HMAC-SHA256"
+	amzDateFormat   = "20060102T150405Z"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// sha256 ของ body ว่าง
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+type signer struct {
+	accessKey, secretKey, region string
+}
+
+// signRequest ใส่ x-amz-date / x-amz-content-sha256 / Authorization ให้ req
+// เซ็นเฉพาะ host กับ x-amz-* (header อื่นเช่น Content-Type ส่งได้แต่ไม่อยู่ในลายเซ็น)
+func (s signer) signRequest(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonical, signedHeaders := canonicalRequest(req, payloadHash, amzDate)
+	scope := s.scope(now)
+	sig := s.signature(now, amzDate, scope, canonical)
+	req.Header.Set("Authorization", sigAlgorithm+" Credential="+s.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+sig)
+}
+
+// canonicalRequest = ข้อความที่ถูกเซ็นของ signRequest และรายชื่อ header ที่เซ็น
+func canonicalRequest(req *http.Request, payloadHash, amzDate string) (canonical, signedHeaders string) {
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + strings.TrimSpace(headers[k]) + "\n")
+	}
+	signedHeaders = strings.Join(names, ";")
+
+	canonical = strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	return canonical, signedHeaders
+}
+
+// presign คืน URL แบบ query-string auth (เซ็นแค่ host ใช้ GET ได้จากเบราว์เซอร์ตรง ๆ)
+func (s signer) presign(method string, u *url.URL, ttl time.Duration, now time.Time) string {
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+	scope := s.scope(now)
+
+	q := u.Query()
+	q.Set("X-Amz-Algorithm", sigAlgorithm)
+	q.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", expiresSeconds(ttl))
+	q.Set("X-Amz-SignedHeaders", "host")
+
+	query := canonicalQuery(q)
+	canonical := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		query,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	out := *u
+	out.RawQuery = query + "&X-Amz-Signature=" + s.signature(now, amzDate, scope, canonical)
+	return out.String()
+}
+
+func (s signer) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+func (s signer) signature(now time.Time, amzDate, scope, canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := sigAlgorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	k := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	k = hmacSHA256(k, s.region)
+	k = hmacSHA256(k, "s3")
+	k = hmacSHA256(k, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(k, toSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// query เรียงตามชื่อ encode แบบ AWS (ต่างจาก url.Values.Encode ตรงที่ space = %20)
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, awsEscape(k, true)+"="+awsEscape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape เก็บไว้เฉพาะ A-Z a-z 0-9 - _ . ~ (และ / ถ้าไม่ได้ encode slash) ที่เหลือเป็น %XX
+func awsEscape(s string, encodeSlash bool) string {
+	const hexUpper = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexUpper[c>>4])
+			b.WriteByte(hexUpper[c&15])
+		}
+	}
+	return b.String()
+}
+
+// S3 รับอายุลิงก์ 1 วินาที ถึง 7 วัน
+func expiresSeconds(d time.Duration) string {
+	sec := int64(d / time.Second)
+	if sec < 1 {
+		sec = 1
+	}
+	if max := int64(7 * 24 * 3600); sec > max {
+		sec = max
+	}
+	return strconv.FormatInt(sec, 10)
+}
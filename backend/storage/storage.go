@@ -0,0 +1,180 @@
+// Package storage เก็บไฟล์อัปโหลดผ่าน interface เดียว เลือก driver ด้วย STORAGE_DRIVER
+//
+//	STORAGE_DRIVER=local (ค่าเริ่มต้น) เขียนลงดิสก์ใต้ STORAGE_LOCAL_DIR (default: ./uploads)
+//	STORAGE_DRIVER=s3    เก็บใน bucket ของ S3 หรือเซิร์ฟเวอร์ที่เข้ากันได้ (MinIO ฯลฯ)
+//
+// ทุก driver อ้างไฟล์ด้วย key แบบ "products/123.jpg" และ URL ที่เก็บใน DB คือ /uploads/<key> เสมอ
+// จึงสลับ driver ได้โดยไม่ต้องแก้ข้อมูลเดิม (ย้ายไฟล์ไป bucket ตาม key เดิมก็พอ)
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// URLPrefix = path ที่ server เสิร์ฟไฟล์อัปโหลด (ดู controller.ServeUpload)
+const URLPrefix = "/uploads/"
+
+var (
+	ErrNotFound   = errors.New("storage: ไม่พบไฟล์")
+	ErrInvalidKey = errors.New("storage: key ไม่ถูกต้อง")
+)
+
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+type Storage interface {
+	// Put เขียนทับถ้ามี key นี้อยู่แล้ว
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get ไม่พบ = ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat ไม่พบ = ErrNotFound
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete ไม่พบก็ไม่ถือเป็น error
+	Delete(ctx context.Context, key string) error
+	// Presign คืน URL ที่ใช้ดาวน์โหลดได้ตรง ๆ ภายในเวลา ttl
+	Presign(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// List เรียก fn กับทุกไฟล์ที่ key ขึ้นต้นด้วย prefix ("" = ทั้งหมด) fn คืน error = หยุด
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+}
+
+// Move ย้ายไฟล์ from -> to (local = rename, อื่น ๆ = คัดลอกแล้วลบต้นทาง)
+func Move(ctx context.Context, s Storage, from, to string) error {
+	if l, ok := s.(*Local); ok {
+		return l.rename(from, to)
+	}
+	info, err := s.Stat(ctx, from)
+	if err != nil {
+		return err
+	}
+	rc, err := s.Get(ctx, from)
+	if err != nil {
+		return err
+	}
+	err = s.Put(ctx, to, rc, info.Size, info.ContentType)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+	return s.Delete(ctx, from)
+}
+
+var (
+	mu      sync.Mutex
+	current Storage
+)
+
+// Setup สร้าง storage ตาม env (เรียกตอนสตาร์ต ตั้งค่าผิด = error)
+func Setup() error {
+	s, err := FromEnv()
+	if err != nil {
+		return err
+	}
+	SetDefault(s)
+	return nil
+}
+
+// Default คืน storage ปัจจุบัน (ยังไม่ได้ Setup = local ที่ ./uploads)
+func Default() Storage {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		current = &Local{Dir: "uploads"}
+	}
+	return current
+}
+
+// SetDefault ใช้สลับ storage เอง เช่นตอนเทสต์
+func SetDefault(s Storage) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = s
+}
+
+func FromEnv() (Storage, error) {
+	switch strings.ToLower(os.Getenv("STORAGE_DRIVER")) {
+	case "", "local":
+		return &Local{Dir: getenv("STORAGE_LOCAL_DIR", "uploads")}, nil
+	case "s3":
+		endpoint := os.Getenv("STORAGE_S3_ENDPOINT")
+		s := &S3{
+			Endpoint:  endpoint,
+			Region:    getenv("STORAGE_S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("STORAGE_S3_BUCKET"),
+			AccessKey: os.Getenv("STORAGE_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("STORAGE_S3_SECRET_KEY"),
+			// เซิร์ฟเวอร์ในเครื่อง (MinIO ฯลฯ) ส่วนใหญ่ใช้ได้แค่แบบ path-style
+			PathStyle: boolEnv("STORAGE_S3_PATH_STYLE", endpoint != ""),
+		}
+		if s.Bucket == "" || s.AccessKey == "" || s.SecretKey == "" {
+			return nil, errors.New("storage: STORAGE_DRIVER=s3 ต้องตั้ง STORAGE_S3_BUCKET, STORAGE_S3_ACCESS_KEY และ STORAGE_S3_SECRET_KEY")
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("storage: ไม่รู้จัก STORAGE_DRIVER=%q", os.Getenv("STORAGE_DRIVER"))
+	}
+}
+
+// CleanKey ตัด / นำหน้าแล้วตรวจว่าไม่หลุดออกนอก root (.., ว่าง, backslash)
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	clean := path.Clean(key)
+	if clean != key || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", ErrInvalidKey
+	}
+	return clean, nil
+}
+
+// URL = path สาธารณะของ key (เก็บค่านี้ใน DB)
+func URL(key string) string {
+	return URLPrefix + key
+}
+
+// KeyFromURL แปลง URL/path ที่เก็บใน DB (/uploads/<key> หรือ http://host/uploads/<key>) เป็น key
+// ลิงก์ภายนอกหรือ path แปลก ๆ = false
+func KeyFromURL(u string) (string, bool) {
+	switch i := strings.Index(u, URLPrefix); {
+	case i >= 0:
+		u = u[i+len(URLPrefix):]
+	case strings.HasPrefix(u, URLPrefix[1:]): // แบบไม่มี / นำหน้า เช่น "uploads/logo/x.png"
+		u = u[len(URLPrefix)-1:]
+	default:
+		return "", false
+	}
+	key, err := CleanKey(u)
+	if err != nil {
+		return "", false
+	}
+	return key, true
+}
+
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func boolEnv(key string, def bool) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "1", "true", "yes", "on":
+		return true
+	case "0", "false", "no", "off":
+		return false
+	}
+	return def
+}
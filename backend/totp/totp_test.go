@@ -0,0 +1,94 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// secret ของ RFC 6238 Appendix B (SHA1) = ASCII "12345678901234567890" ในรูป base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC ให้รหัส 8 หลัก ของเราใช้ 6 หลัก = 6 หลักท้ายของค่าเดียวกัน
+func TestCodeRFC6238Vectors(t *testing.T) {
+	vectors := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, v := range vectors {
+		got, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("t=%d: %v", v.unix, err)
+		}
+		if want := v.want[len(v.want)-Digits:]; got != want {
+			t.Errorf("t=%d: got %s, want %s", v.unix, got, want)
+		}
+	}
+}
+
+func TestCodeAcceptsLowercaseSecret(t *testing.T) {
+	a, _ := Code(rfcSecret, 1)
+	b, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1)
+	if err != nil || a != b {
+		t.Fatalf("got %q (%v), want %q", b, err, a)
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	cur := Step(now)
+	for _, tc := range []struct {
+		offset int64
+		ok     bool
+	}{
+		{-2, false},
+		{-1, true},
+		{0, true},
+		{1, true},
+		{2, false},
+	} {
+		code, err := Code(rfcSecret, cur+tc.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, ok := Validate(rfcSecret, code, now, 0)
+		if ok != tc.ok {
+			t.Errorf("offset %d: ok = %v, want %v", tc.offset, ok, tc.ok)
+			continue
+		}
+		if ok && step != cur+tc.offset {
+			t.Errorf("offset %d: step = %d, want %d", tc.offset, step, cur+tc.offset)
+		}
+	}
+}
+
+func TestValidateRejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := Code(rfcSecret, Step(now))
+	step, ok := Validate(rfcSecret, code, now, 0)
+	if !ok {
+		t.Fatal("first use should pass")
+	}
+	if _, ok := Validate(rfcSecret, code, now, step); ok {
+		t.Fatal("same step used twice should fail")
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	now := time.Unix(59, 0)
+	code, _ := Code(rfcSecret, Step(now))
+	if _, ok := Validate(rfcSecret, code[:3]+" "+code[3:], now, 0); !ok {
+		t.Error("code with a space in the middle should pass")
+	}
+	for _, bad := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := Validate(rfcSecret, bad, now, 0); ok {
+			t.Errorf("%q should not pass", bad)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Package trash = ถังขยะของโพสต์ขายสินค้า
+//
+// ลบโพสต์ = soft delete โพสต์ + สินค้า + รูป ด้วย deleted_at ค่าเดียวกัน ผู้ขายกู้คืนได้ภายใน Retention
+// พ้นจากนั้น Purge ลบแถวทิ้งจริง (รวม variant / ตัวเลือก / รีวิว) และลบไฟล์รูปที่ไม่มีแถวอื่นใช้แล้ว
+// ประวัติที่อ้างสินค้า (รายการสั่งซื้อ, ความเคลื่อนไหวสต็อก, ประวัติราคา) ไม่ลบ เพราะเก็บชื่อ/ราคาไว้ในตัวเองแล้ว
+//
+// ยกเว้นที่ไม่ลบถาวรเลย (ค้างเป็น soft delete ไว้เหมือนเก็บถาวร พ้น Retention แล้วผู้ขายไม่เห็น/กู้คืนไม่ได้):
+//   - สินค้าที่มี order อ้างอยู่ (order ยังต้องเปิดดูสินค้า/variant เดิมได้)
+//   - โพสต์ของผู้ขายที่ปิดบัญชีไปแล้ว (DeleteMe ซ่อนโพสต์โดยสัญญาว่าสินค้ายังอยู่)
+package trash
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"example.com/GROUB/entity"
+	"example.com/GROUB/imaging"
+	"example.com/GROUB/search"
+	"example.com/GROUB/storage"
+	"gorm.io/gorm"
+)
+
+// Retention = เก็บในถังขยะนานเท่าไรก่อนลบถาวร
+const Retention = 30 * 24 * time.Hour
+
+// รูปที่ถูกลบพร้อมโพสต์มี deleted_at เท่ากับโพสต์ แต่โพสต์ที่ลบก่อนมีถังขยะต่างกันได้ไม่กี่ ms
+// (รูปที่ถูกแทนที่ตอนแก้สินค้าก็เป็น soft delete เหมือนกัน ต้องไม่กู้คืนตัวพวกนั้นมาด้วย)
+const sameDeleteWindow = 2 * time.Second
+
+// purgeBatch = จำนวนโพสต์ต่อรอบของ Purge
+const purgeBatch = 100
+
+var (
+	ErrNotFound = errors.New("ไม่พบโพสต์ในถังขยะ")
+	ErrExpired  = errors.New("โพสต์นี้อยู่ในถังขยะเกินระยะเวลากู้คืนแล้ว")
+)
+
+// PurgeAt = เวลาที่โพสต์ที่ถูกลบตอน deletedAt จะถูกลบถาวร
+func PurgeAt(deletedAt time.Time) time.Time {
+	return deletedAt.Add(Retention)
+}
+
+// Delete ย้ายโพสต์ (พร้อมสินค้าและรูป) ลงถังขยะ post ต้องมี ID และ Product_ID
+func Delete(tx *gorm.DB, post *entity.Post_a_New_Product, now time.Time) error {
+	tx = tx.Session(&gorm.Session{NowFunc: func() time.Time { return now }})
+	if err := tx.Delete(post).Error; err != nil {
+		return err
+	}
+	if post.Product_ID == nil {
+		return nil
+	}
+	if err := tx.Where("product_id = ?", *post.Product_ID).Delete(&entity.ProductImage{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&entity.Product{}, *post.Product_ID).Error
+}
+
+// SameDelete = a กับ b เป็นการลบครั้งเดียวกันหรือไม่ (ใช้กรองรูปหลังโหลดมาแล้ว)
+func SameDelete(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d >= -sameDeleteWindow && d <= sameDeleteWindow
+}
+
+// DeletedWith = เงื่อนไขรูปที่ถูกลบพร้อมโพสต์ที่ถูกลบตอน at
+func DeletedWith(db *gorm.DB, at time.Time) *gorm.DB {
+	return db.Where("deleted_at BETWEEN ? AND ?", at.Add(-sameDeleteWindow), at.Add(sameDeleteWindow))
+}
+
+// Restore กู้คืนโพสต์ + สินค้า + รูปที่ถูกลบไปพร้อมกัน (post ต้องอ่านแบบ Unscoped มา)
+func Restore(db *gorm.DB, post *entity.Post_a_New_Product, now time.Time) error {
+	if !post.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	at := post.DeletedAt.Time
+	if now.After(PurgeAt(at)) {
+		return ErrExpired
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Model(&entity.Post_a_New_Product{}).
+			Where("id = ? AND deleted_at IS NOT NULL", post.ID).
+			Update("deleted_at", nil)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound // มีคนกู้คืน/ลบถาวรไปก่อน
+		}
+		if post.Product_ID == nil {
+			return nil
+		}
+		if err := tx.Unscoped().Model(&entity.Product{}).
+			Where("id = ?", *post.Product_ID).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return DeletedWith(tx.Unscoped().Model(&entity.ProductImage{}).Where("product_id = ?", *post.Product_ID), at).
+			Update("deleted_at", nil).Error
+	}); err != nil {
+		return err
+	}
+
+	if post.Product_ID != nil {
+		if err := search.IndexProducts(db, *post.Product_ID); err != nil {
+			log.Println("search index:", err)
+		}
+	}
+	post.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// Purge ลบถาวรโพสต์ที่อยู่ในถังขยะเกิน Retention คืนจำนวนโพสต์และไฟล์ที่ลบ
+func Purge(ctx context.Context, db *gorm.DB, st storage.Storage, now time.Time) (posts, files int, err error) {
+	for {
+		var batch []entity.Post_a_New_Product
+		if err := db.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", now.Add(-Retention)).
+			Scopes(purgeable).
+			Order("id").Limit(purgeBatch).
+			Find(&batch).Error; err != nil {
+			return posts, files, err
+		}
+		for i := range batch {
+			n, err := purgeOne(ctx, db, st, &batch[i])
+			if err != nil {
+				return posts, files, err
+			}
+			posts++
+			files += n
+		}
+		if len(batch) < purgeBatch {
+			return posts, files, nil
+		}
+	}
+}
+
+// purgeable ตัดโพสต์ที่ต้องเก็บไว้ออก (ดูหัวไฟล์) กรองใน query เลย ไม่งั้นรอบถัดไปจะเจอแถวเดิมซ้ำไม่รู้จบ
+func purgeable(db *gorm.DB) *gorm.DB {
+	return db.
+		Where(`product_id IS NULL OR NOT EXISTS
+			(SELECT 1 FROM order_items oi WHERE oi.product_id = post_a_new_products.product_id)`).
+		Where(`seller_id IS NULL OR NOT EXISTS
+			(SELECT 1 FROM sellers s JOIN members m ON m.id = s.member_id
+			 WHERE s.id = post_a_new_products.seller_id AND m.deactivated_at IS NOT NULL)`)
+}
+
+func purgeOne(ctx context.Context, db *gorm.DB, st storage.Storage, post *entity.Post_a_New_Product) (int, error) {
+	var paths []string
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		tx = tx.Unscoped().Session(&gorm.Session{}) // ใช้ซ้ำหลายคำสั่ง
+		if pid := post.Product_ID; pid != nil {
+			var imgs, variantImgs, reviewImgs []string
+			if err := tx.Model(&entity.ProductImage{}).Where("product_id = ?", *pid).Pluck("image_path", &imgs).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&entity.ProductVariant{}).Where("product_id = ? AND image_path <> ''", *pid).Pluck("image_path", &variantImgs).Error; err != nil {
+				return err
+			}
+			reviews := tx.Model(&entity.Review{}).Select("id").Where("product_id = ?", *pid)
+			if err := tx.Model(&entity.ReviewPhoto{}).Where("review_id IN (?)", reviews).Pluck("image_path", &reviewImgs).Error; err != nil {
+				return err
+			}
+			paths = append(append(imgs, variantImgs...), reviewImgs...)
+
+			steps := []func() error{
+				func() error {
+					return tx.Exec(`DELETE FROM product_variant_values WHERE product_variant_id IN
+						(SELECT id FROM product_variants WHERE product_id = ?)`, *pid).Error
+				},
+				func() error {
+					return tx.Where("option_id IN (?)", tx.Model(&entity.ProductOption{}).Select("id").Where("product_id = ?", *pid)).
+						Delete(&entity.ProductOptionValue{}).Error
+				},
+				func() error { return tx.Where("product_id = ?", *pid).Delete(&entity.ProductOption{}).Error },
+				func() error { return tx.Where("product_id = ?", *pid).Delete(&entity.ProductVariant{}).Error },
+				func() error { return tx.Where("product_id = ?", *pid).Delete(&entity.ProductAttributeValue{}).Error },
+				func() error { return tx.Where("review_id IN (?)", reviews).Delete(&entity.ReviewPhoto{}).Error },
+				func() error { return tx.Where("product_id = ?", *pid).Delete(&entity.Review{}).Error },
+				func() error { return tx.Where("product_id = ?", *pid).Delete(&entity.ProductImage{}).Error },
+				func() error { return tx.Delete(&entity.Product{}, *pid).Error },
+			}
+			for _, step := range steps {
+				if err := step(); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Delete(&entity.Post_a_New_Product{}, post.ID).Error
+	}); err != nil {
+		return 0, err
+	}
+
+	// ลบไฟล์หลัง commit เฉพาะที่ไม่มีแถวอื่นอ้างอยู่ (นำเข้าแคตตาล็อกใช้รูปซ้ำข้ามสินค้าได้)
+	removed := 0
+	seen := map[string]bool{}
+	for _, p := range paths {
+		key, ok := storage.KeyFromURL(p)
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if StillUsed(db, p) {
+			continue
+		}
+		for _, k := range imaging.VariantPaths(key) {
+			if err := st.Delete(ctx, k); err != nil {
+				log.Printf("trash: remove %s: %v", k, err)
+			}
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// StillUsed = มีแถวไหน (รวมที่ลบแบบ soft) ยังอ้าง path รูปนี้อยู่ ใช้ก่อนลบไฟล์ทุกครั้ง
+func StillUsed(db *gorm.DB, p string) bool {
+	for _, m := range []interface{}{&entity.ProductImage{}, &entity.ProductVariant{}, &entity.ReviewPhoto{}} {
+		var n int64
+		if err := db.Unscoped().Model(m).Where("image_path = ?", p).Count(&n).Error; err != nil || n > 0 {
+			return true // อ่านไม่ได้ = ไม่เสี่ยงลบ
+		}
+	}
+	return false
+}
+
+// StartPurger เรียก Purge ทันที 1 ครั้ง แล้วทุก interval ใน goroutine
+func StartPurger(db *gorm.DB, interval time.Duration) {
+	run := func() {
+		posts, files, err := Purge(context.Background(), db, storage.Default(), time.Now())
+		if err != nil {
+			log.Println("trash: purge:", err)
+		}
+		if posts > 0 {
+			log.Printf("trash: purged %d posts, %d image files", posts, files)
+		}
+	}
+	go func() {
+		run()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for range t.C {
+			run()
+		}
+	}()
+}
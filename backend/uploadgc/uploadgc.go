@@ -0,0 +1,315 @@
+// Package uploadgc เก็บกวาดไฟล์อัปโหลดที่ไม่มีแถวไหนใน DB อ้างถึง
+//
+// ไฟล์ถูกอัปโหลดก่อนฟอร์มจะบันทึก (สร้างสินค้า/ร้าน) ถ้าผู้ใช้ทิ้งฟอร์มไฟล์ก็ค้างอยู่ตลอดไป
+// รอบหนึ่งของ Sweep ทำ 3 อย่าง:
+//  1. ไฟล์ที่ไม่มีใครอ้างและเก่ากว่า Grace -> ย้ายไป quarantine/<unix>/<key> (ยังไม่ลบ)
+//  2. ไฟล์ใน quarantine ที่กลับมามีคนอ้าง (ฟอร์มบันทึกช้า) -> ย้ายคืนที่เดิม
+//  3. ไฟล์ที่อยู่ใน quarantine นานเกิน PurgeAfter -> ลบจริง
+//  4. ไฟล์ export ข้อมูลส่วนตัว (ExportPrefix) ที่หมดอายุหรือไม่มีงานไหนอ้างแล้ว -> ลบจริงเลย (สร้างใหม่ได้เสมอ)
+package uploadgc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/GROUB/entity"
+	"example.com/GROUB/imaging"
+	"example.com/GROUB/storage"
+	"gorm.io/gorm"
+)
+
+// QuarantinePrefix และ ExportPrefix ไม่เสิร์ฟผ่าน /uploads (ดู controller.ServeUpload)
+const (
+	QuarantinePrefix = "quarantine/"
+	ExportPrefix     = "exports/" // zip จาก DataExport ดาวน์โหลดผ่าน API ของเจ้าของเท่านั้น
+)
+
+// maxItems จำกัดรายการไฟล์ในรายงาน (ตัวเลขรวมยังนับครบ)
+const maxItems = 500
+
+var ErrRunning = errors.New("กำลังเก็บกวาดไฟล์อยู่แล้ว")
+
+type Options struct {
+	Grace      time.Duration // อัปโหลดไม่ถึงเท่านี้ยังไม่แตะ (ฟอร์มอาจยังกรอกไม่เสร็จ)
+	PurgeAfter time.Duration // อยู่ใน quarantine นานเท่านี้แล้วลบจริง
+	DryRun     bool          // แค่รายงาน ไม่ย้าย/ลบ
+}
+
+func DefaultOptions() Options {
+	return Options{Grace: 24 * time.Hour, PurgeAfter: 7 * 24 * time.Hour}
+}
+
+type Item struct {
+	Key    string `json:"key"`
+	Size   int64  `json:"size"`
+	Action string `json:"action"` // quarantine | restore | purge
+}
+
+type Report struct {
+	StartedAt        time.Time `json:"started_at"`
+	FinishedAt       time.Time `json:"finished_at"`
+	DryRun           bool      `json:"dry_run"`
+	Scanned          int       `json:"scanned"`
+	Referenced       int       `json:"referenced"`
+	Quarantined      int       `json:"quarantined"`
+	QuarantinedBytes int64     `json:"quarantined_bytes"`
+	Restored         int       `json:"restored"`
+	Purged           int       `json:"purged"`
+	ExportsDeleted   int       `json:"exports_deleted"`
+	ReclaimedBytes   int64     `json:"reclaimed_bytes"` // ไฟล์ที่ลบจริงในรอบนี้
+	Items            []Item    `json:"items"`
+	Errors           []string  `json:"errors,omitempty"`
+}
+
+func (r *Report) add(key string, size int64, action string) {
+	if len(r.Items) < maxItems {
+		r.Items = append(r.Items, Item{Key: key, Size: size, Action: action})
+	}
+}
+
+func (r *Report) fail(key string, err error) {
+	if len(r.Errors) < maxItems {
+		r.Errors = append(r.Errors, fmt.Sprintf("%s: %v", key, err))
+	}
+}
+
+// กันรอบอัตโนมัติ, รอบที่แอดมินสั่ง และ cmd/uploadgc ชนกัน ด้วย lease ใน DB (entity.JobLock)
+// ไม่ใช่ mutex ใน process เพราะ CLI เป็นคนละ process กับ server
+const (
+	lockName = "uploadgc"
+	lockTTL  = time.Hour // รอบหนึ่งต้องจบก่อนนี้ ถ้า process ตายกลางทาง lease ก็หมดเองเมื่อครบ
+)
+
+// Sweep เก็บกวาด 1 รอบ (มีรอบอื่นทำอยู่ ไม่ว่า process ไหน = ErrRunning)
+func Sweep(ctx context.Context, db *gorm.DB, st storage.Storage, opt Options) (*Report, error) {
+	holder, err := acquireLock(db, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer releaseLock(db, holder)
+
+	now := time.Now()
+	rep := &Report{StartedAt: now, DryRun: opt.DryRun, Items: []Item{}}
+	refs, err := referencedKeys(db)
+	if err != nil {
+		return nil, err
+	}
+	exports, err := liveExports(db, now)
+	if err != nil {
+		return nil, err
+	}
+
+	type object struct {
+		key  string
+		size int64
+	}
+	var orphans, quarantined, staleExports []object
+	live := map[string]bool{}
+	if err := st.List(ctx, "", func(o storage.ObjectInfo) error {
+		if strings.HasPrefix(o.Key, QuarantinePrefix) {
+			quarantined = append(quarantined, object{o.Key, o.Size})
+			return nil
+		}
+		if strings.HasPrefix(o.Key, ExportPrefix) {
+			// Grace กันไฟล์ของงานที่เพิ่ง Put แต่ยังไม่ได้บันทึก file_path
+			if !exports[o.Key] && now.Sub(o.ModTime) >= opt.Grace {
+				staleExports = append(staleExports, object{o.Key, o.Size})
+			}
+			return nil
+		}
+		rep.Scanned++
+		live[o.Key] = true
+		switch {
+		case refs[o.Key]:
+			rep.Referenced++
+		case now.Sub(o.ModTime) >= opt.Grace:
+			orphans = append(orphans, object{o.Key, o.Size})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// 1) ไม่มีใครอ้าง -> quarantine
+	stamp := strconv.FormatInt(now.Unix(), 10)
+	for _, o := range orphans {
+		if !opt.DryRun {
+			if err := storage.Move(ctx, st, o.key, QuarantinePrefix+stamp+"/"+o.key); err != nil {
+				rep.fail(o.key, err)
+				continue
+			}
+		}
+		rep.Quarantined++
+		rep.QuarantinedBytes += o.size
+		rep.add(o.key, o.size, "quarantine")
+	}
+
+	// 2) + 3) ไฟล์ใน quarantine: มีคนอ้างแล้ว = คืนที่เดิม, ครบเวลา = ลบจริง
+	for _, o := range quarantined {
+		at, orig, ok := parseQuarantineKey(o.key)
+		if !ok {
+			continue
+		}
+		switch {
+		case refs[orig] && !live[orig]:
+			if !opt.DryRun {
+				if err := storage.Move(ctx, st, o.key, orig); err != nil {
+					rep.fail(o.key, err)
+					continue
+				}
+			}
+			live[orig] = true
+			rep.Restored++
+			rep.add(orig, o.size, "restore")
+		case now.Sub(at) >= opt.PurgeAfter:
+			if !opt.DryRun {
+				if err := st.Delete(ctx, o.key); err != nil {
+					rep.fail(o.key, err)
+					continue
+				}
+			}
+			rep.Purged++
+			rep.ReclaimedBytes += o.size
+			rep.add(orig, o.size, "purge")
+		}
+	}
+
+	// 4) export หมดอายุ / ไม่มีงานอ้าง -> ลบ แล้วล้าง file_path ของงานที่หมดอายุ
+	for _, o := range staleExports {
+		if !opt.DryRun {
+			if err := st.Delete(ctx, o.key); err != nil {
+				rep.fail(o.key, err)
+				continue
+			}
+		}
+		rep.ExportsDeleted++
+		rep.ReclaimedBytes += o.size
+		rep.add(o.key, o.size, "purge")
+	}
+	if !opt.DryRun {
+		if err := db.Model(&entity.DataExport{}).
+			Where("file_path <> '' AND expires_at < ?", now).
+			Update("file_path", "").Error; err != nil {
+			rep.fail("data_exports", err)
+		}
+	}
+
+	rep.FinishedAt = time.Now()
+	return rep, nil
+}
+
+// liveExports = key ของไฟล์ export ที่ยังดาวน์โหลดได้
+func liveExports(db *gorm.DB, now time.Time) (map[string]bool, error) {
+	var keys []string
+	if err := db.Model(&entity.DataExport{}).
+		Where("file_path <> '' AND (expires_at IS NULL OR expires_at >= ?)", now).
+		Pluck("file_path", &keys).Error; err != nil {
+		return nil, fmt.Errorf("uploadgc: อ่าน data_exports: %w", err)
+	}
+	out := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		out[k] = true
+	}
+	return out, nil
+}
+
+// acquireLock จอง lease ถ้ายังไม่มีใครถือหรือของเดิมหมดอายุแล้ว (INSERT/UPDATE คำสั่งเดียว ไม่อ่านก่อนเขียน)
+func acquireLock(db *gorm.DB, now time.Time) (string, error) {
+	host, _ := os.Hostname()
+	holder := fmt.Sprintf("%s:%d:%d", host, os.Getpid(), now.UnixNano())
+	res := db.Exec(`INSERT INTO job_locks (name, holder, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+		WHERE job_locks.expires_at < ?`,
+		lockName, holder, now.Add(lockTTL), now)
+	if res.Error != nil {
+		return "", fmt.Errorf("uploadgc: จอง lock: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return "", ErrRunning
+	}
+	return holder, nil
+}
+
+// releaseLock ปล่อยเฉพาะ lease ของตัวเอง (หมดอายุแล้วมีคนอื่นจองต่อ = ไม่แตะ)
+func releaseLock(db *gorm.DB, holder string) {
+	if err := db.Where("name = ? AND holder = ?", lockName, holder).Delete(&entity.JobLock{}).Error; err != nil {
+		log.Println("uploadgc: ปล่อย lock:", err)
+	}
+}
+
+// quarantine/<unix>/<key> -> เวลาที่ย้ายเข้า, key เดิม
+func parseQuarantineKey(k string) (time.Time, string, bool) {
+	rest := strings.TrimPrefix(k, QuarantinePrefix)
+	i := strings.IndexByte(rest, '/')
+	if i <= 0 || i == len(rest)-1 {
+		return time.Time{}, "", false
+	}
+	sec, err := strconv.ParseInt(rest[:i], 10, 64)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return time.Unix(sec, 0), rest[i+1:], true
+}
+
+// referencedKeys = key ทุกไฟล์ที่ DB อ้างอยู่ (รวมรูปย่อทุกขนาด และแถวที่ soft delete ไว้ซึ่งยังกู้คืนได้)
+func referencedKeys(db *gorm.DB) (map[string]bool, error) {
+	sources := []struct {
+		model  interface{}
+		column string
+	}{
+		{&entity.ProductImage{}, "image_path"},
+		{&entity.ProductVariant{}, "image_path"},
+		{&entity.ShopProfile{}, "logo_path"},
+		{&entity.Discountcode{}, "image_url"},
+		{&entity.DMFile{}, "file_url"},
+		{&entity.ReviewPhoto{}, "image_path"},
+	}
+	refs := map[string]bool{}
+	for _, s := range sources {
+		var urls []string
+		if err := db.Unscoped().Model(s.model).Where(s.column+" <> ''").Pluck(s.column, &urls).Error; err != nil {
+			return nil, fmt.Errorf("uploadgc: อ่าน %s: %w", s.column, err)
+		}
+		for _, u := range urls {
+			key, ok := storage.KeyFromURL(u)
+			if !ok {
+				continue
+			}
+			for _, k := range imaging.VariantPaths(key) {
+				refs[k] = true
+			}
+		}
+	}
+	return refs, nil
+}
+
+// StartSweeper เรียก Sweep ทันที 1 ครั้ง แล้วทุก interval ใน goroutine
+func StartSweeper(db *gorm.DB, interval time.Duration) {
+	run := func() {
+		rep, err := Sweep(context.Background(), db, storage.Default(), DefaultOptions())
+		if err != nil {
+			if !errors.Is(err, ErrRunning) {
+				log.Println("uploadgc:", err)
+			}
+			return
+		}
+		if rep.Quarantined+rep.Restored+rep.Purged+rep.ExportsDeleted > 0 || len(rep.Errors) > 0 {
+			log.Printf("uploadgc: quarantined %d (%d bytes), restored %d, purged %d, exports deleted %d (reclaimed %d bytes), errors %d",
+				rep.Quarantined, rep.QuarantinedBytes, rep.Restored, rep.Purged, rep.ExportsDeleted, rep.ReclaimedBytes, len(rep.Errors))
+		}
+	}
+	go func() {
+		run()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for range t.C {
+			run()
+		}
+	}()
+}
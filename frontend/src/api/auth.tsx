@@ -7,8 +7,17 @@ export const currentUser = async (token:any) => await axios.get("http://localhos
     }
 })
 
-export const getAllproducts = async() =>{
-    return await axios.get("http://localhost:8080/api/listAllProducts");
+// รายการสินค้าแบ่งหน้าด้วย cursor: หน้าแรกไม่ต้องส่ง cursor, หน้าถัดไปส่ง next_cursor ที่ได้มา
+// (next_cursor = null คือหมดแล้ว)
+export type ListProductsParams = {
+    cursor?: string;
+    limit?: number;
+    category_id?: number;
+    sort?: "newest" | "price_asc" | "price_desc" | "popular";
+};
+
+export const getAllproducts = async(params: ListProductsParams = {}) =>{
+    return await axios.get("http://localhost:8080/api/listAllProducts", { params });
 };
 
 export const getMyPostProducts = async(token: string) =>{
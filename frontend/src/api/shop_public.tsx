@@ -1,4 +1,5 @@
 import axios from "axios";
+import type { ListProductsParams } from "./auth";
 
 // ถ้า backend ของคุณอยู่ที่ /api/public ให้เปลี่ยน BASE เป็นอันนั้น
 const BASE = "http://localhost:8080/api";
@@ -8,7 +9,8 @@ export const getShopProfileBySellerId = (sellerId: string | number) => {
   return axios.get(`${BASE}/shops/${sellerId}/profile`);
 };
 
-export const getPostProductsBySellerId = (sellerId: string | number) => {
+// แบ่งหน้าด้วย cursor เหมือน getAllproducts
+export const getPostProductsBySellerId = (sellerId: string | number, params: ListProductsParams = {}) => {
   
-  return axios.get(`${BASE}/shops/${sellerId}/posts`);
+  return axios.get(`${BASE}/shops/${sellerId}/posts`, { params });
 };
@@ -13,10 +13,53 @@ axios.interceptors.request.use((config) => {
   return config;
 });
 
-// ดัก 401 -> ล้าง state / redirect ถ้าต้องการ
+// access token อายุสั้น (15 นาที) -> หมดแล้วขอใหม่ด้วย refresh token
+// หลาย request เจอ 401 พร้อมกันให้รอรอบเดียวกัน (refresh token หมุนทุกครั้ง ขอซ้อนกันจะโดนมองว่าถูกขโมย)
+let refreshing: Promise<string | null> | null = null;
+
+export const refreshAccessToken = (): Promise<string | null> => {
+  if (!refreshing) {
+    refreshing = (async () => {
+      const refreshToken = useEcomStore.getState().refreshToken;
+      if (!refreshToken) return null;
+      try {
+        const res = await axios.post("/api/token/refresh", { refresh_token: refreshToken });
+        const token: string | null = res.data?.token || null;
+        if (!token) return null;
+        useEcomStore.getState().setTokens(token, res.data?.refresh_token || null);
+        localStorage.setItem("token", token);
+        window.dispatchEvent(new Event("auth-changed")); // Messenger อ่าน token ใหม่
+        return token;
+      } catch {
+        return null;
+      }
+    })().finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+};
+
+// ดัก 401 -> ลอง refresh แล้วส่งซ้ำ 1 ครั้ง ไม่ได้ค่อยล้าง state
 axios.interceptors.response.use(
   (r) => r,
-  (err) => {
+  async (err) => {
+    const original = err?.config;
+    if (
+      err?.response?.status === 401 &&
+      original &&
+      !original._retried &&
+      !String(original.url ?? "").includes("/api/token/refresh") &&
+      useEcomStore.getState().refreshToken
+    ) {
+      original._retried = true;
+      const token = await refreshAccessToken();
+      if (token) {
+        original.headers = original.headers ?? {};
+        original.headers.Authorization = `Bearer ${token}`;
+        return axios(original);
+      }
+    }
     if (err?.response?.status === 401) {
       useEcomStore.getState().clearPersistedStore();
       // window.location.href = "/login";
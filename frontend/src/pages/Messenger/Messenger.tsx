@@ -1,4 +1,5 @@
 import React, { useEffect, useMemo, useRef, useState } from "react";
+import { refreshAccessToken } from "../../axios.config";
 
 /* ---------- Types ---------- */
 type Member = { ID: number; username?: string; UserName?: string };
@@ -86,7 +87,16 @@ const readStoredUser = (): Member | null => {
   if (uid && !Number.isNaN(Number(uid))) return { ID: Number(uid), username: "Me" };
   return null;
 };
+const readStoreToken = (): string => {
+  try {
+    const raw = localStorage.getItem("ecom-store");
+    return raw ? JSON.parse(raw)?.state?.token || "" : "";
+  } catch {
+    return "";
+  }
+};
 const readToken = () =>
+  readStoreToken() ||
   localStorage.getItem("token") ||
   localStorage.getItem("auth:token") ||
   sessionStorage.getItem("token") ||
@@ -115,11 +125,10 @@ const Messenger: React.FC = () => {
     };
   }, []);
 
-  // ===== Header auth: บังคับใช้ uid เสมอ + แนบ X-User-Id =====
+  // ===== Header auth: ใช้ JWT จริงก่อน (uid:<id> ใช้ได้เฉพาะฝั่ง Go เปิด DEV_AUTH) =====
   const authHeaderValue = useMemo(() => {
-    // ให้ uid นำเสมอ (ฝั่ง Go รองรับ Bearer uid:<id>)
-    if (currentUser?.ID) return `Bearer uid:${currentUser.ID}`;
     if (usableToken(token)) return `Bearer ${token!}`;
+    if (currentUser?.ID) return `Bearer uid:${currentUser.ID}`;
     return "";
   }, [token, currentUser?.ID]);
 
@@ -138,12 +147,20 @@ const Messenger: React.FC = () => {
       init.body = JSON.stringify(jsonBody);
     }
 
-    const res = await fetch(url, {
+    let res = await fetch(url, {
       mode: "cors",
       cache: "no-store",
       ...init,
       headers,
     });
+    // access token หมดอายุ -> ขอใหม่แล้วลองซ้ำ 1 ครั้ง (เหมือน interceptor ของ axios)
+    if (res.status === 401) {
+      const fresh = await refreshAccessToken();
+      if (fresh) {
+        headers.set("Authorization", `Bearer ${fresh}`);
+        res = await fetch(url, { mode: "cors", cache: "no-store", ...init, headers });
+      }
+    }
 
     let payload: any = undefined;
     try {
@@ -9,6 +9,9 @@ const BASE = 'http://localhost:8080';
 const ShopPublic: React.FC = () => {
   const { sellerId } = useParams();
   const [products, setProducts] = useState<any[]>([]);
+  const [nextCursor, setNextCursor] = useState<string | null>(null);
+  const [total, setTotal] = useState(0);
+  const [loadingMore, setLoadingMore] = useState(false);
   const [shopInfo, setShopInfo] = useState<any>(null);
   const [loading, setLoading] = useState(true);
 
@@ -24,28 +27,9 @@ const ShopPublic: React.FC = () => {
 
         setShopInfo(resShop.data?.data ?? null);
 
-        const raw = resPosts.data?.data || [];
-        const mapped = raw.map((item: any) => {
-          const images: string[] =
-            item?.Product?.ProductImage?.map((img: any) =>
-              img?.image_path?.startsWith('http')
-                ? img.image_path
-                : `${BASE}${img?.image_path || ''}`
-            ).filter(Boolean) || [];
-
-          return {
-            postId: item?.ID,
-            productId: item?.Product?.ID,
-            name: item?.Product?.name || '—',
-            category: item?.Category?.name || 'ไม่ระบุ',
-            description: item?.Product?.description || 'ไม่ระบุ',
-            quantity: item?.Product?.quantity || 'ไม่ระบุ',
-            price: item?.Product?.price ?? 0,
-            images,
-          };
-        });
-
-        setProducts(mapped);
+        setProducts(mapPosts(resPosts.data?.data || []));
+        setNextCursor(resPosts.data?.next_cursor ?? null);
+        setTotal(resPosts.data?.total ?? 0);
       } catch (err) {
         console.error("โหลดหน้าร้านสาธารณะล้มเหลว:", err);
       } finally {
@@ -56,6 +40,20 @@ const ShopPublic: React.FC = () => {
     run();
   }, [sellerId]);
 
+  const loadMore = async () => {
+    if (!sellerId || !nextCursor) return;
+    setLoadingMore(true);
+    try {
+      const res = await getPostProductsBySellerId(sellerId, { cursor: nextCursor });
+      setProducts((prev) => [...prev, ...mapPosts(res.data?.data || [])]);
+      setNextCursor(res.data?.next_cursor ?? null);
+    } catch (err) {
+      console.error("โหลดสินค้าเพิ่มล้มเหลว:", err);
+    } finally {
+      setLoadingMore(false);
+    }
+  };
+
   if (loading) return <p>กำลังโหลดข้อมูลร้านค้า...</p>;
   if (!shopInfo) return <p>ไม่พบข้อมูลร้านค้าสำหรับผู้ขาย {sellerId}</p>;
 
@@ -123,7 +121,7 @@ const ShopPublic: React.FC = () => {
 
       {/* Products */}
       <div className="shop-section">
-        <h3><AppstoreOutlined style={{ marginRight: 6 }} /> สินค้าในร้าน ({products.length})</h3>
+        <h3><AppstoreOutlined style={{ marginRight: 6 }} /> สินค้าในร้าน ({total})</h3>
         {products.length === 0 ? (
           <p>ยังไม่มีสินค้าในร้านนี้</p>
         ) : (
@@ -133,11 +131,40 @@ const ShopPublic: React.FC = () => {
             ))}
           </div>
         )}
+        {nextCursor && (
+          <div style={{ textAlign: 'center', marginTop: 16 }}>
+            <button type="button" onClick={loadMore} disabled={loadingMore}>
+              {loadingMore ? 'กำลังโหลด...' : 'โหลดสินค้าเพิ่ม'}
+            </button>
+          </div>
+        )}
       </div>
     </div>
   );
 };
 
+// แปลงโพสต์จาก API เป็นข้อมูลที่การ์ดใช้
+const mapPosts = (raw: any[]) =>
+  raw.map((item: any) => {
+    const images: string[] =
+      item?.Product?.ProductImage?.map((img: any) =>
+        img?.image_path?.startsWith('http')
+          ? img.image_path
+          : `${BASE}${img?.image_path || ''}`
+      ).filter(Boolean) || [];
+
+    return {
+      postId: item?.ID,
+      productId: item?.Product?.ID,
+      name: item?.Product?.name || '—',
+      category: item?.Category?.name || 'ไม่ระบุ',
+      description: item?.Product?.description || 'ไม่ระบุ',
+      quantity: item?.Product?.quantity || 'ไม่ระบุ',
+      price: item?.Product?.price ?? 0,
+      images,
+    };
+  });
+
 const PublicProductCard: React.FC<{ product: any }> = ({ product }) => {
   const imgs: string[] = Array.isArray(product.images) ? product.images : [];
   const [active, setActive] = useState(0);
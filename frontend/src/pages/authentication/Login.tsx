@@ -1,8 +1,11 @@
-import { useState } from "react";
+import { useEffect, useState } from "react";
+import axios from "axios";
 import { Button, Card, Form, Input, message, Row, Col, Typography } from "antd";
 import type { LoginRequest } from "../../../interfaces/Login";
 import { useNavigate } from "react-router-dom";
 import useEcomStore from "../../store/ecom-store";
+import { persistAuth } from "./persistAuth";
+import TwoFactorForm from "./TwoFactorForm";
 
 const { Title, Text } = Typography;
 
@@ -10,27 +13,59 @@ export default function LoginForm() {
   const navigate = useNavigate();
   const [messageApi, contextHolder] = message.useMessage();
   const [loading, setLoading] = useState(false);
+  // บัญชีที่เปิด 2FA: ได้ challenge มาแทน token แล้วสลับไปถามรหัส
+  const [challenge, setChallenge] = useState<string | null>(null);
 
   const actionLogin = useEcomStore((state: any) => state.actionLogin);
   const tokenInStore = useEcomStore((state: any) => state.token);
   console.log("token from zustand:", tokenInStore);
 
-  const persistAuth = (user: any, token?: string) => {
-    const ID = Number(user?.ID ?? user?.id);
-    const username = user?.UserName ?? user?.username ?? user?.userName ?? "Me";
-    if (!ID || Number.isNaN(ID)) throw new Error("Invalid user ID from login response");
+  // ผู้ให้บริการ OIDC ที่ backend เปิดไว้ (ไม่มี = ไม่แสดงปุ่ม)
+  const [providers, setProviders] = useState<string[]>([]);
+  useEffect(() => {
+    axios
+      .get("/api/oauth/providers")
+      .then((res) => setProviders(res.data?.data ?? []))
+      .catch(() => setProviders([]));
+  }, []);
 
-    localStorage.setItem("auth:user", JSON.stringify({ ID, username }));
-    localStorage.setItem("uid", String(ID));          // dev mode header: Bearer uid:<ID>
-    if (token) localStorage.setItem("token", token);  // JWT (ถ้ามี)
-
-    window.dispatchEvent(new Event("auth-changed"));  // แจ้งให้ Messenger/หน้าอื่นรีโหลดผู้ใช้
+  // start ตั้ง cookie oauth_state ไว้ (ต้อง withCredentials) แล้วค่อยไปหน้า login ของผู้ให้บริการ
+  const startOAuth = async (provider: string) => {
+    setLoading(true);
+    try {
+      const res = await axios.get(`/api/oauth/${encodeURIComponent(provider)}/start`, {
+        withCredentials: true,
+      });
+      window.location.href = res.data.auth_url;
+    } catch (err: any) {
+      messageApi.error(err?.response?.data?.message || "ไม่สามารถเชื่อมต่อผู้ให้บริการได้");
+      setLoading(false);
+    }
   };
 
   const onFinish = async (values: LoginRequest) => {
     setLoading(true);
     try {
-      const { user, token } = await actionLogin(values);
+      const res = await actionLogin(values);
+      if (res.mfaRequired) {
+        setChallenge(res.challenge);
+        return;
+      }
+      onLoggedIn(res.user, res.token);
+    } catch (err: any) {
+      const errMsg =
+        err?.response?.data?.message ||
+        err?.response?.data?.error ||
+        err?.message ||
+        "Login failed";
+      messageApi.error(errMsg);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const onLoggedIn = (user: any, token: string) => {
+    try {
       persistAuth(user, token);
 
       messageApi.success({
@@ -43,14 +78,7 @@ export default function LoginForm() {
         },
       });
     } catch (err: any) {
-      const errMsg =
-        err?.response?.data?.message ||
-        err?.response?.data?.error ||
-        err?.message ||
-        "Login failed";
-      messageApi.error(errMsg);
-    } finally {
-      setLoading(false);
+      messageApi.error(err?.message || "Login failed");
     }
   };
 
@@ -80,6 +108,15 @@ export default function LoginForm() {
                 <Text type="secondary">Access your account</Text>
               </Col>
 
+              {challenge ? (
+              <Col span={24}>
+                <TwoFactorForm
+                  challenge={challenge}
+                  onSuccess={onLoggedIn}
+                  onCancel={() => setChallenge(null)}
+                />
+              </Col>
+              ) : (
               <Col span={24}>
                 <Form<LoginRequest>
                   name="login"
@@ -131,9 +168,23 @@ export default function LoginForm() {
                     >
                       Register
                     </Button>
+
+                    {providers.map((p) => (
+                      <Button
+                        key={p}
+                        type="default"
+                        block
+                        disabled={loading}
+                        style={{ marginTop: 12, width: "100%", height: 48, fontSize: 16, borderRadius: 8 }}
+                        onClick={() => startOAuth(p)}
+                      >
+                        Sign in with {p}
+                      </Button>
+                    ))}
                   </Form.Item>
                 </Form>
               </Col>
+              )}
             </Row>
           </Card>
         </Col>
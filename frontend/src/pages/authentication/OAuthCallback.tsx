@@ -0,0 +1,89 @@
+import { useEffect, useRef, useState } from "react";
+import { Button, Card, Result, Spin } from "antd";
+import { useNavigate, useParams, useSearchParams } from "react-router-dom";
+import useEcomStore from "../../store/ecom-store";
+import { persistAuth } from "./persistAuth";
+import TwoFactorForm from "./TwoFactorForm";
+
+// ผู้ให้บริการ OIDC redirect กลับมาที่ /oauth/:provider/callback?code=&state=
+export default function OAuthCallback() {
+  const navigate = useNavigate();
+  const { provider = "" } = useParams();
+  const [params] = useSearchParams();
+  const actionOAuthLogin = useEcomStore((state: any) => state.actionOAuthLogin);
+  const [error, setError] = useState<string | null>(null);
+  // บัญชีที่เปิด 2FA: callback ตอบ challenge มาแทน token
+  const [challenge, setChallenge] = useState<string | null>(null);
+
+  // state ใช้ได้ครั้งเดียว กัน StrictMode เรียก effect ซ้ำ
+  const started = useRef(false);
+
+  useEffect(() => {
+    if (started.current) return;
+    started.current = true;
+
+    if (params.get("error")) {
+      setError("ยกเลิกหรือไม่ได้รับอนุญาตให้เข้าสู่ระบบ");
+      return;
+    }
+    const code = params.get("code");
+    const state = params.get("state");
+    if (!code || !state) {
+      setError("ลิงก์เข้าสู่ระบบไม่ถูกต้อง");
+      return;
+    }
+
+    (async () => {
+      try {
+        const res = await actionOAuthLogin(provider, code, state);
+        if (res.mfaRequired) {
+          setChallenge(res.challenge);
+          return;
+        }
+        persistAuth(res.user, res.token);
+        navigate("/", { replace: true });
+      } catch (err: any) {
+        setError(
+          err?.response?.data?.message ||
+            err?.response?.data?.error ||
+            err?.message ||
+            "Login failed"
+        );
+      }
+    })();
+  }, [actionOAuthLogin, navigate, params, provider]);
+
+  return (
+    <div style={{ minHeight: "60vh", display: "flex", alignItems: "center", justifyContent: "center" }}>
+      {error ? (
+        <Card style={{ width: 500, borderRadius: 16 }}>
+          <Result
+            status="error"
+            title="เข้าสู่ระบบไม่สำเร็จ"
+            subTitle={error}
+            extra={
+              <Button type="primary" onClick={() => navigate("/login", { replace: true })}>
+                กลับไปหน้าเข้าสู่ระบบ
+              </Button>
+            }
+          />
+        </Card>
+      ) : challenge ? (
+        <Card style={{ width: 500, borderRadius: 16 }}>
+          <TwoFactorForm
+            challenge={challenge}
+            onSuccess={(user, token) => {
+              persistAuth(user, token);
+              navigate("/", { replace: true });
+            }}
+            onCancel={() => navigate("/login", { replace: true })}
+          />
+        </Card>
+      ) : (
+        <Spin size="large" tip="กำลังเข้าสู่ระบบ…">
+          <div style={{ width: 200, height: 80 }} />
+        </Spin>
+      )}
+    </div>
+  );
+}
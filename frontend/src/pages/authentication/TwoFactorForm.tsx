@@ -0,0 +1,100 @@
+import { useState } from "react";
+import { Button, Form, Input, Typography, message } from "antd";
+import useEcomStore from "../../store/ecom-store";
+
+const { Title, Text } = Typography;
+
+type Props = {
+  challenge: string;
+  onSuccess: (user: any, token: string) => void;
+  onCancel: () => void; // กลับไปกรอกรหัสผ่านใหม่ (challenge หมดอายุ/ยกเลิก)
+};
+
+// ขั้นที่ 2 ของการเข้าสู่ระบบเมื่อบัญชีเปิด 2FA (ใช้ทั้งหน้า Login และ OAuthCallback)
+export default function TwoFactorForm({ challenge, onSuccess, onCancel }: Props) {
+  const [messageApi, contextHolder] = message.useMessage();
+  const [loading, setLoading] = useState(false);
+  const [useRecovery, setUseRecovery] = useState(false);
+  const actionLoginTwoFactor = useEcomStore((state: any) => state.actionLoginTwoFactor);
+
+  const onFinish = async ({ code }: { code: string }) => {
+    setLoading(true);
+    try {
+      const { user, token } = await actionLoginTwoFactor(challenge, code);
+      onSuccess(user, token);
+    } catch (err: any) {
+      const status = err?.response?.status;
+      const data = err?.response?.data;
+      if (status === 429) {
+        const secs = Number(data?.retry_after) || 0;
+        messageApi.error(
+          secs > 0
+            ? `กรอกรหัสผิดหลายครั้ง กรุณารอ ${Math.ceil(secs / 60)} นาทีแล้วลองใหม่`
+            : "กรอกรหัสผิดหลายครั้ง กรุณารอสักครู่แล้วลองใหม่"
+        );
+      } else if (status === 401 && String(data?.message ?? "").includes("challenge")) {
+        // challenge ใช้ไม่ได้แล้ว ต้องเริ่ม login ใหม่
+        messageApi.error("หมดเวลายืนยันตัวตน กรุณาเข้าสู่ระบบอีกครั้ง");
+        onCancel();
+      } else if (status === 401) {
+        messageApi.error("รหัสยืนยันไม่ถูกต้อง");
+      } else {
+        messageApi.error(data?.message || data?.error || err?.message || "Login failed");
+      }
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <>
+      {contextHolder}
+      <div style={{ textAlign: "center", marginBottom: 20 }}>
+        <Title level={3} style={{ margin: 0, color: "#1890ff" }}>ยืนยันตัวตน 2 ขั้นตอน</Title>
+        <Text type="secondary">
+          {useRecovery
+            ? "กรอก recovery code ที่ได้ตอนเปิด 2FA (ใช้ได้ครั้งเดียว)"
+            : "กรอกรหัส 6 หลักจากแอป Authenticator"}
+        </Text>
+      </div>
+
+      <Form<{ code: string }> key={String(useRecovery)} onFinish={onFinish} layout="vertical" size="large" autoComplete="off">
+        <Form.Item
+          name="code"
+          rules={
+            useRecovery
+              ? [{ required: true, message: "กรุณากรอก recovery code" }]
+              : [
+                  { required: true, message: "กรุณากรอกรหัส 6 หลัก" },
+                  { pattern: /^\s*\d{6}\s*$/, message: "รหัสต้องเป็นตัวเลข 6 หลัก" },
+                ]
+          }
+        >
+          <Input
+            autoFocus
+            disabled={loading}
+            inputMode={useRecovery ? "text" : "numeric"}
+            autoComplete="one-time-code"
+            maxLength={useRecovery ? 64 : 6}
+            placeholder={useRecovery ? "xxxxx-xxxxx" : "123456"}
+          />
+        </Form.Item>
+
+        <Button
+          type="primary"
+          htmlType="submit"
+          loading={loading}
+          style={{ width: "100%", height: 48, fontSize: 16, borderRadius: 8 }}
+        >
+          ยืนยัน
+        </Button>
+        <Button type="link" block disabled={loading} style={{ marginTop: 8 }} onClick={() => setUseRecovery(!useRecovery)}>
+          {useRecovery ? "ใช้รหัสจากแอป Authenticator" : "ใช้ recovery code แทน"}
+        </Button>
+        <Button type="link" block disabled={loading} onClick={onCancel}>
+          กลับไปหน้าเข้าสู่ระบบ
+        </Button>
+      </Form>
+    </>
+  );
+}
@@ -0,0 +1,12 @@
+// เก็บข้อมูลผู้ใช้หลังล็อกอินสำเร็จ (หน้า Login และ OAuthCallback ใช้ร่วมกัน)
+export const persistAuth = (user: any, token?: string) => {
+  const ID = Number(user?.ID ?? user?.id);
+  const username = user?.UserName ?? user?.username ?? user?.userName ?? "Me";
+  if (!ID || Number.isNaN(ID)) throw new Error("Invalid user ID from login response");
+
+  localStorage.setItem("auth:user", JSON.stringify({ ID, username }));
+  localStorage.setItem("uid", String(ID));          // dev mode header: Bearer uid:<ID>
+  if (token) localStorage.setItem("token", token);  // JWT (ถ้ามี)
+
+  window.dispatchEvent(new Event("auth-changed"));  // แจ้งให้ Messenger/หน้าอื่นรีโหลดผู้ใช้
+};
@@ -4,7 +4,7 @@ import './Productlist.css'
 
 import { useEffect, useState } from "react";
 import { getAllproducts } from '../../../api/auth';
-import { message } from 'antd';
+import { Button, message } from 'antd';
 import axios from 'axios';
 import { getAllcategory } from '../../../api/categoty';
 import { Link } from 'react-router-dom';
@@ -13,21 +13,49 @@ import Cardlistproduct from './Cardlistproduct';
 function ProductList() {
 
    const [products, setProducts] = useState<any[]>([]);
+   const [nextCursor, setNextCursor] = useState<string | null>(null);
+   const [loadingMore, setLoadingMore] = useState(false);
    const [categories, setCategories] = useState<any[]>([]);
    const [selectedCategory, setSelectedCategory] = useState("ทั้งหมด");
 
+   // กรองหมวดที่ backend (รวมหมวดย่อย) เพราะหน้าแรกมีสินค้าแค่บางส่วน
+   const categoryId =
+      selectedCategory === "ทั้งหมด"
+         ? undefined
+         : categories.find((c) => c?.name === selectedCategory)?.ID;
+
    useEffect(() => {
+      let cancelled = false;
       const fetchData = async () => {
          try {
-            const res = await getAllproducts();
-            console.log(res);
+            const res = await getAllproducts({ category_id: categoryId });
+            if (cancelled) return;
             setProducts(res.data?.data || []);
+            setNextCursor(res.data?.next_cursor ?? null);
          } catch (err) {
             console.error("โหลดสินค้าล้มเหลว:", err);
          }
       };
       fetchData();
-   }, []);
+      return () => {
+         cancelled = true;
+      };
+   }, [categoryId]);
+
+   const loadMore = async () => {
+      if (!nextCursor) return;
+      setLoadingMore(true);
+      try {
+         const res = await getAllproducts({ category_id: categoryId, cursor: nextCursor });
+         setProducts((prev) => [...prev, ...(res.data?.data || [])]);
+         setNextCursor(res.data?.next_cursor ?? null);
+      } catch (err) {
+         console.error("โหลดสินค้าเพิ่มล้มเหลว:", err);
+         message.error("โหลดสินค้าเพิ่มล้มเหลว");
+      } finally {
+         setLoadingMore(false);
+      }
+   };
 
    useEffect(() => {
       const fetchCategories = async () => {
@@ -43,10 +71,7 @@ function ProductList() {
       fetchCategories();
    }, []);
 
-   const filteredProducts =
-      selectedCategory === "ทั้งหมด"
-         ? products
-         : products.filter((p) => p?.Category?.name === selectedCategory);
+   const filteredProducts = products;
 
 
 
@@ -62,6 +87,11 @@ function ProductList() {
             </nav>
             <section>
                <Cardlistproduct filteredProducts={filteredProducts} />
+               {nextCursor && (
+                  <div style={{ textAlign: "center", margin: "24px 0" }}>
+                     <Button onClick={loadMore} loading={loadingMore}>โหลดสินค้าเพิ่ม</Button>
+                  </div>
+               )}
 
 
             </section>
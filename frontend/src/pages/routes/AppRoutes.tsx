@@ -7,6 +7,7 @@ import Profile from "../ShopProfile/Profile";
 import Layout from "../layout/Layout";
 import RegisterForm from "../authentication/Register";
 import LoginForm from "../authentication/Login";
+import OAuthCallback from "../authentication/OAuthCallback";
 import ProtectRouteUser from "./ProtectRouteUser";
 import EditShopProfile from "../ShopProfile/EditShopProfile/EditShopProfile";
 import AdminLayout from "../admin/Admin";
@@ -26,6 +27,7 @@ const router = createBrowserRouter([
     children: [
       { index: true, element: <Productlist /> },
       { path: "login", element: <LoginForm /> },
+      { path: "oauth/:provider/callback", element: <OAuthCallback /> },
       { path: "Admin", element: <AdminLayout /> },
       { path: "shop/:sellerId", element: <ShopPublic /> },
       { path: "Cart", element: <Cart /> },
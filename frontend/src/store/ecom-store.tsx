@@ -15,16 +15,25 @@ type User = {
   hasShop?: boolean;
 };
 
+// บัญชีที่เปิด 2FA: login ตอบ challenge มาแทน token ต้องส่งรหัสไปที่ /api/login/2fa ต่อ
+type LoginResult =
+  | { mfaRequired?: false; user: User; token: string; hasShop: boolean }
+  | { mfaRequired: true; challenge: string; expiresIn?: number };
+
 type StoreState = {
   user: User | null;
   token: string | null;
+  refreshToken: string | null; // ใช้ขอ access token ใหม่เมื่อหมดอายุ (ดู axios.config.ts)
   hasShop: boolean | null;
   carts: [];
 
-  actionLogin: (values: LoginRequest) => Promise<{ user: User; token: string; hasShop: boolean }>;
+  actionLogin: (values: LoginRequest) => Promise<LoginResult>;
+  actionLoginTwoFactor: (challenge: string, code: string) => Promise<{ user: User; token: string; hasShop: boolean }>;
+  actionOAuthLogin: (provider: string, code: string, state: string) => Promise<LoginResult>;
   actionRegister: (values: any) => Promise<{ user: User; token?: string }>;
 
   refreshUser: () => Promise<User | null>;
+  setTokens: (token: string, refreshToken: string | null) => void;
   logout: () => void;
   GettotalPrice: () => number;
   clearPersistedStore: () => void;
@@ -35,9 +44,30 @@ type StoreState = {
   authHeader: () => { Authorization?: string };
 };
 
+// เก็บ user/token จาก payload ของ login (/api/login, /api/login/2fa, OIDC callback ตอบรูปแบบเดียวกัน)
+const applyLogin = (set: any, data: any) => {
+  const user: User | null = data?.user || null;
+  const token: string | null = data?.token || null;
+  const refreshToken: string | null = data?.refresh_token || null;
+  if (!user || !token) {
+    throw new Error(data?.message || "Invalid login response");
+  }
+
+  // ถ้ามี hasShop มากับ payload ก็ใช้เลย ไม่ก็ประเมินจากการมี sellerID
+  const hasShop =
+    typeof user.hasShop === "boolean" ? user.hasShop : user.sellerID != null;
+
+  // ✅ บังคับ normalize sellerID เป็น number | null
+  const normalizedUser: User = { ...user, sellerID: user.sellerID ?? null };
+
+  set({ user: normalizedUser, token, refreshToken, hasShop });
+  return { user: normalizedUser, token, hasShop };
+};
+
 const ecomstore = (set: any, get: any): StoreState => ({
   user: null,
   token: null,
+  refreshToken: null,
   hasShop: null,
   carts: [],
 
@@ -82,30 +112,43 @@ const ecomstore = (set: any, get: any): StoreState => ({
       const res = await axios.post("/api/login", values, {
         headers: { "Content-Type": "application/json" },
       });
-
-      const user: User | null = res.data?.user || null;
-      const token: string | null = res.data?.token || null;
-
-      if (!user || !token) {
-        set({ user: null, token: null, hasShop: null });
-        throw new Error(res.data?.message || "Invalid login response");
+      if (res.data?.mfa_required) {
+        set({ user: null, token: null, refreshToken: null, hasShop: null });
+        return { mfaRequired: true, challenge: res.data.challenge, expiresIn: res.data.expires_in };
       }
-
-      // ถ้ามี hasShop มากับ payload ก็ใช้เลย ไม่ก็ประเมินจากการมี sellerID
-      const hasShop =
-        typeof user.hasShop === "boolean" ? user.hasShop : user.sellerID != null;
-
-      // ✅ บังคับ normalize sellerID เป็น number | null
-      const normalizedUser: User = { ...user, sellerID: user.sellerID ?? null };
-
-      set({ user: normalizedUser, token, hasShop });
-      return { user: normalizedUser, token, hasShop };
+      return applyLogin(set, res.data);
     } catch (error) {
-      set({ user: null, token: null, hasShop: null });
+      set({ user: null, token: null, refreshToken: null, hasShop: null });
       throw error;
     }
   },
 
+  // ---------- LOGIN ขั้นที่ 2 (2FA) ----------
+  // code = รหัส 6 หลักจากแอป authenticator หรือ recovery code; challenge ใช้ได้ครั้งเดียวและมีอายุสั้น
+  actionLoginTwoFactor: async (challenge, code) => {
+    const res = await axios.post(
+      "/api/login/2fa",
+      { challenge, code: code.trim() },
+      { headers: { "Content-Type": "application/json" } }
+    );
+    return applyLogin(set, res.data);
+  },
+
+  // ---------- LOGIN ผ่าน OIDC ----------
+  // ผู้ให้บริการ redirect กลับมาหน้าเว็บพร้อม code/state -> ส่งต่อให้ backend (ต้องแนบ cookie oauth_state ที่ได้ตอน start)
+  actionOAuthLogin: async (provider, code, state) => {
+    const res = await axios.get(`/api/oauth/${encodeURIComponent(provider)}/callback`, {
+      params: { code, state },
+      withCredentials: true,
+    });
+    if (res.data?.mfa_required) {
+      // ล้าง session เก่า กัน interceptor เอา refresh token เดิมไปลองซ้ำตอนกรอกรหัส 2FA ผิด
+      set({ user: null, token: null, refreshToken: null, hasShop: null });
+      return { mfaRequired: true, challenge: res.data.challenge, expiresIn: res.data.expires_in };
+    }
+    return applyLogin(set, res.data);
+  },
+
   // ---------- REGISTER ----------
   actionRegister: async (values) => {
     const res = await axios.post("/api/register", values, {
@@ -114,6 +157,7 @@ const ecomstore = (set: any, get: any): StoreState => ({
 
     const user: User | null = res.data?.user || null;
     const token: string | null = res.data?.token || null;
+    const refreshToken: string | null = res.data?.refresh_token || null;
 
     if (!user) throw new Error(res.data?.message || "Invalid register response");
 
@@ -126,6 +170,7 @@ const ecomstore = (set: any, get: any): StoreState => ({
     set({
       user: token ? normalizedUser : null,
       token: token || null,
+      refreshToken: token ? refreshToken : null,
       hasShop: token ? hasShop : null,
     });
 
@@ -164,20 +209,23 @@ const ecomstore = (set: any, get: any): StoreState => ({
       return normalizedUser;
     } catch (err: any) {
       if (err?.response?.status === 401) {
-        set({ user: null, token: null, hasShop: null });
+        set({ user: null, token: null, refreshToken: null, hasShop: null });
       }
       throw err;
     }
   },
 
+  // ---------- TOKEN ใหม่จาก /api/token/refresh ----------
+  setTokens: (token, refreshToken) => set({ token, refreshToken }),
+
   // ---------- LOGOUT ----------
-  logout: () => set({ user: null, token: null, hasShop: null }),
+  logout: () => set({ user: null, token: null, refreshToken: null, hasShop: null }),
 
   // ---------- CLEAR PERSISTED ----------
   clearPersistedStore: () => {
     // ต้องถูกเรียกหลัง useEcomStore ถูกประกาศ (ดูบล็อคด้านล่าง)
     useEcomStore.persist.clearStorage();
-    set({ user: null, token: null, hasShop: null });
+    set({ user: null, token: null, refreshToken: null, hasShop: null });
   },
 });
 